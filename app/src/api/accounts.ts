@@ -0,0 +1,62 @@
+import apiClient from './client';
+import type { Account, AccountBalance, AccountType, CashFlowForecast } from '../types';
+
+interface AccountInput {
+  name?: string;
+  type?: AccountType;
+  currency?: string;
+  institution?: string;
+  owner_user_id?: number;
+  opening_balance?: number;
+  opening_date?: string;
+}
+
+export const accountsApi = {
+  list: async (workspaceId: number): Promise<{ accounts: Account[] }> => {
+    const response = await apiClient.get<{ accounts: Account[] }>(`/workspaces/${workspaceId}/accounts`);
+    return response.data;
+  },
+
+  create: async (
+    workspaceId: number,
+    data: AccountInput & { name: string; type: AccountType }
+  ): Promise<{ account: Account }> => {
+    const response = await apiClient.post<{ account: Account }>(`/workspaces/${workspaceId}/accounts`, data);
+    return response.data;
+  },
+
+  update: async (
+    workspaceId: number,
+    accountId: number,
+    data: AccountInput & { clear_owner?: boolean }
+  ): Promise<{ account: Account }> => {
+    const response = await apiClient.put<{ account: Account }>(`/workspaces/${workspaceId}/accounts/${accountId}`, data);
+    return response.data;
+  },
+
+  delete: async (workspaceId: number, accountId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/accounts/${accountId}`);
+  },
+
+  balances: async (workspaceId: number, on?: string): Promise<{ balances: AccountBalance[] }> => {
+    const response = await apiClient.get<{ balances: AccountBalance[] }>(`/workspaces/${workspaceId}/accounts/balances`, {
+      params: { on },
+    });
+    return response.data;
+  },
+
+  balance: async (workspaceId: number, accountId: number, on?: string): Promise<{ balance: AccountBalance }> => {
+    const response = await apiClient.get<{ balance: AccountBalance }>(
+      `/workspaces/${workspaceId}/accounts/${accountId}/balance`,
+      { params: { on } }
+    );
+    return response.data;
+  },
+
+  forecast: async (workspaceId: number, days?: number, historyMonths?: number): Promise<CashFlowForecast> => {
+    const response = await apiClient.get<CashFlowForecast>(`/workspaces/${workspaceId}/accounts/forecast`, {
+      params: { days, history_months: historyMonths },
+    });
+    return response.data;
+  },
+};
@@ -1,5 +1,6 @@
 import apiClient from './client';
-import type { Area, YearlyAreaSummary } from '../types';
+import type { Area, RealTerms, RealTermsOptions, YearlyAreaSummary } from '../types';
+import { realTermsParams } from './cpi';
 
 interface CreateAreaRequest {
   name: string;
@@ -35,6 +36,7 @@ export interface AreaSummaryResponse {
   month: string;
   total_spent: number;
   areas: AreaSummaryItem[];
+  real_terms?: RealTerms;
 }
 
 export const areasApi = {
@@ -72,16 +74,18 @@ export const areasApi = {
     await apiClient.delete(`/workspaces/${workspaceId}/areas/${areaId}`);
   },
 
-  getSummary: async (workspaceId: number, month: string): Promise<AreaSummaryResponse> => {
+  getSummary: async (workspaceId: number, month: string, options?: RealTermsOptions): Promise<AreaSummaryResponse> => {
     const response = await apiClient.get<AreaSummaryResponse>(
-      `/workspaces/${workspaceId}/areas/summary?month=${month}`
+      `/workspaces/${workspaceId}/areas/summary?month=${month}`,
+      { params: realTermsParams(options) }
     );
     return response.data;
   },
 
-  getYearlySummary: async (workspaceId: number, year: string): Promise<YearlyAreaSummary> => {
+  getYearlySummary: async (workspaceId: number, year: string, options?: RealTermsOptions): Promise<YearlyAreaSummary> => {
     const response = await apiClient.get<YearlyAreaSummary>(
-      `/workspaces/${workspaceId}/areas/yearly-summary?year=${year}`
+      `/workspaces/${workspaceId}/areas/yearly-summary?year=${year}`,
+      { params: realTermsParams(options) }
     );
     return response.data;
   },
@@ -0,0 +1,97 @@
+import apiClient from './client';
+import type { Budget, BudgetAlert, BudgetReport, BudgetTemplate } from '../types';
+
+export interface BudgetInput {
+  month: string;
+  category_id?: number;
+  area_id?: number;
+  amount: number;
+  rollover?: boolean;
+}
+
+export interface BudgetTemplateInput {
+  category_id?: number;
+  area_id?: number;
+  amount: number;
+  rollover?: boolean;
+  start_month: string;
+  end_month?: string;
+}
+
+export const budgetsApi = {
+  list: async (workspaceId: number, month: string): Promise<{ budgets: Budget[] }> => {
+    const response = await apiClient.get<{ budgets: Budget[] }>(`/workspaces/${workspaceId}/budgets`, {
+      params: { month },
+    });
+    return response.data;
+  },
+
+  applyTemplates: async (workspaceId: number, month: string): Promise<{ budgets: Budget[] }> => {
+    const response = await apiClient.post<{ budgets: Budget[] }>(`/workspaces/${workspaceId}/budgets/apply-templates`, {
+      month,
+    });
+    return response.data;
+  },
+
+  status: async (workspaceId: number, month: string): Promise<BudgetReport> => {
+    const response = await apiClient.get<BudgetReport>(`/workspaces/${workspaceId}/budgets/status`, {
+      params: { month },
+    });
+    return response.data;
+  },
+
+  create: async (workspaceId: number, data: BudgetInput): Promise<{ budget: Budget }> => {
+    const response = await apiClient.post<{ budget: Budget }>(`/workspaces/${workspaceId}/budgets`, data);
+    return response.data;
+  },
+
+  update: async (
+    workspaceId: number,
+    budgetId: number,
+    data: { amount?: number; rollover?: boolean }
+  ): Promise<{ budget: Budget }> => {
+    const response = await apiClient.put<{ budget: Budget }>(`/workspaces/${workspaceId}/budgets/${budgetId}`, data);
+    return response.data;
+  },
+
+  delete: async (workspaceId: number, budgetId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/budgets/${budgetId}`);
+  },
+
+  listTemplates: async (workspaceId: number): Promise<{ templates: BudgetTemplate[] }> => {
+    const response = await apiClient.get<{ templates: BudgetTemplate[] }>(`/workspaces/${workspaceId}/budget-templates`);
+    return response.data;
+  },
+
+  createTemplate: async (workspaceId: number, data: BudgetTemplateInput): Promise<{ template: BudgetTemplate }> => {
+    const response = await apiClient.post<{ template: BudgetTemplate }>(`/workspaces/${workspaceId}/budget-templates`, data);
+    return response.data;
+  },
+
+  updateTemplate: async (
+    workspaceId: number,
+    templateId: number,
+    data: { amount?: number; rollover?: boolean; end_month?: string }
+  ): Promise<{ template: BudgetTemplate }> => {
+    const response = await apiClient.put<{ template: BudgetTemplate }>(
+      `/workspaces/${workspaceId}/budget-templates/${templateId}`,
+      data
+    );
+    return response.data;
+  },
+
+  deleteTemplate: async (workspaceId: number, templateId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/budget-templates/${templateId}`);
+  },
+
+  alerts: async (workspaceId: number, month?: string, pending?: boolean): Promise<{ alerts: BudgetAlert[] }> => {
+    const response = await apiClient.get<{ alerts: BudgetAlert[] }>(`/workspaces/${workspaceId}/budget-alerts`, {
+      params: { month, pending: pending ? 'true' : undefined },
+    });
+    return response.data;
+  },
+
+  acknowledgeAlert: async (workspaceId: number, alertId: number): Promise<void> => {
+    await apiClient.post(`/workspaces/${workspaceId}/budget-alerts/${alertId}/acknowledge`);
+  },
+};
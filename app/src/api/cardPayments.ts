@@ -0,0 +1,37 @@
+import apiClient from './client';
+import type { CardPayment, CardPaymentCandidate } from '../types';
+
+interface CardPaymentInput {
+  transaction_id: number;
+  card_account_id: number;
+  import_batch_id?: number;
+  card_transaction_id?: number;
+}
+
+export const cardPaymentsApi = {
+  list: async (workspaceId: number): Promise<{ card_payments: CardPayment[] }> => {
+    const response = await apiClient.get<{ card_payments: CardPayment[] }>(`/workspaces/${workspaceId}/card-payments`);
+    return response.data;
+  },
+
+  candidates: async (workspaceId: number, from?: string, to?: string): Promise<{ candidates: CardPaymentCandidate[] }> => {
+    const response = await apiClient.get<{ candidates: CardPaymentCandidate[] }>(
+      `/workspaces/${workspaceId}/card-payments/candidates`,
+      { params: { from, to } }
+    );
+    return response.data;
+  },
+
+  create: async (workspaceId: number, data: CardPaymentInput): Promise<{ card_payment: CardPayment }> => {
+    const response = await apiClient.post<{ card_payment: CardPayment }>(`/workspaces/${workspaceId}/card-payments`, data);
+    return response.data;
+  },
+
+  delete: async (workspaceId: number, paymentId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/card-payments/${paymentId}`);
+  },
+
+  setStatementBalance: async (workspaceId: number, batchId: number, statementBalance: number | null): Promise<void> => {
+    await apiClient.put(`/workspaces/${workspaceId}/import-batches/${batchId}`, { statement_balance: statementBalance });
+  },
+};
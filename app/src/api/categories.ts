@@ -1,8 +1,9 @@
 import apiClient from './client';
-import type { Category } from '../types';
+import type { Category, CategoryReassignment } from '../types';
 
 interface CreateCategoryRequest {
   name: string;
+  parent_id?: number | null;
   area_id?: number | null;
   color?: string;
   icon?: string;
@@ -10,6 +11,7 @@ interface CreateCategoryRequest {
 
 interface UpdateCategoryRequest {
   name?: string;
+  parent_id?: number | null;
   area_id?: number | null;
   color?: string;
   icon?: string;
@@ -46,7 +48,21 @@ export const categoriesApi = {
     return response.data;
   },
 
-  delete: async (workspaceId: number, categoryId: number): Promise<void> => {
-    await apiClient.delete(`/workspaces/${workspaceId}/categories/${categoryId}`);
+  delete: async (workspaceId: number, categoryId: number, reassignTo?: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/categories/${categoryId}`, {
+      params: reassignTo ? { reassign_to: reassignTo } : undefined,
+    });
+  },
+
+  merge: async (
+    workspaceId: number,
+    categoryId: number,
+    targetId: number
+  ): Promise<{ result: CategoryReassignment }> => {
+    const response = await apiClient.post<{ result: CategoryReassignment }>(
+      `/workspaces/${workspaceId}/categories/${categoryId}/merge`,
+      { target_id: targetId }
+    );
+    return response.data;
   },
 };
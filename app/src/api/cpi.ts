@@ -0,0 +1,40 @@
+import apiClient from './client';
+import type { CPIValue, RealTermsOptions } from '../types';
+
+// Query params for summary endpoints; empty for nominal amounts.
+export const realTermsParams = (options?: RealTermsOptions): Record<string, string> => {
+  if (!options?.real) return {};
+  return options.base ? { real: 'true', base: options.base } : { real: 'true' };
+};
+
+export const cpiApi = {
+  list: async (workspaceId: number): Promise<{ cpi: CPIValue[] }> => {
+    const response = await apiClient.get<{ cpi: CPIValue[] }>(`/workspaces/${workspaceId}/cpi`);
+    return response.data;
+  },
+
+  importCsv: async (workspaceId: number, file: File): Promise<{ imported: number }> => {
+    const formData = new FormData();
+    formData.append('file', file);
+    const response = await apiClient.post<{ imported: number }>(`/workspaces/${workspaceId}/cpi/import`, formData, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
+    return response.data;
+  },
+
+  sync: async (workspaceId: number): Promise<{ imported: number }> => {
+    const response = await apiClient.post<{ imported: number }>(`/workspaces/${workspaceId}/cpi/sync`);
+    return response.data;
+  },
+
+  upsert: async (workspaceId: number, month: string, value: number): Promise<{ month: string; value: number }> => {
+    const response = await apiClient.put<{ month: string; value: number }>(`/workspaces/${workspaceId}/cpi/${month}`, {
+      value,
+    });
+    return response.data;
+  },
+
+  delete: async (workspaceId: number, month: string): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/cpi/${month}`);
+  },
+};
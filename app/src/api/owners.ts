@@ -0,0 +1,35 @@
+import apiClient from './client';
+import type { Owner } from '../types';
+
+export const ownersApi = {
+  list: async (workspaceId: number): Promise<{ owners: Owner[] }> => {
+    const response = await apiClient.get<{ owners: Owner[] }>(`/workspaces/${workspaceId}/owners`);
+    return response.data;
+  },
+
+  create: async (workspaceId: number, data: { name: string; member_id?: number }): Promise<{ owner: Owner }> => {
+    const response = await apiClient.post<{ owner: Owner }>(`/workspaces/${workspaceId}/owners`, data);
+    return response.data;
+  },
+
+  // member_id 0 unlinks the member, making the owner an external person.
+  update: async (
+    workspaceId: number,
+    ownerId: number,
+    data: { name?: string; member_id?: number }
+  ): Promise<{ owner: Owner }> => {
+    const response = await apiClient.put<{ owner: Owner }>(`/workspaces/${workspaceId}/owners/${ownerId}`, data);
+    return response.data;
+  },
+
+  delete: async (workspaceId: number, ownerId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/owners/${ownerId}`);
+  },
+
+  merge: async (workspaceId: number, ownerId: number, sourceIds: number[]): Promise<{ owner: Owner }> => {
+    const response = await apiClient.post<{ owner: Owner }>(`/workspaces/${workspaceId}/owners/${ownerId}/merge`, {
+      source_ids: sourceIds,
+    });
+    return response.data;
+  },
+};
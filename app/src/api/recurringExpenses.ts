@@ -1,5 +1,6 @@
 import apiClient from './client';
-import type { RecurringExpense, RecurringExpenseSummary, Transaction } from '../types';
+import type { RealTermsOptions, RecurringExpense, RecurringExpenseSummary, Transaction } from '../types';
+import { realTermsParams } from './cpi';
 
 interface CreateRecurringExpenseRequest {
   name: string;
@@ -7,6 +8,7 @@ interface CreateRecurringExpenseRequest {
   category_id?: number | null;
   owner?: string;
   due_day: number;
+  account_id?: number | null;
 }
 
 interface UpdateRecurringExpenseRequest {
@@ -15,6 +17,7 @@ interface UpdateRecurringExpenseRequest {
   category_id?: number | null;
   owner?: string;
   due_day?: number;
+  account_id?: number; // 0 unassigns the account
 }
 
 export const recurringExpensesApi = {
@@ -25,8 +28,8 @@ export const recurringExpensesApi = {
     return response.data;
   },
 
-  getSummary: async (workspaceId: number, month?: string): Promise<RecurringExpenseSummary> => {
-    const params = month ? { month } : {};
+  getSummary: async (workspaceId: number, month?: string, options?: RealTermsOptions): Promise<RecurringExpenseSummary> => {
+    const params = { ...(month ? { month } : {}), ...realTermsParams(options) };
     const response = await apiClient.get<RecurringExpenseSummary>(
       `/workspaces/${workspaceId}/recurring-expenses/summary`,
       { params }
@@ -0,0 +1,32 @@
+import apiClient from './client';
+import type { RefundCandidate, RefundLink } from '../types';
+
+export const refundsApi = {
+  list: async (workspaceId: number): Promise<{ refunds: RefundLink[] }> => {
+    const response = await apiClient.get<{ refunds: RefundLink[] }>(`/workspaces/${workspaceId}/refunds`);
+    return response.data;
+  },
+
+  candidates: async (workspaceId: number, from?: string, to?: string): Promise<{ candidates: RefundCandidate[] }> => {
+    const response = await apiClient.get<{ candidates: RefundCandidate[] }>(`/workspaces/${workspaceId}/refunds/candidates`, {
+      params: { from, to },
+    });
+    return response.data;
+  },
+
+  link: async (
+    workspaceId: number,
+    refundTransactionId: number,
+    originalTransactionId: number
+  ): Promise<{ refund: RefundLink }> => {
+    const response = await apiClient.post<{ refund: RefundLink }>(`/workspaces/${workspaceId}/refunds`, {
+      refund_transaction_id: refundTransactionId,
+      original_transaction_id: originalTransactionId,
+    });
+    return response.data;
+  },
+
+  unlink: async (workspaceId: number, refundId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/refunds/${refundId}`);
+  },
+};
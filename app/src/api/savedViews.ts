@@ -0,0 +1,46 @@
+import apiClient from './client';
+import type { SavedView, SavedViewFilter, SavedViewResult } from '../types';
+
+interface SavedViewInput {
+  name: string;
+  shared?: boolean;
+  filter: SavedViewFilter;
+}
+
+export const savedViewsApi = {
+  list: async (workspaceId: number): Promise<{ views: SavedView[] }> => {
+    const response = await apiClient.get<{ views: SavedView[] }>(`/workspaces/${workspaceId}/saved-views`);
+    return response.data;
+  },
+
+  get: async (workspaceId: number, viewId: number): Promise<{ view: SavedView }> => {
+    const response = await apiClient.get<{ view: SavedView }>(`/workspaces/${workspaceId}/saved-views/${viewId}`);
+    return response.data;
+  },
+
+  create: async (workspaceId: number, data: SavedViewInput): Promise<{ view: SavedView }> => {
+    const response = await apiClient.post<{ view: SavedView }>(`/workspaces/${workspaceId}/saved-views`, data);
+    return response.data;
+  },
+
+  update: async (workspaceId: number, viewId: number, data: SavedViewInput): Promise<{ view: SavedView }> => {
+    const response = await apiClient.put<{ view: SavedView }>(`/workspaces/${workspaceId}/saved-views/${viewId}`, data);
+    return response.data;
+  },
+
+  delete: async (workspaceId: number, viewId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/saved-views/${viewId}`);
+  },
+
+  execute: async (
+    workspaceId: number,
+    viewId: number,
+    params?: { page?: number; per_page?: number; cursor?: string }
+  ): Promise<{ result: SavedViewResult }> => {
+    const response = await apiClient.get<{ result: SavedViewResult }>(
+      `/workspaces/${workspaceId}/saved-views/${viewId}/execute`,
+      { params }
+    );
+    return response.data;
+  },
+};
@@ -0,0 +1,78 @@
+import apiClient from './client';
+import type { OwnerTotals, RealTerms, RealTermsOptions, SettlementPayment, SettlementReport, SplitRule } from '../types';
+import { realTermsParams } from './cpi';
+
+export interface SplitRuleInput {
+  category_id?: number | null;
+  shares: { owner: string; percent: number }[];
+}
+
+export interface SettlementPaymentInput {
+  from_owner: string;
+  to_owner: string;
+  amount: number;
+  date: string;
+  note?: string;
+}
+
+export const settlementsApi = {
+  ownerBreakdown: async (
+    workspaceId: number,
+    from: string,
+    to: string,
+    level?: string,
+    options?: RealTermsOptions
+  ): Promise<{ owners: OwnerTotals[]; real_terms: RealTerms | null }> => {
+    const response = await apiClient.get<{ owners: OwnerTotals[]; real_terms: RealTerms | null }>(
+      `/workspaces/${workspaceId}/owners/breakdown`,
+      { params: { from, to, level, ...realTermsParams(options) } }
+    );
+    return response.data;
+  },
+
+  listRules: async (workspaceId: number): Promise<{ rules: SplitRule[] }> => {
+    const response = await apiClient.get<{ rules: SplitRule[] }>(`/workspaces/${workspaceId}/split-rules`);
+    return response.data;
+  },
+
+  createRule: async (workspaceId: number, data: SplitRuleInput): Promise<{ rule: SplitRule }> => {
+    const response = await apiClient.post<{ rule: SplitRule }>(`/workspaces/${workspaceId}/split-rules`, data);
+    return response.data;
+  },
+
+  updateRule: async (workspaceId: number, ruleId: number, data: SplitRuleInput): Promise<{ rule: SplitRule }> => {
+    const response = await apiClient.put<{ rule: SplitRule }>(`/workspaces/${workspaceId}/split-rules/${ruleId}`, data);
+    return response.data;
+  },
+
+  deleteRule: async (workspaceId: number, ruleId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/split-rules/${ruleId}`);
+  },
+
+  report: async (workspaceId: number, from: string, to: string, options?: RealTermsOptions): Promise<SettlementReport> => {
+    const response = await apiClient.get<SettlementReport>(`/workspaces/${workspaceId}/settlement`, {
+      params: { from, to, ...realTermsParams(options) },
+    });
+    return response.data;
+  },
+
+  listPayments: async (workspaceId: number, from?: string, to?: string): Promise<{ payments: SettlementPayment[] }> => {
+    const response = await apiClient.get<{ payments: SettlementPayment[] }>(
+      `/workspaces/${workspaceId}/settlement-payments`,
+      { params: { from, to } }
+    );
+    return response.data;
+  },
+
+  createPayment: async (workspaceId: number, data: SettlementPaymentInput): Promise<{ payment: SettlementPayment }> => {
+    const response = await apiClient.post<{ payment: SettlementPayment }>(
+      `/workspaces/${workspaceId}/settlement-payments`,
+      data
+    );
+    return response.data;
+  },
+
+  deletePayment: async (workspaceId: number, paymentId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/settlement-payments/${paymentId}`);
+  },
+};
@@ -0,0 +1,53 @@
+import apiClient from './client';
+import type { RealTerms, RealTermsOptions, Tag, TagTotal, Transaction } from '../types';
+import { realTermsParams } from './cpi';
+
+interface TagInput {
+  name?: string;
+  color?: string;
+}
+
+export const tagsApi = {
+  list: async (workspaceId: number): Promise<{ tags: Tag[] }> => {
+    const response = await apiClient.get<{ tags: Tag[] }>(`/workspaces/${workspaceId}/tags`);
+    return response.data;
+  },
+
+  create: async (workspaceId: number, data: TagInput & { name: string }): Promise<{ tag: Tag }> => {
+    const response = await apiClient.post<{ tag: Tag }>(`/workspaces/${workspaceId}/tags`, data);
+    return response.data;
+  },
+
+  update: async (workspaceId: number, tagId: number, data: TagInput): Promise<{ tag: Tag }> => {
+    const response = await apiClient.put<{ tag: Tag }>(`/workspaces/${workspaceId}/tags/${tagId}`, data);
+    return response.data;
+  },
+
+  delete: async (workspaceId: number, tagId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/tags/${tagId}`);
+  },
+
+  setTransactionTags: async (
+    workspaceId: number,
+    transactionId: number,
+    tagIds: number[]
+  ): Promise<{ transaction: Transaction }> => {
+    const response = await apiClient.put<{ transaction: Transaction }>(
+      `/workspaces/${workspaceId}/transactions/${transactionId}/tags`,
+      { tag_ids: tagIds }
+    );
+    return response.data;
+  },
+
+  summary: async (
+    workspaceId: number,
+    from?: string,
+    to?: string,
+    options?: RealTermsOptions
+  ): Promise<{ tags: TagTotal[]; real_terms: RealTerms | null }> => {
+    const response = await apiClient.get<{ tags: TagTotal[]; real_terms: RealTerms | null }>(`/workspaces/${workspaceId}/tags/summary`, {
+      params: { from, to, ...realTermsParams(options) },
+    });
+    return response.data;
+  },
+};
@@ -1,5 +1,6 @@
 import apiClient from './client';
-import type { Transaction, Pagination, TransactionSummary, MonthlySummary, YearlySummary, UploadPreview, PreviewTransaction } from '../types';
+import type { Transaction, Pagination, TransactionSummary, MonthlySummary, YearlySummary, UploadPreview, ConfirmTransactionInput, AllocationInput, BulkSelection, TransactionPatch, BulkResult, RealTermsOptions, PeriodComparison, Aggregation, AggregationBucketSize, AggregationGroupBy } from '../types';
+import { realTermsParams } from './cpi';
 
 interface TransactionListResponse {
   transactions: Transaction[];
@@ -8,8 +9,20 @@ interface TransactionListResponse {
 }
 
 interface TransactionFilters {
-  month: string;
+  month?: string;
+  from?: string;
+  to?: string;
   category?: string[];
+  tag?: string[];
+  owner?: string[];
+  area_id?: number[];
+  account_id?: number[];
+  q?: string;
+  min_amount?: number;
+  max_amount?: number;
+  confirmed?: boolean;
+  import_batch_id?: number;
+  cursor?: string;
   type?: string;
   sort?: string;
   order?: 'asc' | 'desc';
@@ -24,6 +37,7 @@ interface CreateTransactionInput {
   type: 'debit' | 'credit';
   category?: string;
   owner?: string;
+  account_id?: number;
 }
 
 interface UpdateTransactionInput {
@@ -33,18 +47,74 @@ interface UpdateTransactionInput {
   type?: 'debit' | 'credit';
   category?: string;
   owner?: string;
+  account_id?: number; // 0 unassigns the account
   user_confirmed?: boolean;
 }
 
 export const transactionsApi = {
+  setAllocations: async (
+    workspaceId: number,
+    id: number,
+    allocations: AllocationInput[]
+  ): Promise<{ transaction: Transaction }> => {
+    const response = await apiClient.put<{ transaction: Transaction }>(
+      `/workspaces/${workspaceId}/transactions/${id}/allocations`,
+      { allocations }
+    );
+    return response.data;
+  },
+
+  clearAllocations: async (workspaceId: number, id: number): Promise<{ transaction: Transaction }> => {
+    const response = await apiClient.delete<{ transaction: Transaction }>(
+      `/workspaces/${workspaceId}/transactions/${id}/allocations`
+    );
+    return response.data;
+  },
+
+  bulkUpdate: async (
+    workspaceId: number,
+    selection: BulkSelection,
+    patch: TransactionPatch
+  ): Promise<{ result: BulkResult }> => {
+    const response = await apiClient.post<{ result: BulkResult }>(
+      `/workspaces/${workspaceId}/transactions/bulk-update`,
+      { ...selection, patch }
+    );
+    return response.data;
+  },
+
+  bulkDelete: async (workspaceId: number, selection: BulkSelection): Promise<{ result: BulkResult }> => {
+    const response = await apiClient.post<{ result: BulkResult }>(
+      `/workspaces/${workspaceId}/transactions/bulk-delete`,
+      selection
+    );
+    return response.data;
+  },
+
   list: async (workspaceId: number, filters: TransactionFilters): Promise<TransactionListResponse> => {
     const params = new URLSearchParams();
-    params.append('month', filters.month);
+    if (filters.month) params.append('month', filters.month);
+    if (filters.from) params.append('from', filters.from);
+    if (filters.to) params.append('to', filters.to);
     filters.category?.forEach((category) => {
       if (category) {
         params.append('category', category);
       }
     });
+    filters.tag?.forEach((tag) => {
+      if (tag) {
+        params.append('tag', tag);
+      }
+    });
+    filters.owner?.forEach((owner) => params.append('owner', owner));
+    filters.area_id?.forEach((areaId) => params.append('area_id', areaId.toString()));
+    filters.account_id?.forEach((accountId) => params.append('account_id', accountId.toString()));
+    if (filters.q) params.append('q', filters.q);
+    if (filters.min_amount !== undefined) params.append('min_amount', filters.min_amount.toString());
+    if (filters.max_amount !== undefined) params.append('max_amount', filters.max_amount.toString());
+    if (filters.confirmed !== undefined) params.append('confirmed', String(filters.confirmed));
+    if (filters.import_batch_id) params.append('import_batch_id', filters.import_batch_id.toString());
+    if (filters.cursor !== undefined) params.append('cursor', filters.cursor);
     if (filters.type) params.append('type', filters.type);
     if (filters.sort) params.append('sort', filters.sort);
     if (filters.order) params.append('order', filters.order);
@@ -84,20 +154,61 @@ export const transactionsApi = {
     await apiClient.delete(`/workspaces/${workspaceId}/transactions/${id}`);
   },
 
-  getSummary: async (workspaceId: number, month: string): Promise<{ summary: MonthlySummary }> => {
+  getSummary: async (workspaceId: number, month: string, options?: RealTermsOptions): Promise<{ summary: MonthlySummary }> => {
     const response = await apiClient.get<{ summary: MonthlySummary }>(
-      `/workspaces/${workspaceId}/transactions/summary?month=${month}`
+      `/workspaces/${workspaceId}/transactions/summary?month=${month}`,
+      { params: realTermsParams(options) }
     );
     return response.data;
   },
 
-  getYearlySummary: async (workspaceId: number, year: string): Promise<{ summary: YearlySummary }> => {
+  getYearlySummary: async (workspaceId: number, year: string, options?: RealTermsOptions): Promise<{ summary: YearlySummary }> => {
     const response = await apiClient.get<{ summary: YearlySummary }>(
-      `/workspaces/${workspaceId}/transactions/yearly-summary?year=${year}`
+      `/workspaces/${workspaceId}/transactions/yearly-summary?year=${year}`,
+      { params: realTermsParams(options) }
     );
     return response.data;
   },
 
+  // Periods are inclusive YYYY-MM-DD ranges.
+  compare: async (
+    workspaceId: number,
+    current: { from: string; to: string },
+    previous: { from: string; to: string },
+    level?: number,
+    options?: RealTermsOptions
+  ): Promise<{ comparison: PeriodComparison }> => {
+    const response = await apiClient.get<{ comparison: PeriodComparison }>(`/workspaces/${workspaceId}/transactions/compare`, {
+      params: {
+        from: current.from,
+        to: current.to,
+        compare_from: previous.from,
+        compare_to: previous.to,
+        level,
+        ...realTermsParams(options),
+      },
+    });
+    return response.data;
+  },
+
+  // from / to are inclusive YYYY-MM-DD dates.
+  aggregate: async (
+    workspaceId: number,
+    params: {
+      from: string;
+      to: string;
+      bucket?: AggregationBucketSize;
+      group_by?: AggregationGroupBy;
+      level?: number;
+    },
+    options?: RealTermsOptions
+  ): Promise<{ aggregation: Aggregation }> => {
+    const response = await apiClient.get<{ aggregation: Aggregation }>(`/workspaces/${workspaceId}/transactions/aggregate`, {
+      params: { ...params, ...realTermsParams(options) },
+    });
+    return response.data;
+  },
+
   getCategories: async (workspaceId: number): Promise<{ categories: string[] }> => {
     const response = await apiClient.get<{ categories: Array<{ name: string } | string> }>(
       `/workspaces/${workspaceId}/categories`
@@ -124,11 +235,13 @@ export const transactionsApi = {
 
   confirmUpload: async (
     workspaceId: number,
-    transactions: Omit<PreviewTransaction, 'temp_id' | 'balance_after'>[]
-  ): Promise<{ created_count: number }> => {
-    const response = await apiClient.post<{ created_count: number }>(
+    transactions: ConfirmTransactionInput[],
+    fileName?: string,
+    accountId?: number
+  ): Promise<{ created_count: number; import_batch_id?: number }> => {
+    const response = await apiClient.post<{ created_count: number; import_batch_id?: number }>(
       `/workspaces/${workspaceId}/transactions/confirm`,
-      { transactions }
+      { transactions, file_name: fileName, account_id: accountId }
     );
     return response.data;
   },
@@ -0,0 +1,38 @@
+import apiClient from './client';
+import type { TransferCandidate, TransferLink } from '../types';
+
+export const transfersApi = {
+  list: async (workspaceId: number, from?: string, to?: string): Promise<{ transfers: TransferLink[] }> => {
+    const response = await apiClient.get<{ transfers: TransferLink[] }>(`/workspaces/${workspaceId}/transfers`, {
+      params: { from, to },
+    });
+    return response.data;
+  },
+
+  candidates: async (
+    workspaceId: number,
+    params?: { from?: string; to?: string; days?: number }
+  ): Promise<{ candidates: TransferCandidate[] }> => {
+    const response = await apiClient.get<{ candidates: TransferCandidate[] }>(
+      `/workspaces/${workspaceId}/transfers/candidates`,
+      { params }
+    );
+    return response.data;
+  },
+
+  link: async (
+    workspaceId: number,
+    debitTransactionId: number,
+    creditTransactionId: number
+  ): Promise<{ transfer: TransferLink }> => {
+    const response = await apiClient.post<{ transfer: TransferLink }>(`/workspaces/${workspaceId}/transfers`, {
+      debit_transaction_id: debitTransactionId,
+      credit_transaction_id: creditTransactionId,
+    });
+    return response.data;
+  },
+
+  unlink: async (workspaceId: number, transferId: number): Promise<void> => {
+    await apiClient.delete(`/workspaces/${workspaceId}/transfers/${transferId}`);
+  },
+};
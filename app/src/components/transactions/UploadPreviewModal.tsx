@@ -1,7 +1,7 @@
 import { useState, useRef } from 'react';
 import { useMutation, useQueryClient } from '@tanstack/react-query';
 import { transactionsApi } from '../../api/transactions';
-import type { PreviewTransaction, UploadPreview } from '../../types';
+import type { ConfirmTransactionInput, PreviewTransaction, UploadPreview } from '../../types';
 
 interface UploadPreviewModalProps {
   workspaceId: number;
@@ -17,6 +17,7 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
   const [transactions, setTransactions] = useState<PreviewTransaction[]>([]);
   const [selectedIds, setSelectedIds] = useState<Set<number>>(new Set());
   const [error, setError] = useState<string | null>(null);
+  const [sortByConfidence, setSortByConfidence] = useState(false);
   const fileInputRef = useRef<HTMLInputElement>(null);
   const queryClient = useQueryClient();
 
@@ -35,8 +36,8 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
   });
 
   const confirmMutation = useMutation({
-    mutationFn: (txns: Omit<PreviewTransaction, 'temp_id' | 'balance_after'>[]) =>
-      transactionsApi.confirmUpload(workspaceId, txns),
+    mutationFn: (txns: ConfirmTransactionInput[]) =>
+      transactionsApi.confirmUpload(workspaceId, txns, preview?.file_name),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['transactions'] });
       queryClient.invalidateQueries({ queryKey: ['categories'] });
@@ -54,6 +55,7 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
     setTransactions([]);
     setSelectedIds(new Set());
     setError(null);
+    setSortByConfidence(false);
     onClose();
   };
 
@@ -96,15 +98,23 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
   };
 
   const handleConfirm = () => {
-    const selectedTxns = transactions
+    const predictions = new Map(preview?.transactions.map((t) => [t.temp_id, t]));
+    const selectedTxns: ConfirmTransactionInput[] = transactions
       .filter((t) => selectedIds.has(t.temp_id))
-      .map(({ date, description, amount, type, category }) => ({
-        date,
-        description,
-        amount,
-        type,
-        category,
-      }));
+      .map(({ temp_id, date, description, amount, type, category }) => {
+        const predicted = predictions.get(temp_id);
+        return {
+          date,
+          description,
+          amount,
+          type,
+          category,
+          predicted_category: predicted?.category,
+          prediction_source: predicted?.source,
+          prediction_confidence: predicted?.confidence,
+          model_version: preview?.model_version,
+        };
+      });
 
     if (selectedTxns.length === 0) {
       setError('Please select at least one transaction');
@@ -114,6 +124,10 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
     confirmMutation.mutate(selectedTxns);
   };
 
+  const visibleTransactions = sortByConfidence
+    ? [...transactions].sort((a, b) => a.confidence - b.confidence)
+    : transactions;
+
   const selectedTransactions = transactions.filter((t) => selectedIds.has(t.temp_id));
   const selectedDebit = selectedTransactions.reduce(
     (sum, t) => (t.amount < 0 ? sum + t.amount : sum),
@@ -205,6 +219,22 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
                 </div>
               </div>
 
+              <div className="flex justify-between items-center mb-4">
+                <p className="text-sm text-on-surface-variant">
+                  {preview.summary.needs_review_count} transaction
+                  {preview.summary.needs_review_count !== 1 ? 's' : ''} need review
+                </p>
+                <label className="flex items-center gap-2 text-sm cursor-pointer">
+                  <input
+                    type="checkbox"
+                    checked={sortByConfidence}
+                    onChange={(e) => setSortByConfidence(e.target.checked)}
+                    className="w-4 h-4 rounded cursor-pointer accent-primary-container"
+                  />
+                  Lowest confidence first
+                </label>
+              </div>
+
               {/* Transactions Table */}
               <div className="bg-surface-container-lowest rounded-xl overflow-hidden">
                 <table className="w-full">
@@ -233,10 +263,13 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
                       <th className="px-4 py-3 text-left text-xs font-semibold uppercase tracking-wider text-on-surface-variant">
                         Category
                       </th>
+                      <th className="px-4 py-3 text-right text-xs font-semibold uppercase tracking-wider text-on-surface-variant">
+                        Confidence
+                      </th>
                     </tr>
                   </thead>
                   <tbody className="divide-y divide-surface-container-low">
-                    {transactions.map((t) => (
+                    {visibleTransactions.map((t) => (
                       <tr
                         key={t.temp_id}
                         className={`${
@@ -302,6 +335,15 @@ export function UploadPreviewModal({ workspaceId, isOpen, onClose }: UploadPrevi
                             ))}
                           </select>
                         </td>
+                        <td className="px-4 py-3 text-right text-sm" title={t.reason}>
+                          {t.needs_review ? (
+                            <span className="px-2 py-1 rounded bg-red-50 text-red-600 font-semibold">Review</span>
+                          ) : (
+                            <span className={t.confidence < 0.6 ? 'text-error' : 'text-on-surface-variant'}>
+                              {Math.round(t.confidence * 100)}%
+                            </span>
+                          )}
+                        </td>
                       </tr>
                     ))}
                   </tbody>
@@ -21,6 +21,25 @@ export interface WorkspaceMember {
   joined_at: string;
 }
 
+// An owner without member_id is an external person (not a workspace member).
+export interface Owner {
+  id: number;
+  workspace_id: number;
+  name: string;
+  member_id: number | null;
+  aliases: OwnerAlias[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface OwnerAlias {
+  id: number;
+  workspace_id: number;
+  owner_id: number;
+  name: string;
+  created_at: string;
+}
+
 export interface WorkspaceInvite {
   id: number;
   workspace_id: number;
@@ -38,8 +57,14 @@ export interface Transaction {
   amount: { Float64: number; Valid: boolean } | number;
   balance_after?: { Float64: number; Valid: boolean } | number;
   type: { String: string; Valid: boolean } | string;
+  category_id?: number | null;
   category: { String: string; Valid: boolean } | string;
   owner?: { String: string; Valid: boolean } | string;
+  owner_id?: number | null;
+  import_batch_id?: number | null;
+  account_id?: number | null;
+  tags?: Tag[];
+  allocations?: TransactionAllocation[];
   user_confirmed: boolean;
   created_at: string;
   updated_at: string;
@@ -50,6 +75,7 @@ export interface Pagination {
   per_page: number;
   total: number;
   total_pages: number;
+  next_cursor?: string;
 }
 
 export interface TransactionSummary {
@@ -71,6 +97,7 @@ export interface MonthlySummary {
   total_income: number;
   net: number;
   by_category: CategorySummary[];
+  real_terms?: RealTerms;
 }
 
 export interface MonthlyCategoryAmount {
@@ -90,6 +117,67 @@ export interface YearlySummary {
   total_income: number;
   net: number;
   by_category: YearlyCategorySummary[];
+  real_terms?: RealTerms;
+}
+
+export interface PeriodTotals {
+  from: string;
+  to: string;
+  spending: number;
+  income: number;
+  net: number;
+}
+
+export interface ComparisonDelta {
+  id?: number;
+  name: string;
+  current: number;
+  previous: number;
+  change: number;
+  change_percentage: number | null;
+  status?: 'new' | 'disappeared';
+}
+
+export interface PeriodComparison {
+  current: PeriodTotals;
+  previous: PeriodTotals;
+  spending: ComparisonDelta;
+  income: ComparisonDelta;
+  net: ComparisonDelta;
+  by_category: ComparisonDelta[];
+  by_area: ComparisonDelta[];
+  real_terms?: RealTerms;
+}
+
+export type AggregationBucketSize = 'day' | 'week' | 'month' | 'quarter' | 'year';
+export type AggregationGroupBy = 'category' | 'area' | 'owner' | 'account' | 'tag';
+
+export interface AggregationTotals {
+  debit: number;
+  credit: number;
+  net: number;
+  count: number;
+}
+
+export interface AggregationGroup extends AggregationTotals {
+  key: string;
+  name: string;
+}
+
+export interface AggregationBucket extends AggregationTotals {
+  start: string;
+  end: string;
+  groups?: AggregationGroup[];
+}
+
+export interface Aggregation {
+  from: string;
+  to: string;
+  bucket: AggregationBucketSize;
+  group_by?: AggregationGroupBy;
+  totals: AggregationTotals;
+  buckets: AggregationBucket[];
+  real_terms?: RealTerms;
 }
 
 export interface PreviewTransaction {
@@ -100,18 +188,39 @@ export interface PreviewTransaction {
   balance_after: number;
   type: 'debit' | 'credit';
   category: string;
+  confidence: number;
+  reason: string;
+  source: PredictionSource;
+  needs_review: boolean;
 }
 
+export type PredictionSource = 'rule' | 'local' | 'llm' | 'fallback';
+
+export type ConfirmTransactionInput = Pick<
+  PreviewTransaction,
+  'date' | 'description' | 'amount' | 'type' | 'category'
+> & {
+  predicted_category?: string;
+  prediction_source?: PredictionSource;
+  prediction_confidence?: number;
+  model_version?: string;
+};
+
 export interface UploadPreviewSummary {
   total_count: number;
   total_debit: number;
   total_credit: number;
+  needs_review_count: number;
 }
 
 export interface UploadPreview {
   transactions: PreviewTransaction[];
   summary: UploadPreviewSummary;
   allowed_categories: string[];
+  model_version: string;
+  example_strategy: string;
+  example_pool_size: number;
+  file_name?: string;
 }
 
 export interface Area {
@@ -124,10 +233,79 @@ export interface Area {
   updated_at: string;
 }
 
+export type AccountType = 'bank' | 'card' | 'wallet' | 'cash';
+
+export interface Account {
+  id: number;
+  workspace_id: number;
+  name: string;
+  type: AccountType;
+  currency: string;
+  institution: string;
+  owner_user_id: number | null;
+  opening_balance: number;
+  opening_date: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface AccountBalance {
+  account: Account;
+  as_of: string;
+  opening_balance: number;
+  credits: number;
+  debits: number;
+  balance: number;
+  count: number;
+}
+
+// Cash flow forecast types
+export type ForecastItemKind = 'recurring' | 'income' | 'installment';
+
+export interface ForecastItem {
+  kind: ForecastItemKind;
+  description: string;
+  amount: number; // positive for money coming in
+}
+
+export interface ForecastDay {
+  date: string;
+  scheduled: number;
+  baseline: number;
+  balance: number;
+  negative: boolean;
+  items?: ForecastItem[];
+}
+
+// account_id is null for flows not assigned to any account.
+export interface AccountForecast {
+  account_id: number | null;
+  name: string;
+  type?: AccountType;
+  currency?: string;
+  starting_balance: number;
+  daily_baseline: number;
+  ending_balance: number;
+  lowest_balance: number;
+  lowest_date: string;
+  first_negative_date: string | null;
+  negative_days: number;
+  days: ForecastDay[];
+}
+
+export interface CashFlowForecast {
+  as_of: string;
+  from: string;
+  to: string;
+  history_from: string;
+  accounts: AccountForecast[];
+}
+
 export interface Category {
   id: number;
   workspace_id: number;
   name: string;
+  parent_id: number | null;
   area_id: number | null;
   area?: Area;
   color: string;
@@ -136,6 +314,323 @@ export interface Category {
   updated_at: string;
 }
 
+export interface TransactionAllocation {
+  id: number;
+  transaction_id: number;
+  amount: number;
+  category_id: number | null;
+  category: { String: string; Valid: boolean } | string;
+  area_id: number | null;
+  owner: string;
+  note: string;
+}
+
+export interface AllocationInput {
+  amount: number;
+  category?: string;
+  category_id?: number;
+  area_id?: number;
+  owner?: string;
+  note?: string;
+}
+
+export interface TransferLink {
+  id: number;
+  workspace_id: number;
+  debit_transaction_id: number;
+  debit_transaction?: Transaction;
+  credit_transaction_id: number;
+  credit_transaction?: Transaction;
+  created_by: number;
+  created_at: string;
+}
+
+export interface TransferCandidate {
+  debit: Transaction;
+  credit: Transaction;
+  days_apart: number;
+  score: number;
+  reasons: string[];
+}
+
+export interface StatementCheck {
+  paid: number;
+  statement_balance: number | null;
+  difference: number | null;
+  status: 'paid' | 'partial' | 'overpaid' | 'unknown';
+}
+
+export interface CardPayment {
+  id: number;
+  workspace_id: number;
+  transaction_id: number;
+  transaction?: Transaction;
+  card_account_id: number;
+  card_transaction_id: number | null;
+  import_batch_id: number | null;
+  created_by: number;
+  created_at: string;
+  check: StatementCheck;
+}
+
+export interface CardPaymentCandidate {
+  transaction: Transaction;
+  card_account_id: number | null;
+  import_batch_id: number | null;
+  check?: StatementCheck;
+}
+
+export interface RefundLink {
+  id: number;
+  workspace_id: number;
+  refund_transaction_id: number;
+  refund_transaction?: Transaction;
+  original_transaction_id: number;
+  original_transaction?: Transaction;
+  created_by: number;
+  created_at: string;
+}
+
+export interface RefundCandidate {
+  refund: Transaction;
+  original: Transaction;
+  score: number;
+  reasons: string[];
+}
+
+export interface Budget {
+  id: number;
+  workspace_id: number;
+  month: string;
+  category_id: number | null;
+  area_id: number | null;
+  amount: number;
+  rollover: boolean;
+  template_id: number | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface BudgetTemplate {
+  id: number;
+  workspace_id: number;
+  category_id: number | null;
+  area_id: number | null;
+  amount: number;
+  rollover: boolean;
+  start_month: string;
+  end_month: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface BudgetStatus {
+  budget: Budget;
+  target_name: string;
+  carried_over: number;
+  available: number;
+  spent: number;
+  remaining: number;
+  percentage: number;
+  status: 'ok' | 'warning' | 'exceeded';
+}
+
+export interface BudgetReport {
+  month: string;
+  total_available: number;
+  total_spent: number;
+  budgets: BudgetStatus[];
+}
+
+export interface BudgetAlert {
+  id: number;
+  workspace_id: number;
+  budget_id: number;
+  month: string;
+  threshold: number;
+  spent: number;
+  available: number;
+  acknowledged: boolean;
+  created_at: string;
+}
+
+// Owner and settlement types
+export interface OwnerCategoryAmount {
+  category_id: number | null;
+  category: string;
+  amount: number;
+}
+
+// Empty owner collects transactions without one.
+export interface OwnerTotals {
+  owner: string;
+  spending: number;
+  income: number;
+  net: number;
+  count: number;
+  by_category: OwnerCategoryAmount[];
+}
+
+export interface SplitShare {
+  id: number;
+  split_rule_id: number;
+  owner: string;
+  percent: number;
+}
+
+// A rule without category_id is the workspace default.
+export interface SplitRule {
+  id: number;
+  workspace_id: number;
+  category_id: number | null;
+  shares: SplitShare[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface SettlementPayment {
+  id: number;
+  workspace_id: number;
+  from_owner: string;
+  to_owner: string;
+  amount: number;
+  date: string;
+  note: string;
+  created_by: number;
+  created_at: string;
+}
+
+// A positive balance is owed to the owner.
+export interface OwnerBalance {
+  owner: string;
+  paid: number;
+  share: number;
+  payments_sent: number;
+  payments_received: number;
+  balance: number;
+}
+
+export interface SettlementTransfer {
+  from_owner: string;
+  to_owner: string;
+  amount: number;
+}
+
+export interface SettlementReport {
+  from: string;
+  to: string;
+  shared_total: number;
+  unassigned_shared: number;
+  owners: OwnerBalance[];
+  payments: SettlementPayment[];
+  transfers: SettlementTransfer[];
+  real_terms?: RealTerms;
+}
+
+export interface Tag {
+  id: number;
+  workspace_id: number;
+  name: string;
+  color: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface TagTotal {
+  tag_id: number;
+  name: string;
+  color: string;
+  debit_total: number;
+  credit_total: number;
+  net: number;
+  count: number;
+}
+
+export interface SavedViewFilter {
+  month?: string;
+  from?: string;
+  to?: string;
+  last_days?: number;
+  last_months?: number;
+  categories?: string[];
+  tags?: string[];
+  type?: 'debit' | 'credit';
+  min_amount?: number;
+  max_amount?: number;
+  q?: string;
+  owners?: string[];
+  area_ids?: number[];
+  account_ids?: number[];
+  confirmed?: boolean;
+  import_batch_id?: number;
+  sort?: string;
+  order?: 'asc' | 'desc';
+}
+
+export interface BulkSelection {
+  ids?: number[];
+  filter?: SavedViewFilter;
+}
+
+export interface TransactionPatch {
+  category?: string;
+  category_id?: number;
+  area_id?: number;
+  account_id?: number;
+  owner?: string;
+  user_confirmed?: boolean;
+  add_tag_ids?: number[];
+  remove_tag_ids?: number[];
+}
+
+export interface BulkOutcome {
+  transaction_id: number;
+  status: 'updated' | 'deleted' | 'not_found' | 'failed' | 'rolled_back';
+  error?: string;
+}
+
+export interface BulkResult {
+  applied: boolean;
+  matched: number;
+  succeeded: number;
+  outcomes: BulkOutcome[];
+}
+
+export interface SavedView {
+  id: number;
+  workspace_id: number;
+  user_id: number | null;
+  created_by: number;
+  name: string;
+  shared: boolean;
+  filter: SavedViewFilter;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface TransactionTotals {
+  count: number;
+  debit_total: number;
+  credit_total: number;
+  net: number;
+}
+
+export interface SavedViewResult {
+  view: SavedView;
+  totals: TransactionTotals;
+  page: Pagination & { transactions: Transaction[] };
+}
+
+export interface CategoryReassignment {
+  source_id: number;
+  target_id: number;
+  target_name: string;
+  transactions: number;
+  recurring_expenses: number;
+  rules_moved: number;
+  rules_deleted: number;
+}
+
 export interface RecurringExpense {
   id: number;
   workspace_id: number;
@@ -147,6 +642,7 @@ export interface RecurringExpense {
   area_name?: string;
   owner: string;
   due_day: number;
+  account_id: number | null;
   last_paid_date: string | null;
   is_paid_this_month: boolean;
   created_at: string;
@@ -159,6 +655,7 @@ export interface RecurringExpenseSummary {
   pending_amount: number;
   previous_month_total: number;
   change_percentage: number;
+  real_terms?: RealTerms;
 }
 
 // Exchange Rate types
@@ -171,6 +668,28 @@ export interface ExchangeRate {
   updated_at: string;
 }
 
+// CPI types
+export interface CPIValue {
+  id: number;
+  workspace_id: number;
+  month: string;
+  value: number;
+  source: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface RealTerms {
+  base_month: string;
+  estimated_months?: string[];
+}
+
+// Restates summary amounts in constant pesos of base (default: the latest CPI month).
+export interface RealTermsOptions {
+  real?: boolean;
+  base?: string;
+}
+
 // Yearly Area Summary types
 export interface MonthlyAreaAmount {
   month: string;
@@ -198,6 +717,7 @@ export interface YearlyAreaSummary {
   year: string;
   total_spent: number;
   areas: YearlyAreaItem[];
+  real_terms?: RealTerms;
 }
 
 // Helper to extract string value from nullable string
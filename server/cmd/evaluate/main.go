@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"etl-banks-ar/internal/categorizer"
+	"etl-banks-ar/internal/evaluation"
+	openAiService "etl-banks-ar/internal/openai"
+	"etl-banks-ar/internal/trainingcsv"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	trainingDir := flag.String("training-dir", "", "Folder with labeled CSV training data")
+	backendName := flag.String("backend", "local", "Categorizer backend: rules | local | llm")
+	provider := flag.String("provider", "offline", "LLM provider for --backend llm: offline | openai")
+	rulesPath := flag.String("rules", "", "JSON rules file for --backend rules")
+	exampleStrategy := flag.String("examples", categorizer.ExampleStrategySimilar, "Example selection for --backend llm: similar | recent | all")
+	exampleBudget := flag.Int("example-budget", categorizer.DefaultExampleTokenBudget, "Approximate token budget for selected examples")
+	split := flag.String("split", "kfold", "Split strategy: kfold | time")
+	folds := flag.Int("folds", 5, "Number of folds for --split kfold")
+	trainFraction := flag.Float64("train-fraction", 0.8, "Oldest fraction used as examples for --split time")
+	seed := flag.Int64("seed", 1, "Shuffle seed for --split kfold")
+	format := flag.String("format", "text", "Output format: text | json")
+	jsonOut := flag.String("json-out", "", "Optional path to also write the JSON report")
+	flag.Parse()
+
+	if *trainingDir == "" {
+		fail("usage: go run ./cmd/evaluate --training-dir ./data/train [--backend local|rules|llm] [--split kfold|time] [--format text|json]")
+	}
+
+	if err := godotenv.Load(); err != nil && *provider == "openai" {
+		fmt.Fprintln(os.Stderr, "warning: .env not loaded, relying on environment variables")
+	}
+
+	_, examples, err := trainingcsv.LoadTrainingData(*trainingDir)
+	if err != nil {
+		fail("failed to load training CSVs: %v", err)
+	}
+	examples = labeledOnly(examples)
+	if len(examples) == 0 {
+		fail("training CSVs have no labeled rows")
+	}
+
+	backend, err := buildBackend(*backendName, *provider, *rulesPath, *exampleStrategy, *exampleBudget)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	var evalFolds []evaluation.Fold
+	switch *split {
+	case "kfold":
+		evalFolds, err = evaluation.KFold(examples, *folds, *seed)
+	case "time":
+		evalFolds, err = evaluation.TimeSplit(examples, *trainFraction)
+	default:
+		err = fmt.Errorf("unknown split %q", *split)
+	}
+	if err != nil {
+		fail("failed to split data: %v", err)
+	}
+
+	report, err := evaluation.Run(backend, evalFolds, *split)
+	if err != nil {
+		fail("evaluation failed: %v", err)
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fail("failed to encode report: %v", err)
+	}
+
+	switch *format {
+	case "json":
+		fmt.Println(string(reportJSON))
+	default:
+		report.WriteText(os.Stdout)
+	}
+
+	if *jsonOut != "" {
+		if err := os.WriteFile(*jsonOut, append(reportJSON, '\n'), 0o644); err != nil {
+			fail("failed to write %s: %v", *jsonOut, err)
+		}
+	}
+}
+
+func buildBackend(name, provider, rulesPath, exampleStrategy string, exampleBudget int) (categorizer.Categorizer, error) {
+	switch name {
+	case "rules":
+		if rulesPath == "" {
+			return nil, fmt.Errorf("--rules is required for --backend rules")
+		}
+		rules, err := categorizer.LoadRules(rulesPath)
+		if err != nil {
+			return nil, err
+		}
+		return categorizer.RuleCategorizer{Rules: rules}, nil
+	case "local":
+		return categorizer.LocalCategorizer{}, nil
+	case "llm":
+		var selector categorizer.ExampleSelector
+		if exampleStrategy != "all" {
+			var err error
+			selector, err = categorizer.NewExampleSelector(exampleStrategy, exampleBudget)
+			if err != nil {
+				return nil, err
+			}
+		}
+		switch provider {
+		case "offline":
+			return categorizer.LLMCategorizer{Client: categorizer.OfflinePrompter{}, Selector: selector}, nil
+		case "openai":
+			return categorizer.LLMCategorizer{Client: openAiService.NewClient(), Selector: selector}, nil
+		default:
+			return nil, fmt.Errorf("unknown provider %q", provider)
+		}
+	default:
+		return nil, fmt.Errorf("unknown backend %q", name)
+	}
+}
+
+func labeledOnly(examples []trainingcsv.Example) []trainingcsv.Example {
+	out := make([]trainingcsv.Example, 0, len(examples))
+	for _, ex := range examples {
+		if strings.TrimSpace(ex.Category) != "" {
+			out = append(out, ex)
+		}
+	}
+	return out
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(1)
+}
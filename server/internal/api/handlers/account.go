@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+type CreateAccountRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	Type           string  `json:"type" binding:"required"`
+	Currency       string  `json:"currency"`
+	Institution    string  `json:"institution"`
+	OwnerUserID    *uint   `json:"owner_user_id"`
+	OpeningBalance float64 `json:"opening_balance"`
+	OpeningDate    string  `json:"opening_date"` // YYYY-MM-DD
+}
+
+type UpdateAccountRequest struct {
+	Name           *string  `json:"name"`
+	Type           *string  `json:"type"`
+	Currency       *string  `json:"currency"`
+	Institution    *string  `json:"institution"`
+	OwnerUserID    *uint    `json:"owner_user_id"`
+	ClearOwner     bool     `json:"clear_owner"`
+	OpeningBalance *float64 `json:"opening_balance"`
+	OpeningDate    *string  `json:"opening_date"` // YYYY-MM-DD; empty clears it
+}
+
+func (h *AccountHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	accounts, err := h.accountService.List(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+func (h *AccountHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account := &models.Account{
+		WorkspaceID:    uint(workspaceID),
+		Name:           req.Name,
+		Type:           req.Type,
+		Currency:       req.Currency,
+		Institution:    req.Institution,
+		OwnerUserID:    req.OwnerUserID,
+		OpeningBalance: req.OpeningBalance,
+	}
+	if req.OpeningDate != "" {
+		date, err := time.Parse("2006-01-02", req.OpeningDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid opening_date. Use YYYY-MM-DD"})
+			return
+		}
+		account.OpeningDate = &date
+	}
+
+	if err := h.accountService.Save(account); err != nil {
+		respondAccountError(c, err, "Failed to create account")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"account": account})
+}
+
+func (h *AccountHandler) Get(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	accountID, _ := strconv.ParseUint(c.Param("account_id"), 10, 32)
+
+	account, err := h.accountService.FindByID(uint(accountID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account": account})
+}
+
+func (h *AccountHandler) Update(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	accountID, _ := strconv.ParseUint(c.Param("account_id"), 10, 32)
+
+	account, err := h.accountService.FindByID(uint(accountID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	var req UpdateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		account.Name = *req.Name
+	}
+	if req.Type != nil {
+		account.Type = *req.Type
+	}
+	if req.Currency != nil {
+		account.Currency = *req.Currency
+	}
+	if req.Institution != nil {
+		account.Institution = *req.Institution
+	}
+	if req.OwnerUserID != nil {
+		account.OwnerUserID = req.OwnerUserID
+	} else if req.ClearOwner {
+		account.OwnerUserID = nil
+	}
+	if req.OpeningBalance != nil {
+		account.OpeningBalance = *req.OpeningBalance
+	}
+	if req.OpeningDate != nil {
+		account.OpeningDate = nil
+		if *req.OpeningDate != "" {
+			date, err := time.Parse("2006-01-02", *req.OpeningDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid opening_date. Use YYYY-MM-DD"})
+				return
+			}
+			account.OpeningDate = &date
+		}
+	}
+
+	if err := h.accountService.Save(account); err != nil {
+		respondAccountError(c, err, "Failed to update account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account": account})
+}
+
+// Delete removes the account; its transactions stay, unassigned.
+func (h *AccountHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	accountID, _ := strconv.ParseUint(c.Param("account_id"), 10, 32)
+
+	if err := h.accountService.Delete(uint(accountID), uint(workspaceID)); err != nil {
+		respondAccountError(c, err, "Failed to delete account")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Balances returns every account's balance at the end of the day given by ?on= (YYYY-MM-DD, default today).
+func (h *AccountHandler) Balances(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	on, err := parseBalanceDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	balances, err := h.accountService.Balances(uint(workspaceID), on)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balances"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"balances": balances})
+}
+
+// Balance returns one account's balance at the end of the day given by ?on= (YYYY-MM-DD, default today).
+func (h *AccountHandler) Balance(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	accountID, _ := strconv.ParseUint(c.Param("account_id"), 10, 32)
+
+	on, err := parseBalanceDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	balance, err := h.accountService.Balance(uint(accountID), uint(workspaceID), on)
+	if err != nil {
+		respondAccountError(c, err, "Failed to compute balance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"balance": balance})
+}
+
+func parseBalanceDate(c *gin.Context) (time.Time, error) {
+	raw := c.Query("on")
+	if raw == "" {
+		return time.Now(), nil
+	}
+	on, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return on, errors.New("Invalid on date. Use YYYY-MM-DD")
+	}
+	return on, nil
+}
+
+func respondAccountError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+	case errors.Is(err, services.ErrInvalidAccount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
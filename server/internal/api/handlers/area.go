@@ -13,12 +13,14 @@ import (
 type AreaHandler struct {
 	areaService     *services.AreaService
 	categoryService *services.CategoryService
+	cpiService      *services.CPIService
 }
 
-func NewAreaHandler(areaService *services.AreaService, categoryService *services.CategoryService) *AreaHandler {
+func NewAreaHandler(areaService *services.AreaService, categoryService *services.CategoryService, cpiService *services.CPIService) *AreaHandler {
 	return &AreaHandler{
 		areaService:     areaService,
 		categoryService: categoryService,
+		cpiService:      cpiService,
 	}
 }
 
@@ -144,7 +146,19 @@ func (h *AreaHandler) GetSummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.areaService.GetMonthlySummary(uint(workspaceID), month)
+	level, err := services.ParseCategoryLevel(c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	summary, err := h.areaService.GetMonthlySummary(uint(workspaceID), month, level, deflator)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch area summary"})
 		return
@@ -175,7 +189,19 @@ func (h *AreaHandler) GetYearlySummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.areaService.GetYearlySummary(uint(workspaceID), year)
+	level, err := services.ParseCategoryLevel(c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	summary, err := h.areaService.GetYearlySummary(uint(workspaceID), year, level, deflator)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch yearly area summary"})
 		return
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BudgetHandler struct {
+	budgetService *services.BudgetService
+}
+
+func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{budgetService: budgetService}
+}
+
+type CreateBudgetRequest struct {
+	Month      string  `json:"month" binding:"required"` // YYYY-MM
+	CategoryID *uint   `json:"category_id"`
+	AreaID     *uint   `json:"area_id"`
+	Amount     float64 `json:"amount"`
+	Rollover   bool    `json:"rollover"`
+}
+
+// UpdateBudgetRequest changes the amount or rollover; a budget's month and target are fixed.
+type UpdateBudgetRequest struct {
+	Amount   *float64 `json:"amount"`
+	Rollover *bool    `json:"rollover"`
+}
+
+type CreateBudgetTemplateRequest struct {
+	CategoryID *uint   `json:"category_id"`
+	AreaID     *uint   `json:"area_id"`
+	Amount     float64 `json:"amount"`
+	Rollover   bool    `json:"rollover"`
+	StartMonth string  `json:"start_month" binding:"required"` // YYYY-MM
+	EndMonth   string  `json:"end_month"`                      // YYYY-MM; empty for open-ended
+}
+
+type UpdateBudgetTemplateRequest struct {
+	Amount   *float64 `json:"amount"`
+	Rollover *bool    `json:"rollover"`
+	EndMonth *string  `json:"end_month"` // empty makes it open-ended
+}
+
+func respondBudgetError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, services.ErrInvalidBudget) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+// List returns the budgets of ?month=YYYY-MM. It does not create budgets from templates; see ApplyTemplates.
+func (h *BudgetHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	month := c.Query("month")
+	if month == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month parameter is required (YYYY-MM)"})
+		return
+	}
+
+	budgets, err := h.budgetService.List(uint(workspaceID), month)
+	if err != nil {
+		respondBudgetError(c, err, "Failed to fetch budgets")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"budgets": budgets})
+}
+
+type ApplyBudgetTemplatesRequest struct {
+	Month string `json:"month" binding:"required"` // YYYY-MM
+}
+
+// ApplyTemplates creates the month's budgets due from templates and returns all of the month's budgets.
+func (h *BudgetHandler) ApplyTemplates(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req ApplyBudgetTemplatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budgets, err := h.budgetService.ApplyTemplates(uint(workspaceID), req.Month)
+	if err != nil {
+		respondBudgetError(c, err, "Failed to apply budget templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"budgets": budgets})
+}
+
+// Status compares the budgets of ?month=YYYY-MM with the month's spending.
+func (h *BudgetHandler) Status(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	month := c.Query("month")
+	if month == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month parameter is required (YYYY-MM)"})
+		return
+	}
+
+	report, err := h.budgetService.Report(uint(workspaceID), month)
+	if err != nil {
+		respondBudgetError(c, err, "Failed to compute budget status")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *BudgetHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget := &models.Budget{
+		WorkspaceID: uint(workspaceID),
+		Month:       req.Month,
+		CategoryID:  req.CategoryID,
+		AreaID:      req.AreaID,
+		Amount:      req.Amount,
+		Rollover:    req.Rollover,
+	}
+	if err := h.budgetService.Save(budget); err != nil {
+		respondBudgetError(c, err, "Failed to create budget")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"budget": budget})
+}
+
+func (h *BudgetHandler) Update(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	budgetID, _ := strconv.ParseUint(c.Param("budget_id"), 10, 32)
+
+	budget, err := h.budgetService.FindByID(uint(budgetID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	var req UpdateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount != nil {
+		budget.Amount = *req.Amount
+	}
+	if req.Rollover != nil {
+		budget.Rollover = *req.Rollover
+	}
+
+	if err := h.budgetService.Save(budget); err != nil {
+		respondBudgetError(c, err, "Failed to update budget")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"budget": budget})
+}
+
+func (h *BudgetHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	budgetID, _ := strconv.ParseUint(c.Param("budget_id"), 10, 32)
+
+	if err := h.budgetService.Delete(uint(budgetID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *BudgetHandler) ListTemplates(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	templates, err := h.budgetService.ListTemplates(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budget templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+func (h *BudgetHandler) CreateTemplate(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req CreateBudgetTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template := &models.BudgetTemplate{
+		WorkspaceID: uint(workspaceID),
+		CategoryID:  req.CategoryID,
+		AreaID:      req.AreaID,
+		Amount:      req.Amount,
+		Rollover:    req.Rollover,
+		StartMonth:  req.StartMonth,
+		EndMonth:    req.EndMonth,
+	}
+	if err := h.budgetService.SaveTemplate(template); err != nil {
+		respondBudgetError(c, err, "Failed to create budget template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"template": template})
+}
+
+func (h *BudgetHandler) UpdateTemplate(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	templateID, _ := strconv.ParseUint(c.Param("template_id"), 10, 32)
+
+	template, err := h.budgetService.FindTemplateByID(uint(templateID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget template not found"})
+		return
+	}
+
+	var req UpdateBudgetTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount != nil {
+		template.Amount = *req.Amount
+	}
+	if req.Rollover != nil {
+		template.Rollover = *req.Rollover
+	}
+	if req.EndMonth != nil {
+		template.EndMonth = *req.EndMonth
+	}
+
+	if err := h.budgetService.SaveTemplate(template); err != nil {
+		respondBudgetError(c, err, "Failed to update budget template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"template": template})
+}
+
+func (h *BudgetHandler) DeleteTemplate(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	templateID, _ := strconv.ParseUint(c.Param("template_id"), 10, 32)
+
+	if err := h.budgetService.DeleteTemplate(uint(templateID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget template"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAlerts returns budget alerts, optionally for ?month=YYYY-MM and only unacknowledged ones with
+// ?pending=true.
+func (h *BudgetHandler) ListAlerts(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	alerts, err := h.budgetService.ListAlerts(uint(workspaceID), c.Query("month"), c.Query("pending") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budget alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
+
+func (h *BudgetHandler) AcknowledgeAlert(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	alertID, _ := strconv.ParseUint(c.Param("alert_id"), 10, 32)
+
+	if err := h.budgetService.AcknowledgeAlert(uint(alertID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget alert not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge budget alert"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CardPaymentHandler struct {
+	cardPaymentService *services.CardPaymentService
+}
+
+func NewCardPaymentHandler(cardPaymentService *services.CardPaymentService) *CardPaymentHandler {
+	return &CardPaymentHandler{cardPaymentService: cardPaymentService}
+}
+
+func (h *CardPaymentHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	payments, err := h.cardPaymentService.List(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch card payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"card_payments": payments})
+}
+
+// Candidates lists bank debits that look like card bill payments ("PAGO VISA", ...) within optional
+// from / to (YYYY-MM-DD, inclusive), with a suggested card account and statement check.
+func (h *CardPaymentHandler) Candidates(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	candidates, err := h.cardPaymentService.Candidates(uint(workspaceID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find card payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+}
+
+// Create marks a debit as a card payment, excluding it from expense totals. The response includes the
+// check of the paid amount against the statement balance.
+func (h *CardPaymentHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req services.CardPaymentInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.cardPaymentService.Create(uint(workspaceID), c.MustGet("userID").(uint), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCardPayment):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTransactionLinked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create card payment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"card_payment": payment})
+}
+
+func (h *CardPaymentHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	paymentID, _ := strconv.ParseUint(c.Param("payment_id"), 10, 32)
+
+	if err := h.cardPaymentService.Delete(uint(paymentID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Card payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete card payment"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategorizationHandler struct {
+	feedbackService *services.CategorizationFeedbackService
+}
+
+func NewCategorizationHandler(feedbackService *services.CategorizationFeedbackService) *CategorizationHandler {
+	return &CategorizationHandler{feedbackService: feedbackService}
+}
+
+// GetAccuracy reports prediction accuracy for the workspace. Optional query params:
+// from / to (YYYY-MM-DD, default: last 6 months) and top (number of confused pairs, default 10).
+func (h *CategorizationHandler) GetAccuracy(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	from := to.AddDate(0, -6, 0)
+
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date. Use YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date. Use YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	top, _ := strconv.Atoi(c.DefaultQuery("top", "10"))
+
+	report, err := h.feedbackService.GetAccuracyReport(uint(workspaceID), from, to, top)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization accuracy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accuracy": report})
+}
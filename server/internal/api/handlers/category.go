@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -21,17 +22,19 @@ func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHand
 }
 
 type CreateCategoryRequest struct {
-	Name   string `json:"name" binding:"required"`
-	AreaID *uint  `json:"area_id"`
-	Color  string `json:"color"`
-	Icon   string `json:"icon"`
+	Name     string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+	AreaID   *uint  `json:"area_id"`
+	Color    string `json:"color"`
+	Icon     string `json:"icon"`
 }
 
 type UpdateCategoryRequest struct {
-	Name   *string          `json:"name"`
-	Color  *string          `json:"color"`
-	Icon   *string          `json:"icon"`
-	AreaID nullableUintJSON `json:"area_id"`
+	Name     *string          `json:"name"`
+	Color    *string          `json:"color"`
+	Icon     *string          `json:"icon"`
+	ParentID nullableUintJSON `json:"parent_id"`
+	AreaID   nullableUintJSON `json:"area_id"`
 }
 
 // nullableUintJSON distinguishes JSON key omission (Provided=false), explicit JSON null (clear FK),
@@ -78,9 +81,17 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if req.ParentID != nil {
+		if err := h.categoryService.ValidateParent(uint(workspaceID), 0, *req.ParentID); err != nil {
+			respondCategoryParentError(c, err)
+			return
+		}
+	}
+
 	category := &models.Category{
 		WorkspaceID: uint(workspaceID),
 		Name:        req.Name,
+		ParentID:    req.ParentID,
 		AreaID:      req.AreaID,
 		Color:       req.Color,
 		Icon:        req.Icon,
@@ -134,6 +145,18 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		updates["icon"] = *req.Icon
 	}
 
+	if req.ParentID.Provided {
+		if req.ParentID.Ptr == nil {
+			updates["parent_id"] = nil
+		} else {
+			if err := h.categoryService.ValidateParent(uint(workspaceID), uint(categoryID), *req.ParentID.Ptr); err != nil {
+				respondCategoryParentError(c, err)
+				return
+			}
+			updates["parent_id"] = *req.ParentID.Ptr
+		}
+	}
+
 	if req.AreaID.Provided {
 		switch {
 		case req.AreaID.Ptr == nil:
@@ -172,14 +195,74 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"category": category})
 }
 
+// Delete removes a category. Its transactions move to the category given by ?reassign_to, or to Missing.
 func (h *CategoryHandler) Delete(c *gin.Context) {
 	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	categoryID, _ := strconv.ParseUint(c.Param("cat_id"), 10, 32)
 
-	if err := h.categoryService.Delete(uint(categoryID), uint(workspaceID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+	var reassignTo *uint
+	if raw := c.Query("reassign_to"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reassign_to must be a category id"})
+			return
+		}
+		target := uint(parsed)
+		reassignTo = &target
+	}
+
+	if _, err := h.categoryService.Delete(uint(categoryID), uint(workspaceID), reassignTo); err != nil {
+		respondCategoryReassignError(c, err, "Failed to delete category")
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+type MergeCategoryRequest struct {
+	TargetID uint `json:"target_id" binding:"required"`
+}
+
+// Merge folds the category into target_id and deletes it.
+func (h *CategoryHandler) Merge(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	categoryID, _ := strconv.ParseUint(c.Param("cat_id"), 10, 32)
+
+	var req MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.categoryService.Merge(uint(categoryID), req.TargetID, uint(workspaceID))
+	if err != nil {
+		respondCategoryReassignError(c, err, "Failed to merge categories")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+func respondCategoryReassignError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMissingCategoryProtected), errors.Is(err, services.ErrCategoryMergeSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func respondCategoryParentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCategoryParentNotFound),
+		errors.Is(err, services.ErrCategoryCycle),
+		errors.Is(err, services.ErrCategoryTooDeep),
+		errors.Is(err, services.ErrMissingCategoryNesting),
+		errors.Is(err, services.ErrCategoryParentHasRules):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate parent category"})
+	}
+}
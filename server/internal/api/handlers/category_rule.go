@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryRuleHandler struct {
+	ruleService *services.CategoryRuleService
+}
+
+func NewCategoryRuleHandler(ruleService *services.CategoryRuleService) *CategoryRuleHandler {
+	return &CategoryRuleHandler{ruleService: ruleService}
+}
+
+type CreateCategoryRuleRequest struct {
+	Pattern  string `json:"pattern" binding:"required"`
+	Type     string `json:"type"`
+	Category string `json:"category" binding:"required"`
+	Priority int    `json:"priority"`
+}
+
+type UpdateCategoryRuleRequest struct {
+	Pattern  *string `json:"pattern"`
+	Type     *string `json:"type"`
+	Category *string `json:"category"`
+	Priority *int    `json:"priority"`
+}
+
+func (h *CategoryRuleHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	rules, err := h.ruleService.List(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func (h *CategoryRuleHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req CreateCategoryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &models.CategoryRule{
+		WorkspaceID: uint(workspaceID),
+		Pattern:     req.Pattern,
+		Type:        req.Type,
+		Category:    req.Category,
+		Priority:    req.Priority,
+	}
+
+	if err := h.ruleService.Create(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"rule": rule})
+}
+
+func (h *CategoryRuleHandler) Update(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	ruleID, _ := strconv.ParseUint(c.Param("rule_id"), 10, 32)
+
+	rule, err := h.ruleService.FindByID(uint(ruleID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category rule not found"})
+		return
+	}
+
+	var req UpdateCategoryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Pattern != nil {
+		rule.Pattern = *req.Pattern
+	}
+	if req.Type != nil {
+		rule.Type = *req.Type
+	}
+	if req.Category != nil {
+		rule.Category = *req.Category
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+
+	if err := h.ruleService.Update(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+func (h *CategoryRuleHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	ruleID, _ := strconv.ParseUint(c.Param("rule_id"), 10, 32)
+
+	if err := h.ruleService.Delete(uint(ruleID), uint(workspaceID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category rule"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
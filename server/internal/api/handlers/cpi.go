@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CPIHandler struct {
+	cpiService *services.CPIService
+}
+
+func NewCPIHandler(cpiService *services.CPIService) *CPIHandler {
+	return &CPIHandler{cpiService: cpiService}
+}
+
+type UpsertCPIRequest struct {
+	Value float64 `json:"value" binding:"required,gt=0"`
+}
+
+func respondCPIError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCPI), errors.Is(err, services.ErrNoCPISeries), errors.Is(err, services.ErrNoCPIProvider):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// deflatorQuery reads ?real=true and the optional ?base=YYYY-MM (default: the latest CPI month). It
+// returns nil, meaning nominal amounts, unless real terms were requested.
+func deflatorQuery(c *gin.Context, cpiService *services.CPIService, workspaceID uint) (*services.Deflator, error) {
+	if c.Query("real") != "true" {
+		return nil, nil
+	}
+	return cpiService.Deflator(workspaceID, c.Query("base"))
+}
+
+func (h *CPIHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	values, err := h.cpiService.List(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch CPI series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cpi": values})
+}
+
+// Import loads a CSV of month and index value from the "file" form field, replacing existing months.
+func (h *CPIHandler) Import(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer f.Close()
+
+	points, err := services.ParseCPISeries(f)
+	if err != nil {
+		respondCPIError(c, err, "Failed to read CPI series")
+		return
+	}
+	count, err := h.cpiService.Import(uint(workspaceID), points, "csv")
+	if err != nil {
+		respondCPIError(c, err, "Failed to import CPI series")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": count})
+}
+
+// Sync imports the series from the server's configured CPI provider.
+func (h *CPIHandler) Sync(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	count, err := h.cpiService.Sync(uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to sync CPI series")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": count})
+}
+
+func (h *CPIHandler) Upsert(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	month := c.Param("month")
+
+	var req UpsertCPIRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month. Use YYYY-MM"})
+		return
+	}
+
+	if _, err := h.cpiService.Import(uint(workspaceID), []services.CPIPoint{{Month: month, Value: req.Value}}, "manual"); err != nil {
+		respondCPIError(c, err, "Failed to save CPI value")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"month": month, "value": req.Value})
+}
+
+func (h *CPIHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	if err := h.cpiService.Delete(uint(workspaceID), c.Param("month")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete CPI value"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
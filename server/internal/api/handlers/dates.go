@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseDateRangeQuery reads optional from / to query params (YYYY-MM-DD, both inclusive) and returns them
+// as a half-open [from, to) range. Missing bounds are nil.
+func parseDateRangeQuery(c *gin.Context) (from, to *time.Time, err error) {
+	return parseDateRangeParams(c, "from", "to")
+}
+
+// parseDateRangeParams is parseDateRangeQuery for arbitrary param names.
+func parseDateRangeParams(c *gin.Context, fromKey, toKey string) (from, to *time.Time, err error) {
+	if raw := c.Query(fromKey); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, nil, errors.New("Invalid " + fromKey + " date. Use YYYY-MM-DD")
+		}
+		from = &parsed
+	}
+	if raw := c.Query(toKey); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, nil, errors.New("Invalid " + toKey + " date. Use YYYY-MM-DD")
+		}
+		end := parsed.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, errors.New(fromKey + " must not be after " + toKey)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ForecastHandler struct {
+	forecastService *services.ForecastService
+}
+
+func NewForecastHandler(forecastService *services.ForecastService) *ForecastHandler {
+	return &ForecastHandler{forecastService: forecastService}
+}
+
+// CashFlow projects each account's daily balance for the next ?days= days (default 30), from
+// ?history_months= months of history (default 3), flagging the days an account would be negative.
+func (h *ForecastHandler) CashFlow(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var query services.ForecastQuery
+	if raw := c.Query("days"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a number"})
+			return
+		}
+		query.Days = days
+	}
+	if raw := c.Query("history_months"); raw != "" {
+		months, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "history_months must be a number"})
+			return
+		}
+		query.HistoryMonths = months
+	}
+
+	forecast, err := h.forecastService.CashFlow(uint(workspaceID), time.Now(), query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidForecast) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute forecast"})
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OwnerHandler struct {
+	ownerService *services.OwnerService
+}
+
+func NewOwnerHandler(ownerService *services.OwnerService) *OwnerHandler {
+	return &OwnerHandler{ownerService: ownerService}
+}
+
+type CreateOwnerRequest struct {
+	Name     string `json:"name" binding:"required"`
+	MemberID *uint  `json:"member_id"` // nil for an external person
+}
+
+type UpdateOwnerRequest struct {
+	Name     *string `json:"name"`
+	MemberID *uint   `json:"member_id"` // 0 unlinks the member, making the owner an external person
+}
+
+type MergeOwnersRequest struct {
+	SourceIDs []uint `json:"source_ids" binding:"required,min=1"`
+}
+
+func respondOwnerError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidOwner):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOwnerInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Owner not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *OwnerHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	owners, err := h.ownerService.List(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch owners"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owners": owners})
+}
+
+func (h *OwnerHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req CreateOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner := &models.Owner{WorkspaceID: uint(workspaceID), Name: req.Name, MemberID: req.MemberID}
+	if err := h.ownerService.Create(owner); err != nil {
+		respondOwnerError(c, err, "Failed to create owner")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"owner": owner})
+}
+
+// Update renames an owner, everywhere it is used, or links it to a member.
+func (h *OwnerHandler) Update(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	ownerID, _ := strconv.ParseUint(c.Param("owner_id"), 10, 32)
+
+	owner, err := h.ownerService.FindByID(uint(ownerID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Owner not found"})
+		return
+	}
+
+	var req UpdateOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name != nil {
+		owner.Name = *req.Name
+	}
+	if req.MemberID != nil {
+		owner.MemberID = nil
+		if *req.MemberID != 0 {
+			owner.MemberID = req.MemberID
+		}
+	}
+
+	if err := h.ownerService.Update(owner); err != nil {
+		respondOwnerError(c, err, "Failed to update owner")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owner": owner})
+}
+
+func (h *OwnerHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	ownerID, _ := strconv.ParseUint(c.Param("owner_id"), 10, 32)
+
+	if err := h.ownerService.Delete(uint(ownerID), uint(workspaceID)); err != nil {
+		respondOwnerError(c, err, "Failed to delete owner")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Merge folds the owners in source_ids into the owner in the path, keeping their names as aliases.
+func (h *OwnerHandler) Merge(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	ownerID, _ := strconv.ParseUint(c.Param("owner_id"), 10, 32)
+
+	var req MergeOwnersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner, err := h.ownerService.Merge(uint(workspaceID), uint(ownerID), req.SourceIDs)
+	if err != nil {
+		respondOwnerError(c, err, "Failed to merge owners")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owner": owner})
+}
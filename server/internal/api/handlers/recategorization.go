@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RecategorizationHandler struct {
+	recategorizationService *services.RecategorizationService
+}
+
+func NewRecategorizationHandler(recategorizationService *services.RecategorizationService) *RecategorizationHandler {
+	return &RecategorizationHandler{recategorizationService: recategorizationService}
+}
+
+type CreateRecategorizationRequest struct {
+	Filter           services.RecategorizationFilter `json:"filter"`
+	Strategy         string                          `json:"strategy" binding:"required,oneof=rules local llm"`
+	IncludeConfirmed bool                            `json:"include_confirmed"`
+}
+
+type ApplyRecategorizationRequest struct {
+	// ChangeIDs limits the apply to a reviewed subset; empty applies every proposed change.
+	ChangeIDs []uint `json:"change_ids"`
+}
+
+// Create runs a recategorization preview and returns the job with its proposed changes.
+func (h *RecategorizationHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	var req CreateRecategorizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.recategorizationService.Preview(uint(workspaceID), userID, services.RecategorizationRequest{
+		Filter:           req.Filter,
+		Strategy:         req.Strategy,
+		IncludeConfirmed: req.IncludeConfirmed,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRecategorizeFilter) || errors.Is(err, services.ErrUnknownRecategorizeStrategy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"job": job})
+}
+
+func (h *RecategorizationHandler) Get(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	jobID, _ := strconv.ParseUint(c.Param("job_id"), 10, 32)
+
+	job, err := h.recategorizationService.FindByID(uint(jobID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recategorization job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+func (h *RecategorizationHandler) Apply(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	jobID, _ := strconv.ParseUint(c.Param("job_id"), 10, 32)
+
+	var req ApplyRecategorizationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	job, err := h.recategorizationService.Apply(uint(jobID), uint(workspaceID), req.ChangeIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recategorization job not found"})
+		case errors.Is(err, services.ErrRecategorizationApplied):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCategoryNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply recategorization"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
@@ -12,11 +12,12 @@ import (
 )
 
 type RecurringExpenseHandler struct {
-	service *services.RecurringExpenseService
+	service    *services.RecurringExpenseService
+	cpiService *services.CPIService
 }
 
-func NewRecurringExpenseHandler(service *services.RecurringExpenseService) *RecurringExpenseHandler {
-	return &RecurringExpenseHandler{service: service}
+func NewRecurringExpenseHandler(service *services.RecurringExpenseService, cpiService *services.CPIService) *RecurringExpenseHandler {
+	return &RecurringExpenseHandler{service: service, cpiService: cpiService}
 }
 
 type CreateRecurringExpenseRequest struct {
@@ -25,6 +26,7 @@ type CreateRecurringExpenseRequest struct {
 	CategoryID *uint   `json:"category_id"`
 	Owner      string  `json:"owner"`
 	DueDay     int     `json:"due_day" binding:"required"`
+	AccountID  *uint   `json:"account_id"`
 }
 
 type UpdateRecurringExpenseRequest struct {
@@ -33,6 +35,7 @@ type UpdateRecurringExpenseRequest struct {
 	CategoryID *uint    `json:"category_id"`
 	Owner      *string  `json:"owner"`
 	DueDay     *int     `json:"due_day"`
+	AccountID  *uint    `json:"account_id"` // 0 unassigns the account
 }
 
 type RecurringExpenseResponse struct {
@@ -46,6 +49,7 @@ type RecurringExpenseResponse struct {
 	AreaName        string  `json:"area_name,omitempty"`
 	Owner           string  `json:"owner"`
 	DueDay          int     `json:"due_day"`
+	AccountID       *uint   `json:"account_id"`
 	LastPaidDate    *string `json:"last_paid_date"`
 	IsPaidThisMonth bool    `json:"is_paid_this_month"`
 	CreatedAt       string  `json:"created_at"`
@@ -61,6 +65,7 @@ func toRecurringExpenseResponse(exp *models.RecurringExpense) RecurringExpenseRe
 		CategoryID:      exp.CategoryID,
 		Owner:           exp.Owner,
 		DueDay:          exp.DueDay,
+		AccountID:       exp.AccountID,
 		IsPaidThisMonth: exp.IsPaidThisMonth(),
 		CreatedAt:       exp.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       exp.UpdatedAt.Format(time.RFC3339),
@@ -106,7 +111,13 @@ func (h *RecurringExpenseHandler) GetSummary(c *gin.Context) {
 		}
 	}
 
-	summary, err := h.service.GetSummary(uint(workspaceID), month)
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	summary, err := h.service.GetSummary(uint(workspaceID), month, deflator)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch summary"})
 		return
@@ -131,6 +142,7 @@ func (h *RecurringExpenseHandler) Create(c *gin.Context) {
 		CategoryID:  req.CategoryID,
 		Owner:       req.Owner,
 		DueDay:      req.DueDay,
+		AccountID:   req.AccountID,
 	}
 
 	if err := h.service.Create(expense); err != nil {
@@ -187,6 +199,12 @@ func (h *RecurringExpenseHandler) Update(c *gin.Context) {
 	if req.DueDay != nil {
 		expense.DueDay = *req.DueDay
 	}
+	if req.AccountID != nil {
+		expense.AccountID = nil
+		if *req.AccountID != 0 {
+			expense.AccountID = req.AccountID
+		}
+	}
 
 	if err := h.service.Update(expense); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
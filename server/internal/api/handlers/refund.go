@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RefundHandler struct {
+	refundService *services.RefundService
+}
+
+func NewRefundHandler(refundService *services.RefundService) *RefundHandler {
+	return &RefundHandler{refundService: refundService}
+}
+
+type LinkRefundRequest struct {
+	RefundTransactionID   uint `json:"refund_transaction_id" binding:"required"`
+	OriginalTransactionID uint `json:"original_transaction_id" binding:"required"`
+}
+
+// Candidates suggests the purchase each unlinked credit within optional from / to (YYYY-MM-DD, inclusive)
+// most likely refunds.
+func (h *RefundHandler) Candidates(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	candidates, err := h.refundService.Candidates(uint(workspaceID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find refund candidates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+}
+
+func (h *RefundHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	refunds, err := h.refundService.List(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
+}
+
+// Link marks a credit as a refund of a purchase; summaries then net it against the purchase's category.
+func (h *RefundHandler) Link(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req LinkRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refund, err := h.refundService.Link(uint(workspaceID), req.RefundTransactionID, req.OriginalTransactionID, c.MustGet("userID").(uint))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRefund):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTransactionLinked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link refund"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"refund": refund})
+}
+
+func (h *RefundHandler) Unlink(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	refundID, _ := strconv.ParseUint(c.Param("refund_id"), 10, 32)
+
+	if err := h.refundService.Unlink(uint(refundID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Refund not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink refund"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
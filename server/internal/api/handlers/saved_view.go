@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SavedViewHandler struct {
+	savedViewService *services.SavedViewService
+}
+
+func NewSavedViewHandler(savedViewService *services.SavedViewService) *SavedViewHandler {
+	return &SavedViewHandler{savedViewService: savedViewService}
+}
+
+type SaveViewRequest struct {
+	Name   string                   `json:"name" binding:"required"`
+	Shared *bool                    `json:"shared"` // omitted keeps an existing view's visibility
+	Filter services.SavedViewFilter `json:"filter"`
+}
+
+func (h *SavedViewHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	views, err := h.savedViewService.List(uint(workspaceID), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved views"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"views": views})
+}
+
+func (h *SavedViewHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	var req SaveViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	view := &services.SavedView{Filter: req.Filter}
+	view.WorkspaceID = uint(workspaceID)
+	view.Name = req.Name
+
+	if err := h.savedViewService.Save(view, userID, req.Shared); err != nil {
+		respondSavedViewError(c, err, "Failed to save view")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"view": view})
+}
+
+func (h *SavedViewHandler) Get(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	viewID, _ := strconv.ParseUint(c.Param("view_id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	view, err := h.savedViewService.FindByID(uint(viewID), uint(workspaceID), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved view not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"view": view})
+}
+
+func (h *SavedViewHandler) Update(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	viewID, _ := strconv.ParseUint(c.Param("view_id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	view, err := h.savedViewService.FindByID(uint(viewID), uint(workspaceID), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved view not found"})
+		return
+	}
+
+	var req SaveViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	view.Name = req.Name
+	view.Filter = req.Filter
+	if err := h.savedViewService.Save(view, userID, req.Shared); err != nil {
+		respondSavedViewError(c, err, "Failed to save view")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"view": view})
+}
+
+func (h *SavedViewHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	viewID, _ := strconv.ParseUint(c.Param("view_id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	if err := h.savedViewService.Delete(uint(viewID), uint(workspaceID), userID); err != nil {
+		respondSavedViewError(c, err, "Failed to delete view")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Execute runs the view and returns its totals plus one page of transactions. Accepts page, per_page and
+// cursor like the transaction list.
+func (h *SavedViewHandler) Execute(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	viewID, _ := strconv.ParseUint(c.Param("view_id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	var cursor *string
+	if raw, ok := c.GetQuery("cursor"); ok {
+		cursor = &raw
+	}
+
+	result, err := h.savedViewService.Execute(uint(viewID), uint(workspaceID), userID, page, perPage, cursor)
+	if err != nil {
+		respondSavedViewError(c, err, "Failed to run view")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+func respondSavedViewError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved view not found"})
+	case errors.Is(err, services.ErrInvalidSavedView), errors.Is(err, services.ErrInvalidTransactionQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSavedViewVisibility):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SettlementHandler struct {
+	settlementService *services.SettlementService
+	cpiService        *services.CPIService
+}
+
+func NewSettlementHandler(settlementService *services.SettlementService, cpiService *services.CPIService) *SettlementHandler {
+	return &SettlementHandler{settlementService: settlementService, cpiService: cpiService}
+}
+
+type SplitRuleRequest struct {
+	CategoryID *uint                      `json:"category_id"` // nil for the workspace default
+	Shares     []services.SplitShareInput `json:"shares" binding:"required"`
+}
+
+type CreateSettlementPaymentRequest struct {
+	FromOwner string  `json:"from_owner" binding:"required"`
+	ToOwner   string  `json:"to_owner" binding:"required"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Date      string  `json:"date" binding:"required"` // YYYY-MM-DD
+	Note      string  `json:"note"`
+}
+
+func respondSettlementError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, services.ErrInvalidSettlement) || errors.Is(err, services.ErrInvalidOwner) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+// requiredDateRange reads the from / to query params, both required.
+func requiredDateRange(c *gin.Context) (from, to time.Time, ok bool) {
+	fromRef, toRef, err := parseDateRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return from, to, false
+	}
+	if fromRef == nil || toRef == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to parameters are required (YYYY-MM-DD)"})
+		return from, to, false
+	}
+	return *fromRef, *toRef, true
+}
+
+// OwnerBreakdown totals spending and income per owner for ?from&to, with spending by category at ?level.
+// ?real=true restates the amounts in constant pesos (see deflatorQuery).
+func (h *SettlementHandler) OwnerBreakdown(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, ok := requiredDateRange(c)
+	if !ok {
+		return
+	}
+	level, err := services.ParseCategoryLevel(c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	owners, err := h.settlementService.OwnerBreakdown(uint(workspaceID), from, to, level, deflator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute owner breakdown"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owners": owners, "real_terms": deflator.RealTerms()})
+}
+
+func (h *SettlementHandler) ListRules(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	rules, err := h.settlementService.ListRules(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch split rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func (h *SettlementHandler) CreateRule(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req SplitRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &models.SplitRule{WorkspaceID: uint(workspaceID), CategoryID: req.CategoryID}
+	if err := h.settlementService.SaveRule(rule, req.Shares); err != nil {
+		respondSettlementError(c, err, "Failed to create split rule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"rule": rule})
+}
+
+func (h *SettlementHandler) UpdateRule(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	ruleID, _ := strconv.ParseUint(c.Param("rule_id"), 10, 32)
+
+	rule, err := h.settlementService.FindRuleByID(uint(ruleID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Split rule not found"})
+		return
+	}
+
+	var req SplitRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule.CategoryID = req.CategoryID
+
+	if err := h.settlementService.SaveRule(rule, req.Shares); err != nil {
+		respondSettlementError(c, err, "Failed to update split rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+func (h *SettlementHandler) DeleteRule(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	ruleID, _ := strconv.ParseUint(c.Param("rule_id"), 10, 32)
+
+	if err := h.settlementService.DeleteRule(uint(ruleID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Split rule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete split rule"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Report returns who owes whom for ?from&to, net of the settlement payments recorded in the period.
+// ?real=true restates the balances in constant pesos (see deflatorQuery).
+func (h *SettlementHandler) Report(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, ok := requiredDateRange(c)
+	if !ok {
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	report, err := h.settlementService.Report(uint(workspaceID), from, to, deflator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute settlement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListPayments returns settlement payments, optionally within ?from&to.
+func (h *SettlementHandler) ListPayments(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payments, err := h.settlementService.ListPayments(uint(workspaceID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settlement payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payments": payments})
+}
+
+func (h *SettlementHandler) CreatePayment(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.MustGet("userID").(uint)
+
+	var req CreateSettlementPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date. Use YYYY-MM-DD"})
+		return
+	}
+
+	payment := &models.SettlementPayment{
+		WorkspaceID: uint(workspaceID),
+		FromOwner:   req.FromOwner,
+		ToOwner:     req.ToOwner,
+		Amount:      req.Amount,
+		Date:        date,
+		Note:        req.Note,
+		CreatedBy:   userID,
+	}
+	if err := h.settlementService.RecordPayment(payment); err != nil {
+		respondSettlementError(c, err, "Failed to record settlement payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"payment": payment})
+}
+
+func (h *SettlementHandler) DeletePayment(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	paymentID, _ := strconv.ParseUint(c.Param("payment_id"), 10, 32)
+
+	if err := h.settlementService.DeletePayment(uint(paymentID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Settlement payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete settlement payment"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TagHandler struct {
+	tagService *services.TagService
+	cpiService *services.CPIService
+}
+
+func NewTagHandler(tagService *services.TagService, cpiService *services.CPIService) *TagHandler {
+	return &TagHandler{tagService: tagService, cpiService: cpiService}
+}
+
+type CreateTagRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Color string `json:"color"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+type SetTransactionTagsRequest struct {
+	TagIDs []uint `json:"tag_ids"`
+}
+
+func (h *TagHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	tags, err := h.tagService.List(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func (h *TagHandler) Create(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag := &models.Tag{
+		WorkspaceID: uint(workspaceID),
+		Name:        req.Name,
+		Color:       req.Color,
+	}
+
+	if err := h.tagService.Create(tag); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"tag": tag})
+}
+
+func (h *TagHandler) Update(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	tagID, _ := strconv.ParseUint(c.Param("tag_id"), 10, 32)
+
+	tag, err := h.tagService.FindByID(uint(tagID), uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	var req UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		tag.Name = *req.Name
+	}
+	if req.Color != nil {
+		tag.Color = *req.Color
+	}
+
+	if err := h.tagService.Update(tag); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+func (h *TagHandler) Delete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	tagID, _ := strconv.ParseUint(c.Param("tag_id"), 10, 32)
+
+	if err := h.tagService.Delete(uint(tagID), uint(workspaceID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetTransactionTags replaces the tags of one transaction with tag_ids (an empty list clears them).
+func (h *TagHandler) SetTransactionTags(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	transactionID, _ := strconv.ParseUint(c.Param("txn_id"), 10, 32)
+
+	var req SetTransactionTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.tagService.SetTransactionTags(uint(workspaceID), uint(transactionID), req.TagIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		case errors.Is(err, services.ErrTagNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction tags"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
+}
+
+// GetSummary returns totals by tag. Optional query params: from / to (YYYY-MM-DD, inclusive).
+func (h *TagHandler) GetSummary(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	totals, err := h.tagService.Summary(uint(workspaceID), from, to, deflator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag summary"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":       c.Query("from"),
+		"to":         c.Query("to"),
+		"tags":       totals,
+		"real_terms": deflator.RealTerms(),
+	})
+}
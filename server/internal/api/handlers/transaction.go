@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,14 +12,17 @@ import (
 	"etl-banks-ar/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type TransactionHandler struct {
 	transactionService *services.TransactionService
+	categoryService    *services.CategoryService
+	cpiService         *services.CPIService
 }
 
-func NewTransactionHandler(transactionService *services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{transactionService: transactionService}
+func NewTransactionHandler(transactionService *services.TransactionService, categoryService *services.CategoryService, cpiService *services.CPIService) *TransactionHandler {
+	return &TransactionHandler{transactionService: transactionService, categoryService: categoryService, cpiService: cpiService}
 }
 
 type CreateTransactionRequest struct {
@@ -26,8 +31,10 @@ type CreateTransactionRequest struct {
 	Amount      float64 `json:"amount" binding:"required"`
 	Type        string  `json:"type" binding:"required,oneof=debit credit"`
 	Category    string  `json:"category"`
+	CategoryID  *uint   `json:"category_id"`
 	Owner       string  `json:"owner"`
 	AreaID      *uint   `json:"area_id"`
+	AccountID   *uint   `json:"account_id"`
 }
 
 type UpdateTransactionRequest struct {
@@ -36,52 +43,132 @@ type UpdateTransactionRequest struct {
 	Amount        *float64 `json:"amount"`
 	Type          *string  `json:"type"`
 	Category      *string  `json:"category"`
+	CategoryID    *uint    `json:"category_id"`
 	Owner         *string  `json:"owner"`
 	AreaID        *uint    `json:"area_id"`
+	AccountID     *uint    `json:"account_id"` // 0 clears the account
 	UserConfirmed *bool    `json:"user_confirmed"`
 }
 
+// List searches transactions. Date range: month (YYYY-MM) or from / to (YYYY-MM-DD, inclusive); without
+// either, all dates match. Other filters: category, tag, owner, area_id, account_id (repeatable), type,
+// min_amount, max_amount, q (description text), confirmed (true|false) and import_batch_id. sort takes a
+// comma-separated list of columns with an optional "-" prefix for descending; order sets the direction of
+// unprefixed ones.
+// Pass cursor (empty for the first page) to page with next_cursor instead of page numbers.
 func (h *TransactionHandler) List(c *gin.Context) {
 	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
-	month := c.Query("month")
 
-	if month == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month parameter is required (YYYY-MM)"})
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
-
-	filter := services.TransactionFilter{
-		WorkspaceID: uint(workspaceID),
-		Month:       month,
-		Categories:  c.QueryArray("category"),
-		Type:        c.Query("type"),
-		Page:        page,
-		PerPage:     perPage,
-		SortBy:      c.DefaultQuery("sort", "date"),
-		SortOrder:   c.DefaultQuery("order", "desc"),
-	}
+	filter.WorkspaceID = uint(workspaceID)
 
 	result, summary, err := h.transactionService.List(filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidTransactionQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
 		return
 	}
 
+	pagination := gin.H{
+		"page":        result.Page,
+		"per_page":    result.PerPage,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	}
+	if filter.Cursor != nil {
+		pagination["next_cursor"] = result.NextCursor
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"transactions": result.Transactions,
-		"pagination": gin.H{
-			"page":        result.Page,
-			"per_page":    result.PerPage,
-			"total":       result.Total,
-			"total_pages": result.TotalPages,
-		},
-		"summary": summary,
+		"pagination":   pagination,
+		"summary":      summary,
 	})
 }
 
+func parseTransactionFilter(c *gin.Context) (services.TransactionFilter, error) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	filter := services.TransactionFilter{
+		Month:      c.Query("month"),
+		Categories: c.QueryArray("category"),
+		Tags:       c.QueryArray("tag"),
+		Type:       c.Query("type"),
+		Text:       c.Query("q"),
+		Owners:     c.QueryArray("owner"),
+		Page:       page,
+		PerPage:    perPage,
+		SortBy:     c.DefaultQuery("sort", "date"),
+		SortOrder:  c.DefaultQuery("order", "desc"),
+	}
+
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.From, filter.To = from, to
+
+	if filter.MinAmount, err = parseFloatQuery(c, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseFloatQuery(c, "max_amount"); err != nil {
+		return filter, err
+	}
+	for _, raw := range c.QueryArray("area_id") {
+		areaID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return filter, errors.New("area_id must be a number")
+		}
+		filter.AreaIDs = append(filter.AreaIDs, uint(areaID))
+	}
+	for _, raw := range c.QueryArray("account_id") {
+		accountID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return filter, errors.New("account_id must be a number")
+		}
+		filter.AccountIDs = append(filter.AccountIDs, uint(accountID))
+	}
+	if raw := c.Query("confirmed"); raw != "" {
+		confirmed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, errors.New("confirmed must be true or false")
+		}
+		filter.Confirmed = &confirmed
+	}
+	if raw := c.Query("import_batch_id"); raw != "" {
+		batchID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return filter, errors.New("import_batch_id must be a number")
+		}
+		id := uint(batchID)
+		filter.ImportBatchID = &id
+	}
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		filter.Cursor = &cursor
+	}
+	return filter, nil
+}
+
+func parseFloatQuery(c *gin.Context, key string) (*float64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &value, nil
+}
+
 func (h *TransactionHandler) Create(c *gin.Context) {
 	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 
@@ -106,9 +193,23 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 		Category:    sql.NullString{String: req.Category, Valid: req.Category != ""},
 		Owner:       sql.NullString{String: req.Owner, Valid: req.Owner != ""},
 		AreaID:      req.AreaID,
+		AccountID:   req.AccountID,
+	}
+	if req.CategoryID != nil {
+		category, err := h.categoryService.FindByID(*req.CategoryID, uint(workspaceID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+			return
+		}
+		transaction.Category = sql.NullString{String: category.Name, Valid: true}
 	}
 
 	if err := h.transactionService.Create(transaction); err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) || errors.Is(err, services.ErrInvalidOwner) ||
+			errors.Is(err, services.ErrCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
 		return
 	}
@@ -163,17 +264,36 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 	if req.Category != nil {
 		transaction.Category = sql.NullString{String: *req.Category, Valid: *req.Category != ""}
 	}
+	if req.CategoryID != nil {
+		category, err := h.categoryService.FindByID(*req.CategoryID, uint(workspaceID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+			return
+		}
+		transaction.Category = sql.NullString{String: category.Name, Valid: true}
+	}
 	if req.Owner != nil {
 		transaction.Owner = sql.NullString{String: *req.Owner, Valid: *req.Owner != ""}
 	}
 	if req.AreaID != nil {
 		transaction.AreaID = req.AreaID
 	}
+	if req.AccountID != nil {
+		transaction.AccountID = req.AccountID
+		if *req.AccountID == 0 {
+			transaction.AccountID = nil
+		}
+	}
 	if req.UserConfirmed != nil {
 		transaction.UserConfirmed = *req.UserConfirmed
 	}
 
 	if err := h.transactionService.Update(transaction); err != nil {
+		if errors.Is(err, services.ErrAllocationSum) || errors.Is(err, services.ErrAccountNotFound) ||
+			errors.Is(err, services.ErrInvalidOwner) || errors.Is(err, services.ErrCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
 		return
 	}
@@ -181,6 +301,52 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
 }
 
+type SetAllocationsRequest struct {
+	Allocations []services.AllocationInput `json:"allocations"`
+}
+
+// SetAllocations splits a transaction into allocations that must add up to its amount. An empty list, like
+// DELETE on the same path, removes the split.
+func (h *TransactionHandler) SetAllocations(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	transactionID, _ := strconv.ParseUint(c.Param("txn_id"), 10, 32)
+
+	var req SetAllocationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondAllocations(c, uint(workspaceID), uint(transactionID), req.Allocations)
+}
+
+func (h *TransactionHandler) DeleteAllocations(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	transactionID, _ := strconv.ParseUint(c.Param("txn_id"), 10, 32)
+
+	h.respondAllocations(c, uint(workspaceID), uint(transactionID), nil)
+}
+
+func (h *TransactionHandler) respondAllocations(c *gin.Context, workspaceID, transactionID uint, allocations []services.AllocationInput) {
+	transaction, err := h.transactionService.SetAllocations(workspaceID, transactionID, allocations)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		case errors.Is(err, services.ErrTransactionLinked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAllocationSum), errors.Is(err, services.ErrAllocationInvalid),
+			errors.Is(err, services.ErrInvalidOwner), errors.Is(err, services.ErrCategoryNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update allocations"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
+}
+
 func (h *TransactionHandler) Delete(c *gin.Context) {
 	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	transactionID, _ := strconv.ParseUint(c.Param("txn_id"), 10, 32)
@@ -193,6 +359,56 @@ func (h *TransactionHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+type BulkUpdateRequest struct {
+	services.BulkSelection
+	Patch services.TransactionPatch `json:"patch"`
+}
+
+// BulkUpdate applies one patch to many transactions, selected by ids or by a filter with the same fields as
+// a saved view. Everything runs in one database transaction; if any row fails, nothing is changed and the
+// response is 409 with the per-row outcomes.
+func (h *TransactionHandler) BulkUpdate(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req BulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.transactionService.BulkUpdate(uint(workspaceID), req.BulkSelection, req.Patch)
+	respondBulk(c, result, err, "Failed to update transactions")
+}
+
+// BulkDelete deletes many transactions, selected like BulkUpdate, in one database transaction.
+func (h *TransactionHandler) BulkDelete(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req services.BulkSelection
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.transactionService.BulkDelete(uint(workspaceID), req)
+	respondBulk(c, result, err, "Failed to delete transactions")
+}
+
+func respondBulk(c *gin.Context, result *services.BulkResult, err error, fallback string) {
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"result": result})
+	case errors.Is(err, services.ErrBulkRolledBack):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "result": result})
+	case errors.Is(err, services.ErrInvalidBulkRequest),
+		errors.Is(err, services.ErrInvalidSavedView),
+		errors.Is(err, services.ErrInvalidTransactionQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
 func (h *TransactionHandler) GetSummary(c *gin.Context) {
 	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	month := c.Query("month")
@@ -202,7 +418,19 @@ func (h *TransactionHandler) GetSummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.transactionService.GetMonthlySummary(uint(workspaceID), month)
+	level, err := services.ParseCategoryLevel(c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	summary, err := h.transactionService.GetMonthlySummary(uint(workspaceID), month, level, deflator)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch summary"})
 		return
@@ -220,7 +448,19 @@ func (h *TransactionHandler) GetYearlySummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.transactionService.GetYearlySummary(uint(workspaceID), year)
+	level, err := services.ParseCategoryLevel(c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	summary, err := h.transactionService.GetYearlySummary(uint(workspaceID), year, level, deflator)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch yearly summary"})
 		return
@@ -229,26 +469,110 @@ func (h *TransactionHandler) GetYearlySummary(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"summary": summary})
 }
 
-func (h *TransactionHandler) GetCategories(c *gin.Context) {
+// Compare sets the from / to period against compare_from / compare_to (all YYYY-MM-DD, inclusive), with
+// totals and per-category (at ?level=) and per-area spending deltas. ?real=true restates both periods in
+// constant pesos (see deflatorQuery).
+func (h *TransactionHandler) Compare(c *gin.Context) {
 	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 
-	categories, err := h.transactionService.GetCategories(uint(workspaceID))
+	from, to, err := parseDateRangeQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	compareFrom, compareTo, err := parseDateRangeParams(c, "compare_from", "compare_to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from == nil || to == nil || compareFrom == nil || compareTo == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from, to, compare_from and compare_to are required (YYYY-MM-DD)"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"categories": categories})
+	level, err := services.ParseCategoryLevel(c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	comparison, err := h.transactionService.Compare(uint(workspaceID),
+		services.DateRange{From: *from, To: *to},
+		services.DateRange{From: *compareFrom, To: *compareTo},
+		level, deflator)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidComparison) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare periods"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comparison": comparison})
 }
 
-func (h *TransactionHandler) GetOwners(c *gin.Context) {
+// Aggregate totals the from / to range (YYYY-MM-DD, inclusive, both required) per ?bucket= (day, week,
+// month, quarter or year; default month), optionally split by ?group_by= (category, area, owner, account
+// or tag).
+func (h *TransactionHandler) Aggregate(c *gin.Context) {
 	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 
-	owners, err := h.transactionService.GetOwners(uint(workspaceID))
+	from, to, err := parseDateRangeQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch owners"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from == nil || to == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required (YYYY-MM-DD)"})
+		return
+	}
+
+	level, err := services.ParseCategoryLevel(c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"owners": owners})
+	deflator, err := deflatorQuery(c, h.cpiService, uint(workspaceID))
+	if err != nil {
+		respondCPIError(c, err, "Failed to load CPI series")
+		return
+	}
+
+	aggregation, err := h.transactionService.Aggregate(uint(workspaceID), services.AggregationQuery{
+		From:    *from,
+		To:      *to,
+		Bucket:  c.DefaultQuery("bucket", services.BucketMonth),
+		GroupBy: c.Query("group_by"),
+		Level:   level,
+	}, deflator)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAggregation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"aggregation": aggregation})
+}
+
+func (h *TransactionHandler) GetCategories(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	categories, err := h.transactionService.GetCategories(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
 }
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"etl-banks-ar/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TransferHandler struct {
+	transferService *services.TransferService
+}
+
+func NewTransferHandler(transferService *services.TransferService) *TransferHandler {
+	return &TransferHandler{transferService: transferService}
+}
+
+type LinkTransferRequest struct {
+	DebitTransactionID  uint `json:"debit_transaction_id" binding:"required"`
+	CreditTransactionID uint `json:"credit_transaction_id" binding:"required"`
+}
+
+// Candidates suggests unlinked debit/credit pairs that look like transfers between the workspace's own
+// accounts. Optional from / to (YYYY-MM-DD, inclusive) and days (how far apart the sides may be, default 3).
+func (h *TransferHandler) Candidates(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(services.DefaultTransferWindowDays)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a number"})
+		return
+	}
+
+	candidates, err := h.transferService.Candidates(uint(workspaceID), from, to, days)
+	if err != nil {
+		respondTransferError(c, err, "Failed to find transfer candidates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+}
+
+func (h *TransferHandler) List(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfers, err := h.transferService.List(uint(workspaceID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": transfers})
+}
+
+// Link marks a debit and a credit as one transfer, removing both from spending and income summaries.
+func (h *TransferHandler) Link(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req LinkTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.transferService.Link(uint(workspaceID), req.DebitTransactionID, req.CreditTransactionID, c.MustGet("userID").(uint))
+	if err != nil {
+		respondTransferError(c, err, "Failed to link transfer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"transfer": transfer})
+}
+
+func (h *TransferHandler) Unlink(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	transferID, _ := strconv.ParseUint(c.Param("transfer_id"), 10, 32)
+
+	if err := h.transferService.Unlink(uint(transferID), uint(workspaceID)); err != nil {
+		respondTransferError(c, err, "Failed to unlink transfer")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondTransferError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+	case errors.Is(err, services.ErrTransactionLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTransfer):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 
+	"etl-banks-ar/internal/models"
 	"etl-banks-ar/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -61,13 +63,19 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	preview.FileName = file.Filename
 
 	c.JSON(http.StatusOK, gin.H{"preview": preview})
 }
 
-// ConfirmRequest is the request body for confirming transactions
+// ConfirmRequest is the request body for confirming transactions. FileName marks an upload, which gets an
+// import batch; without it the rows are a manual confirm. AccountID is the account the statement belongs to;
+// StatementBalance is the total due printed on a card statement.
 type ConfirmRequest struct {
-	Transactions []services.ConfirmTransactionInput `json:"transactions" binding:"required"`
+	Transactions     []services.ConfirmTransactionInput `json:"transactions" binding:"required"`
+	FileName         string                             `json:"file_name"`
+	AccountID        *uint                              `json:"account_id"`
+	StatementBalance *float64                           `json:"statement_balance"`
 }
 
 // Confirm saves confirmed transactions to the database
@@ -84,11 +92,62 @@ func (h *UploadHandler) Confirm(c *gin.Context) {
 		return
 	}
 
-	count, err := h.uploadService.ConfirmTransactions(uint(workspaceID), req.Transactions)
+	batch := &models.ImportBatch{
+		CreatedBy:        c.MustGet("userID").(uint),
+		FileName:         req.FileName,
+		AccountID:        req.AccountID,
+		StatementBalance: req.StatementBalance,
+	}
+	count, err := h.uploadService.ConfirmTransactions(uint(workspaceID), batch, req.Transactions)
+	if errors.Is(err, services.ErrAccountNotFound) || errors.Is(err, services.ErrCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"created_count": count})
+	response := gin.H{"created_count": count}
+	if batch.ID != 0 {
+		response["import_batch_id"] = batch.ID
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListImportBatches returns the confirmed uploads of the workspace, usable as the import_batch_id filter.
+func (h *UploadHandler) ListImportBatches(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	batches, err := h.uploadService.ListImportBatches(uint(workspaceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch import batches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"import_batches": batches})
+}
+
+type UpdateImportBatchRequest struct {
+	StatementBalance *float64 `json:"statement_balance"`
+}
+
+// UpdateImportBatch sets the statement balance of an import, used to check card payments against it.
+func (h *UploadHandler) UpdateImportBatch(c *gin.Context) {
+	workspaceID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	batchID, _ := strconv.ParseUint(c.Param("batch_id"), 10, 32)
+
+	var req UpdateImportBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	batch, err := h.uploadService.SetStatementBalance(uint(batchID), uint(workspaceID), req.StatementBalance)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import batch not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"import_batch": batch})
 }
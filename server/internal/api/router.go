@@ -3,6 +3,7 @@ package api
 import (
 	"etl-banks-ar/internal/api/handlers"
 	"etl-banks-ar/internal/api/middleware"
+	"etl-banks-ar/internal/configs"
 	"etl-banks-ar/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -23,16 +24,35 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	areaService := services.NewAreaService(db)
 	recurringExpenseService := services.NewRecurringExpenseService(db)
 	exchangeRateService := services.NewExchangeRateService(db)
+	cpiService := services.NewCPIService(db, services.NewFileCPIProvider(configs.GetEnvOrDefault("CPI_SERIES_FILE", "data/ipc_indec.csv")))
+	categorizationFeedbackService := services.NewCategorizationFeedbackService(db)
+	categoryRuleService := services.NewCategoryRuleService(db)
+	recategorizationService := services.NewRecategorizationService(db, categoryService, categoryRuleService)
 
 	// Handlers
 	authHandler := handlers.NewAuthHandler(userService)
 	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService, categoryService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
-	uploadHandler := handlers.NewUploadHandler(services.NewUploadService(db, categoryService))
+	transactionHandler := handlers.NewTransactionHandler(transactionService, categoryService, cpiService)
+	uploadHandler := handlers.NewUploadHandler(services.NewUploadService(db, categoryService, categoryRuleService))
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
-	areaHandler := handlers.NewAreaHandler(areaService, categoryService)
-	recurringExpenseHandler := handlers.NewRecurringExpenseHandler(recurringExpenseService)
+	areaHandler := handlers.NewAreaHandler(areaService, categoryService, cpiService)
+	recurringExpenseHandler := handlers.NewRecurringExpenseHandler(recurringExpenseService, cpiService)
 	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateService)
+	cpiHandler := handlers.NewCPIHandler(cpiService)
+	categorizationHandler := handlers.NewCategorizationHandler(categorizationFeedbackService)
+	categoryRuleHandler := handlers.NewCategoryRuleHandler(categoryRuleService)
+	recategorizationHandler := handlers.NewRecategorizationHandler(recategorizationService)
+	tagHandler := handlers.NewTagHandler(services.NewTagService(db), cpiService)
+	savedViewHandler := handlers.NewSavedViewHandler(services.NewSavedViewService(db, transactionService))
+	accountService := services.NewAccountService(db)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	forecastHandler := handlers.NewForecastHandler(services.NewForecastService(db, accountService))
+	transferHandler := handlers.NewTransferHandler(services.NewTransferService(db))
+	cardPaymentHandler := handlers.NewCardPaymentHandler(services.NewCardPaymentService(db))
+	refundHandler := handlers.NewRefundHandler(services.NewRefundService(db))
+	budgetHandler := handlers.NewBudgetHandler(services.NewBudgetService(db, areaService))
+	settlementHandler := handlers.NewSettlementHandler(services.NewSettlementService(db), cpiService)
+	ownerHandler := handlers.NewOwnerHandler(services.NewOwnerService(db))
 
 	// API v1
 	v1 := router.Group("/api/v1")
@@ -72,14 +92,28 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 					workspace.POST("/transactions", transactionHandler.Create)
 					workspace.GET("/transactions/summary", transactionHandler.GetSummary)
 					workspace.GET("/transactions/yearly-summary", transactionHandler.GetYearlySummary)
+					workspace.GET("/transactions/compare", transactionHandler.Compare)
+					workspace.GET("/transactions/aggregate", transactionHandler.Aggregate)
 					workspace.POST("/transactions/upload", uploadHandler.Upload)
 					workspace.POST("/transactions/confirm", uploadHandler.Confirm)
+					workspace.POST("/transactions/bulk-update", transactionHandler.BulkUpdate)
+					workspace.POST("/transactions/bulk-delete", transactionHandler.BulkDelete)
 					workspace.GET("/transactions/:txn_id", transactionHandler.Get)
 					workspace.PUT("/transactions/:txn_id", transactionHandler.Update)
 					workspace.DELETE("/transactions/:txn_id", transactionHandler.Delete)
+					workspace.PUT("/transactions/:txn_id/tags", tagHandler.SetTransactionTags)
+					workspace.PUT("/transactions/:txn_id/allocations", transactionHandler.SetAllocations)
+					workspace.DELETE("/transactions/:txn_id/allocations", transactionHandler.DeleteAllocations)
+
+					workspace.GET("/import-batches", uploadHandler.ListImportBatches)
+					workspace.PUT("/import-batches/:batch_id", uploadHandler.UpdateImportBatch)
 
 					// Owners
-					workspace.GET("/owners", transactionHandler.GetOwners)
+					workspace.GET("/owners", ownerHandler.List)
+					workspace.POST("/owners", ownerHandler.Create)
+					workspace.PUT("/owners/:owner_id", ownerHandler.Update)
+					workspace.DELETE("/owners/:owner_id", ownerHandler.Delete)
+					workspace.POST("/owners/:owner_id/merge", ownerHandler.Merge)
 
 					// Categories CRUD
 					workspace.GET("/categories", categoryHandler.List)
@@ -87,6 +121,60 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 					workspace.GET("/categories/:cat_id", categoryHandler.Get)
 					workspace.PUT("/categories/:cat_id", categoryHandler.Update)
 					workspace.DELETE("/categories/:cat_id", categoryHandler.Delete)
+					workspace.POST("/categories/:cat_id/merge", categoryHandler.Merge)
+
+					// Accounts
+					workspace.GET("/accounts", accountHandler.List)
+					workspace.POST("/accounts", accountHandler.Create)
+					workspace.GET("/accounts/balances", accountHandler.Balances)
+					workspace.GET("/accounts/forecast", forecastHandler.CashFlow)
+					workspace.GET("/accounts/:account_id", accountHandler.Get)
+					workspace.PUT("/accounts/:account_id", accountHandler.Update)
+					workspace.DELETE("/accounts/:account_id", accountHandler.Delete)
+					workspace.GET("/accounts/:account_id/balance", accountHandler.Balance)
+
+					// Transfers between own accounts
+					workspace.GET("/transfers", transferHandler.List)
+					workspace.POST("/transfers", transferHandler.Link)
+					workspace.GET("/transfers/candidates", transferHandler.Candidates)
+					workspace.DELETE("/transfers/:transfer_id", transferHandler.Unlink)
+
+					// Card bill payments
+					workspace.GET("/card-payments", cardPaymentHandler.List)
+					workspace.POST("/card-payments", cardPaymentHandler.Create)
+					workspace.GET("/card-payments/candidates", cardPaymentHandler.Candidates)
+					workspace.DELETE("/card-payments/:payment_id", cardPaymentHandler.Delete)
+
+					// Refunds
+					workspace.GET("/refunds", refundHandler.List)
+					workspace.POST("/refunds", refundHandler.Link)
+					workspace.GET("/refunds/candidates", refundHandler.Candidates)
+					workspace.DELETE("/refunds/:refund_id", refundHandler.Unlink)
+
+					// Budgets
+					workspace.GET("/budgets", budgetHandler.List)
+					workspace.POST("/budgets", budgetHandler.Create)
+					workspace.POST("/budgets/apply-templates", budgetHandler.ApplyTemplates)
+					workspace.GET("/budgets/status", budgetHandler.Status)
+					workspace.PUT("/budgets/:budget_id", budgetHandler.Update)
+					workspace.DELETE("/budgets/:budget_id", budgetHandler.Delete)
+					workspace.GET("/budget-templates", budgetHandler.ListTemplates)
+					workspace.POST("/budget-templates", budgetHandler.CreateTemplate)
+					workspace.PUT("/budget-templates/:template_id", budgetHandler.UpdateTemplate)
+					workspace.DELETE("/budget-templates/:template_id", budgetHandler.DeleteTemplate)
+					workspace.GET("/budget-alerts", budgetHandler.ListAlerts)
+					workspace.POST("/budget-alerts/:alert_id/acknowledge", budgetHandler.AcknowledgeAlert)
+
+					// Owners and settlement
+					workspace.GET("/owners/breakdown", settlementHandler.OwnerBreakdown)
+					workspace.GET("/split-rules", settlementHandler.ListRules)
+					workspace.POST("/split-rules", settlementHandler.CreateRule)
+					workspace.PUT("/split-rules/:rule_id", settlementHandler.UpdateRule)
+					workspace.DELETE("/split-rules/:rule_id", settlementHandler.DeleteRule)
+					workspace.GET("/settlement", settlementHandler.Report)
+					workspace.GET("/settlement-payments", settlementHandler.ListPayments)
+					workspace.POST("/settlement-payments", settlementHandler.CreatePayment)
+					workspace.DELETE("/settlement-payments/:payment_id", settlementHandler.DeletePayment)
 
 					// Areas CRUD
 					workspace.GET("/areas", areaHandler.List)
@@ -112,6 +200,42 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 					workspace.GET("/exchange-rates/:month", exchangeRateHandler.Get)
 					workspace.PUT("/exchange-rates/:month", exchangeRateHandler.Upsert)
 					workspace.DELETE("/exchange-rates/:month", exchangeRateHandler.Delete)
+
+					// CPI series (summaries take ?real=true&base=YYYY-MM)
+					workspace.GET("/cpi", cpiHandler.List)
+					workspace.POST("/cpi/import", cpiHandler.Import)
+					workspace.POST("/cpi/sync", cpiHandler.Sync)
+					workspace.PUT("/cpi/:month", cpiHandler.Upsert)
+					workspace.DELETE("/cpi/:month", cpiHandler.Delete)
+
+					// Categorization quality
+					workspace.GET("/categorization/accuracy", categorizationHandler.GetAccuracy)
+
+					// Category rules
+					workspace.GET("/category-rules", categoryRuleHandler.List)
+					workspace.POST("/category-rules", categoryRuleHandler.Create)
+					workspace.PUT("/category-rules/:rule_id", categoryRuleHandler.Update)
+					workspace.DELETE("/category-rules/:rule_id", categoryRuleHandler.Delete)
+
+					// Saved views
+					workspace.GET("/saved-views", savedViewHandler.List)
+					workspace.POST("/saved-views", savedViewHandler.Create)
+					workspace.GET("/saved-views/:view_id", savedViewHandler.Get)
+					workspace.PUT("/saved-views/:view_id", savedViewHandler.Update)
+					workspace.DELETE("/saved-views/:view_id", savedViewHandler.Delete)
+					workspace.GET("/saved-views/:view_id/execute", savedViewHandler.Execute)
+
+					// Tags
+					workspace.GET("/tags", tagHandler.List)
+					workspace.POST("/tags", tagHandler.Create)
+					workspace.GET("/tags/summary", tagHandler.GetSummary)
+					workspace.PUT("/tags/:tag_id", tagHandler.Update)
+					workspace.DELETE("/tags/:tag_id", tagHandler.Delete)
+
+					// Bulk recategorization (preview, then apply)
+					workspace.POST("/recategorizations", recategorizationHandler.Create)
+					workspace.GET("/recategorizations/:job_id", recategorizationHandler.Get)
+					workspace.POST("/recategorizations/:job_id/apply", recategorizationHandler.Apply)
 				}
 			}
 		}
@@ -0,0 +1,136 @@
+package categorizer
+
+import (
+	"fmt"
+	"sync"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+// BatchOptions bounds how CategorizeInBatches splits and schedules prompts.
+type BatchOptions struct {
+	// BatchSize is the maximum number of transactions per prompt.
+	BatchSize int
+	// Concurrency is the maximum number of prompts in flight.
+	Concurrency int
+	// MaxRetries is how many extra prompts a batch may send for rows the model left out of its response.
+	MaxRetries int
+}
+
+func DefaultBatchOptions() BatchOptions {
+	return BatchOptions{BatchSize: 40, Concurrency: 4, MaxRetries: 2}
+}
+
+func (o BatchOptions) normalized() BatchOptions {
+	defaults := DefaultBatchOptions()
+	if o.BatchSize <= 0 {
+		o.BatchSize = defaults.BatchSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaults.Concurrency
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	}
+	return o
+}
+
+// CategorizeInBatches splits transactions into batches of at most opts.BatchSize rows and prompts up to
+// opts.Concurrency batches at once. When selector is set, each batch gets its own examples chosen from
+// examplePool; otherwise every batch receives the whole pool. Rows a response omits are re-sent up to
+// opts.MaxRetries times before falling back. Predictions are returned in input order.
+func CategorizeInBatches(client Prompter, transactions []models.Transaction, examplePool []trainingcsv.Example, selector ExampleSelector, allowedCategories []string, opts BatchOptions) ([]Prediction, error) {
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+	if len(allowedCategories) == 0 {
+		return nil, fmt.Errorf("allowed categories empty")
+	}
+	opts = opts.normalized()
+	fallback := pickFallbackCategory(allowedCategories)
+
+	results := make([]Prediction, len(transactions))
+	semaphore := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	for start := 0; start < len(transactions); start += opts.BatchSize {
+		end := start + opts.BatchSize
+		if end > len(transactions) {
+			end = len(transactions)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			mu.Lock()
+			failed := firstErr != nil
+			mu.Unlock()
+			if failed {
+				return
+			}
+
+			err := categorizeBatch(client, transactions, start, end, examplePool, selector, allowedCategories, fallback, opts.MaxRetries, results)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("batch %d-%d: %w", start, end-1, err)
+				}
+				mu.Unlock()
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// categorizeBatch fills results[start:end]. Each goroutine owns a disjoint range, so no locking is needed.
+func categorizeBatch(client Prompter, transactions []models.Transaction, start, end int, examplePool []trainingcsv.Example, selector ExampleSelector, allowedCategories []string, fallback string, maxRetries int, results []Prediction) error {
+	pending := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		pending = append(pending, i)
+	}
+
+	attempts := 0
+	for len(pending) > 0 && attempts <= maxRetries {
+		attempts++
+
+		batch := make([]models.Transaction, len(pending))
+		for j, idx := range pending {
+			batch[j] = transactions[idx]
+		}
+		examples := examplePool
+		if selector != nil {
+			examples = selector.Select(batch, examplePool)
+		}
+
+		predictions, err := promptWorkspaceBatch(client, batch, examples, allowedCategories, fallback)
+		if err != nil {
+			return err
+		}
+
+		missing := pending[:0:0]
+		for j, idx := range pending {
+			if predictions[j].Category == "" {
+				missing = append(missing, idx)
+				continue
+			}
+			results[idx] = predictions[j]
+		}
+		pending = missing
+	}
+
+	for _, idx := range pending {
+		results[idx] = fallbackPrediction(fallback, fmt.Sprintf("model returned no result for this row after %d attempts", attempts))
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package categorizer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+// flakyPrompter answers with the description as the category, omitting "FLAKY" rows the first time they are
+// seen and "NEVER" rows always. It also tracks the peak number of concurrent calls.
+type flakyPrompter struct {
+	mu       sync.Mutex
+	seen     map[string]bool
+	inFlight int
+	peak     int
+}
+
+func (f *flakyPrompter) PromptText(prompt string) (string, error) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.peak {
+		f.peak = f.inFlight
+	}
+	f.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	var payload offlinePayload
+	if err := json.Unmarshal([]byte(prompt[strings.LastIndex(prompt, "DATA:")+len("DATA:"):]), &payload); err != nil {
+		return "", err
+	}
+
+	response := categorizationResponse{}
+	f.mu.Lock()
+	for _, tx := range payload.Transactions {
+		switch {
+		case strings.HasPrefix(tx.Description, "NEVER"):
+			continue
+		case strings.HasPrefix(tx.Description, "FLAKY") && !f.seen[tx.Description]:
+			f.seen[tx.Description] = true
+			continue
+		}
+		response.Results = append(response.Results, result{Index: tx.Index, Category: tx.Description})
+	}
+	f.mu.Unlock()
+
+	raw, err := json.Marshal(response)
+	return string(raw), err
+}
+
+func TestCategorizeInBatchesRetriesAndKeepsOrder(t *testing.T) {
+	descriptions := []string{"A", "FLAKY1", "B", "C", "NEVER", "D", "FLAKY2"}
+	transactions := make([]models.Transaction, len(descriptions))
+	for i, d := range descriptions {
+		transactions[i] = models.Transaction{Description: sql.NullString{String: d, Valid: true}}
+	}
+	allowed := append([]string{models.MissingCategoryName}, descriptions...)
+
+	prompter := &flakyPrompter{seen: map[string]bool{}}
+	got, err := CategorizeInBatches(prompter, transactions, []trainingcsv.Example{}, nil, allowed, BatchOptions{BatchSize: 2, Concurrency: 2, MaxRetries: 1})
+	if err != nil {
+		t.Fatalf("categorize: %v", err)
+	}
+
+	for i, d := range descriptions {
+		if d == "NEVER" {
+			if !got[i].IsFallback() || got[i].Category != models.MissingCategoryName {
+				t.Fatalf("expected NEVER row to fall back, got %+v", got[i])
+			}
+			continue
+		}
+		if got[i].Category != d || got[i].Source != SourceLLM {
+			t.Fatalf("row %d: expected %q, got %+v", i, d, got[i])
+		}
+	}
+	if prompter.peak > 2 {
+		t.Fatalf("expected at most 2 concurrent prompts, saw %d", prompter.peak)
+	}
+}
@@ -0,0 +1,304 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+// Categorizer predicts one category per transaction, in input order. Examples are already-labeled rows the
+// backend may learn from; allowedCategories is the taxonomy predictions must come from.
+type Categorizer interface {
+	Name() string
+	Categorize(transactions []models.Transaction, examples []trainingcsv.Example, allowedCategories []string) ([]Prediction, error)
+}
+
+const (
+	ruleConfidence     = 0.95
+	localNeighbors     = 5
+	localTypeMismatch  = 0.8
+	localMinSimilarity = 0.2
+)
+
+// Rule assigns Category to every transaction whose description contains Pattern (case-insensitive).
+// Type optionally restricts the rule to "debit" or "credit" rows.
+type Rule struct {
+	Pattern  string `json:"pattern"`
+	Type     string `json:"type,omitempty"`
+	Category string `json:"category"`
+}
+
+// Matches reports whether the rule applies to a description/type pair.
+func (r Rule) Matches(description string, txType string) bool {
+	pattern := strings.ToLower(strings.TrimSpace(r.Pattern))
+	if pattern == "" {
+		return false
+	}
+	if r.Type != "" && !strings.EqualFold(r.Type, txType) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(description), pattern)
+}
+
+// LoadRules reads a JSON array of rules from path.
+func LoadRules(path string) ([]Rule, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// RuleCategorizer applies the first matching rule. Rows no rule matches fall back to Missing.
+type RuleCategorizer struct {
+	Rules []Rule
+}
+
+func (r RuleCategorizer) Name() string {
+	return string(SourceRule)
+}
+
+func (r RuleCategorizer) Categorize(transactions []models.Transaction, _ []trainingcsv.Example, allowedCategories []string) ([]Prediction, error) {
+	fallback := pickFallbackCategory(allowedCategories)
+	out := make([]Prediction, len(transactions))
+	for i, tx := range transactions {
+		out[i] = fallbackPrediction(fallback, "no rule matched")
+		for _, rule := range r.Rules {
+			if !contains(allowedCategories, rule.Category) {
+				continue
+			}
+			if rule.Matches(tx.Description.String, tx.Type.String) {
+				out[i] = Prediction{
+					Category:   rule.Category,
+					Confidence: ruleConfidence,
+					Reason:     fmt.Sprintf("matched rule %q", rule.Pattern),
+					Source:     SourceRule,
+				}
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// LocalCategorizer is an offline nearest-neighbour classifier over description tokens of the labeled examples.
+type LocalCategorizer struct{}
+
+func (LocalCategorizer) Name() string {
+	return string(SourceLocal)
+}
+
+func (LocalCategorizer) Categorize(transactions []models.Transaction, examples []trainingcsv.Example, allowedCategories []string) ([]Prediction, error) {
+	fallback := pickFallbackCategory(allowedCategories)
+	index := make([]tokenizedExample, 0, len(examples))
+	for _, ex := range examples {
+		if !contains(allowedCategories, ex.Category) {
+			continue
+		}
+		index = append(index, tokenizedExample{example: ex, tokens: tokenSet(ex.Description)})
+	}
+
+	out := make([]Prediction, len(transactions))
+	for i, tx := range transactions {
+		out[i] = classifyLocal(tokenSet(tx.Description.String), strings.ToLower(tx.Type.String), index, fallback)
+	}
+	return out, nil
+}
+
+type tokenizedExample struct {
+	example trainingcsv.Example
+	tokens  map[string]struct{}
+}
+
+type scoredExample struct {
+	example    trainingcsv.Example
+	similarity float64
+}
+
+func classifyLocal(tokens map[string]struct{}, txType string, index []tokenizedExample, fallback string) Prediction {
+	neighbors := make([]scoredExample, 0, localNeighbors+1)
+	for _, candidate := range index {
+		sim := tokenSimilarity(tokens, candidate.tokens)
+		if candidate.example.Type != "" && txType != "" && candidate.example.Type != txType {
+			sim *= localTypeMismatch
+		}
+		if sim < localMinSimilarity {
+			continue
+		}
+		neighbors = insertNeighbor(neighbors, scoredExample{example: candidate.example, similarity: sim}, localNeighbors)
+	}
+	if len(neighbors) == 0 {
+		return fallbackPrediction(fallback, "no similar labeled example")
+	}
+
+	votes := make(map[string]float64)
+	var total float64
+	for _, n := range neighbors {
+		votes[n.example.Category] += n.similarity
+		total += n.similarity
+	}
+	best := ""
+	for category, weight := range votes {
+		if best == "" || weight > votes[best] || (weight == votes[best] && category < best) {
+			best = category
+		}
+	}
+
+	var closest scoredExample
+	for _, n := range neighbors {
+		if n.example.Category == best {
+			closest = n
+			break
+		}
+	}
+
+	return Prediction{
+		Category:   best,
+		Confidence: clampConfidence(votes[best] / total * closest.similarity),
+		Reason:     fmt.Sprintf("similar to %q", closest.example.Description),
+		Source:     SourceLocal,
+	}
+}
+
+// insertNeighbor keeps neighbors sorted by descending similarity and capped at limit.
+func insertNeighbor(neighbors []scoredExample, candidate scoredExample, limit int) []scoredExample {
+	pos := len(neighbors)
+	for pos > 0 && neighbors[pos-1].similarity < candidate.similarity {
+		pos--
+	}
+	if pos >= limit {
+		return neighbors
+	}
+	neighbors = append(neighbors, scoredExample{})
+	copy(neighbors[pos+1:], neighbors[pos:])
+	neighbors[pos] = candidate
+	if len(neighbors) > limit {
+		neighbors = neighbors[:limit]
+	}
+	return neighbors
+}
+
+// LLMCategorizer sends rows to a language model through CategorizeWithWorkspaceExamples.
+// When Selector is set, only the examples it picks for each batch are included in the prompt.
+type LLMCategorizer struct {
+	Client   Prompter
+	Selector ExampleSelector
+	Batch    BatchOptions
+}
+
+func (l LLMCategorizer) Name() string {
+	if l.Selector != nil {
+		return string(SourceLLM) + "/" + l.Selector.Name()
+	}
+	return string(SourceLLM)
+}
+
+func (l LLMCategorizer) Categorize(transactions []models.Transaction, examples []trainingcsv.Example, allowedCategories []string) ([]Prediction, error) {
+	return CategorizeInBatches(l.Client, transactions, examples, l.Selector, allowedCategories, l.Batch)
+}
+
+// tokenSet splits a description into lowercase word tokens, dropping numbers and very short fragments
+// (card suffixes, store numbers) that carry no merchant signal.
+func tokenSet(description string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		if len([]rune(w)) < 3 || isNumeric(w) {
+			continue
+		}
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+func isNumeric(value string) bool {
+	for _, r := range value {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenSimilarity is the cosine similarity of two token sets (|A∩B| / sqrt(|A|·|B|)).
+func tokenSimilarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	small, large := a, b
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	shared := 0
+	for token := range small {
+		if _, ok := large[token]; ok {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / math.Sqrt(float64(len(a))*float64(len(b)))
+}
+
+// Chain runs backends in order. Each backend only sees the rows every earlier backend left as fallback,
+// so cheap deterministic backends (rules) can short-circuit expensive ones (LLM).
+type Chain []Categorizer
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, backend := range c {
+		names[i] = backend.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (c Chain) Categorize(transactions []models.Transaction, examples []trainingcsv.Example, allowedCategories []string) ([]Prediction, error) {
+	fallback := pickFallbackCategory(allowedCategories)
+	out := make([]Prediction, len(transactions))
+	pending := make([]int, len(transactions))
+	for i := range transactions {
+		out[i] = fallbackPrediction(fallback, "no categorizer produced a result")
+		pending[i] = i
+	}
+
+	for _, backend := range c {
+		if len(pending) == 0 {
+			break
+		}
+		batch := make([]models.Transaction, len(pending))
+		for j, idx := range pending {
+			batch[j] = transactions[idx]
+		}
+		predictions, err := backend.Categorize(batch, examples, allowedCategories)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", backend.Name(), err)
+		}
+
+		remaining := pending[:0:0]
+		for j, idx := range pending {
+			if j >= len(predictions) || predictions[j].IsFallback() {
+				if j < len(predictions) {
+					out[idx] = predictions[j]
+				}
+				remaining = append(remaining, idx)
+				continue
+			}
+			out[idx] = predictions[j]
+		}
+		pending = remaining
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package categorizer
+
+import (
+	"database/sql"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+func TestChainSendsOnlyUnmatchedRowsToLaterBackends(t *testing.T) {
+	txns := []models.Transaction{
+		{Description: sql.NullString{String: "SUBE CARGA", Valid: true}, Type: sql.NullString{String: "debit", Valid: true}},
+		{Description: sql.NullString{String: "SUPERMERCADO DIA 12", Valid: true}, Type: sql.NullString{String: "debit", Valid: true}},
+		{Description: sql.NullString{String: "ALGO RARO", Valid: true}, Type: sql.NullString{String: "debit", Valid: true}},
+	}
+	examples := []trainingcsv.Example{
+		{Description: "SUPERMERCADO DIA 99", Type: "debit", Category: "Comida"},
+	}
+	allowed := []string{"Comida", "Missing", "Transporte"}
+
+	chain := Chain{
+		RuleCategorizer{Rules: []Rule{{Pattern: "sube", Category: "Transporte"}, {Pattern: "dia", Category: "Viajes"}}},
+		LocalCategorizer{},
+	}
+	got, err := chain.Categorize(txns, examples, allowed)
+	if err != nil {
+		t.Fatalf("categorize: %v", err)
+	}
+
+	if got[0].Category != "Transporte" || got[0].Source != SourceRule {
+		t.Fatalf("expected rule match, got %+v", got[0])
+	}
+	// The "dia" rule points at a category outside the taxonomy, so the local backend decides.
+	if got[1].Category != "Comida" || got[1].Source != SourceLocal {
+		t.Fatalf("expected local match, got %+v", got[1])
+	}
+	if !got[2].IsFallback() || got[2].Category != "Missing" {
+		t.Fatalf("expected fallback, got %+v", got[2])
+	}
+	if chain.Name() != "rule+local" {
+		t.Fatalf("unexpected chain name %q", chain.Name())
+	}
+}
@@ -0,0 +1,212 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+const (
+	ExampleStrategyRecent  = "recent"
+	ExampleStrategySimilar = "similar"
+
+	DefaultExampleTokenBudget = 6000
+	defaultRecentExamples     = 120
+	similarPerTransaction     = 3
+	similarPerCategory        = 2
+)
+
+// ExampleSelector picks which labeled examples are sent with a batch of transactions.
+type ExampleSelector interface {
+	Name() string
+	Select(transactions []models.Transaction, pool []trainingcsv.Example) []trainingcsv.Example
+}
+
+// NewExampleSelector builds a selector by strategy name. tokenBudget <= 0 uses DefaultExampleTokenBudget.
+func NewExampleSelector(strategy string, tokenBudget int) (ExampleSelector, error) {
+	if tokenBudget <= 0 {
+		tokenBudget = DefaultExampleTokenBudget
+	}
+	switch strategy {
+	case ExampleStrategyRecent:
+		return RecentSelector{Limit: defaultRecentExamples, TokenBudget: tokenBudget}, nil
+	case ExampleStrategySimilar, "":
+		return SimilaritySelector{
+			PerTransaction: similarPerTransaction,
+			PerCategory:    similarPerCategory,
+			TokenBudget:    tokenBudget,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown example strategy %q", strategy)
+	}
+}
+
+// RecentSelector keeps the newest Limit examples, ignoring the transactions being categorized.
+type RecentSelector struct {
+	Limit       int
+	TokenBudget int
+}
+
+func (RecentSelector) Name() string {
+	return ExampleStrategyRecent
+}
+
+func (r RecentSelector) Select(_ []models.Transaction, pool []trainingcsv.Example) []trainingcsv.Example {
+	budget := newTokenBudget(r.TokenBudget)
+	selected := make([]trainingcsv.Example, 0, r.Limit)
+	for _, ex := range newestFirst(pool) {
+		if len(selected) >= r.Limit {
+			break
+		}
+		if !budget.take(ex) {
+			break
+		}
+		selected = append(selected, ex)
+	}
+	return selected
+}
+
+// SimilaritySelector picks, for every transaction, the PerTransaction most lexically similar examples, then
+// tops up each category to PerCategory examples (newest first) so rare categories are always represented.
+// Selection stops once the estimated prompt tokens reach TokenBudget; per-transaction matches are added first.
+type SimilaritySelector struct {
+	PerTransaction int
+	PerCategory    int
+	TokenBudget    int
+}
+
+func (SimilaritySelector) Name() string {
+	return ExampleStrategySimilar
+}
+
+func (s SimilaritySelector) Select(transactions []models.Transaction, pool []trainingcsv.Example) []trainingcsv.Example {
+	ordered := newestFirst(pool)
+	tokens := make([]map[string]struct{}, len(ordered))
+	for i, ex := range ordered {
+		tokens[i] = tokenSet(ex.Description)
+	}
+
+	budget := newTokenBudget(s.TokenBudget)
+	chosen := make(map[int]bool)
+	selected := make([]trainingcsv.Example, 0)
+	add := func(i int) bool {
+		if chosen[i] {
+			return true
+		}
+		if !budget.take(ordered[i]) {
+			return false
+		}
+		chosen[i] = true
+		selected = append(selected, ordered[i])
+		return true
+	}
+
+	// Round-robin over transactions so every row gets its best match before any row gets its second.
+	matches := make([][]int, len(transactions))
+	for t, tx := range transactions {
+		matches[t] = mostSimilar(tokenSet(tx.Description.String), tokens, s.PerTransaction)
+	}
+	for rank := 0; rank < s.PerTransaction; rank++ {
+		for t := range matches {
+			if rank < len(matches[t]) && !add(matches[t][rank]) {
+				return selected
+			}
+		}
+	}
+
+	perCategory := make(map[string]int)
+	for i := range chosen {
+		perCategory[ordered[i].Category]++
+	}
+	for i, ex := range ordered {
+		if perCategory[ex.Category] >= s.PerCategory || chosen[i] {
+			continue
+		}
+		if !add(i) {
+			return selected
+		}
+		perCategory[ex.Category]++
+	}
+
+	return selected
+}
+
+// mostSimilar returns the indexes of the limit candidates most similar to target, best first.
+func mostSimilar(target map[string]struct{}, candidates []map[string]struct{}, limit int) []int {
+	type scored struct {
+		index int
+		score float64
+	}
+	scores := make([]scored, 0)
+	for i, c := range candidates {
+		if sim := tokenSimilarity(target, c); sim > 0 {
+			scores = append(scores, scored{index: i, score: sim})
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+	if len(scores) > limit {
+		scores = scores[:limit]
+	}
+	out := make([]int, len(scores))
+	for i, s := range scores {
+		out[i] = s.index
+	}
+	return out
+}
+
+func newestFirst(pool []trainingcsv.Example) []trainingcsv.Example {
+	type datedExample struct {
+		date    time.Time // zero when unparseable, which sorts last
+		example trainingcsv.Example
+	}
+	dated := make([]datedExample, 0, len(pool))
+	for _, ex := range pool {
+		if ex.Category != "" {
+			date, _ := trainingcsv.ParseDate(ex.Date)
+			dated = append(dated, datedExample{date: date, example: ex})
+		}
+	}
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].date.After(dated[j].date)
+	})
+	ordered := make([]trainingcsv.Example, len(dated))
+	for i, d := range dated {
+		ordered[i] = d.example
+	}
+	return ordered
+}
+
+// tokenBudget approximates prompt size as one token per four bytes of the example's JSON encoding.
+type tokenBudget struct {
+	remaining int
+}
+
+func newTokenBudget(limit int) *tokenBudget {
+	if limit <= 0 {
+		limit = DefaultExampleTokenBudget
+	}
+	return &tokenBudget{remaining: limit}
+}
+
+func (b *tokenBudget) take(ex trainingcsv.Example) bool {
+	cost := estimateTokens(ex)
+	if cost > b.remaining {
+		return false
+	}
+	b.remaining -= cost
+	return true
+}
+
+func estimateTokens(ex trainingcsv.Example) int {
+	raw, err := json.Marshal(ex)
+	if err != nil {
+		return len(ex.Description)/4 + 1
+	}
+	return len(raw)/4 + 1
+}
@@ -0,0 +1,68 @@
+package categorizer
+
+import (
+	"database/sql"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+func TestSimilaritySelectorCoversRareCategories(t *testing.T) {
+	pool := []trainingcsv.Example{
+		{Date: "2026-03-05", Description: "SUPERMERCADO DIA", Category: "Comida"},
+		{Date: "2026-03-04", Description: "CARREFOUR", Category: "Comida"},
+		{Date: "2026-03-03", Description: "COTO", Category: "Comida"},
+		{Date: "2026-03-02", Description: "DISCO", Category: "Comida"},
+		{Date: "2025-06-01", Description: "VETERINARIA PATITAS", Category: "Mascotas"},
+	}
+	txns := []models.Transaction{{Description: sql.NullString{String: "SUPERMERCADO DIA 44", Valid: true}}}
+
+	selector := SimilaritySelector{PerTransaction: 1, PerCategory: 1, TokenBudget: 10000}
+	selected := selector.Select(txns, pool)
+
+	if len(selected) != 2 {
+		t.Fatalf("expected best match plus one coverage example, got %+v", selected)
+	}
+	if selected[0].Description != "SUPERMERCADO DIA" {
+		t.Fatalf("expected the most similar example first, got %q", selected[0].Description)
+	}
+	if selected[1].Category != "Mascotas" {
+		t.Fatalf("expected rare category to be covered, got %+v", selected[1])
+	}
+}
+
+func TestSelectorsRespectTokenBudget(t *testing.T) {
+	pool := make([]trainingcsv.Example, 50)
+	for i := range pool {
+		pool[i] = trainingcsv.Example{Date: "2026-01-01", Description: "MERCADO PAGO COMPRA", Category: "Compras"}
+	}
+	budget := estimateTokens(pool[0]) * 3
+
+	for _, selector := range []ExampleSelector{
+		RecentSelector{Limit: 100, TokenBudget: budget},
+		SimilaritySelector{PerTransaction: 10, PerCategory: 10, TokenBudget: budget},
+	} {
+		txns := []models.Transaction{{Description: sql.NullString{String: "MERCADO PAGO", Valid: true}}}
+		if got := len(selector.Select(txns, pool)); got != 3 {
+			t.Fatalf("%s: expected 3 examples within budget, got %d", selector.Name(), got)
+		}
+	}
+}
+
+func TestRecentSelectorParsesDayFirstDates(t *testing.T) {
+	pool := []trainingcsv.Example{
+		{Date: "28/02/2026", Description: "FARMACITY", Category: "Salud"},
+		{Date: "05/03/2026", Description: "SUPERMERCADO DIA", Category: "Comida"},
+		{Date: "not a date", Description: "KIOSCO", Category: "Comida"},
+		{Date: "2026-03-01", Description: "SUBE", Category: "Transporte"},
+	}
+
+	selected := RecentSelector{Limit: 4}.Select(nil, pool)
+	want := []string{"SUPERMERCADO DIA", "SUBE", "FARMACITY", "KIOSCO"}
+	for i, ex := range selected {
+		if ex.Description != want[i] {
+			t.Fatalf("order = %+v, want %v", selected, want)
+		}
+	}
+}
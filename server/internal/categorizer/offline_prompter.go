@@ -0,0 +1,67 @@
+package categorizer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+// OfflinePrompter answers categorization prompts without calling a model: it decodes the prompt's DATA block
+// and labels each transaction with the local nearest-neighbour classifier over the examples the prompt carries.
+// Because it only sees what was sent, it is a cheap way to compare prompt payloads and example selection.
+type OfflinePrompter struct{}
+
+type offlinePayload struct {
+	AllowedCategories []string                    `json:"allowed_categories"`
+	LabeledExamples   []trainingcsv.Example       `json:"labeled_examples"`
+	TrainingExamples  []trainingcsv.Example       `json:"training_examples"`
+	Transactions      []categorizationTransaction `json:"transactions"`
+}
+
+func (OfflinePrompter) PromptText(prompt string) (string, error) {
+	marker := strings.LastIndex(prompt, "DATA:")
+	if marker == -1 {
+		return "", fmt.Errorf("offline prompter: prompt has no DATA block")
+	}
+
+	var payload offlinePayload
+	if err := json.Unmarshal([]byte(strings.TrimSpace(prompt[marker+len("DATA:"):])), &payload); err != nil {
+		return "", fmt.Errorf("offline prompter: invalid DATA block: %w", err)
+	}
+
+	examples := append(payload.LabeledExamples, payload.TrainingExamples...)
+	transactions := make([]models.Transaction, len(payload.Transactions))
+	for i, tx := range payload.Transactions {
+		transactions[i] = models.Transaction{
+			Description: sql.NullString{String: tx.Description, Valid: true},
+			Amount:      sql.NullFloat64{Float64: tx.Amount, Valid: true},
+			Type:        sql.NullString{String: tx.Type, Valid: tx.Type != ""},
+		}
+	}
+
+	predictions, err := LocalCategorizer{}.Categorize(transactions, examples, payload.AllowedCategories)
+	if err != nil {
+		return "", err
+	}
+
+	response := categorizationResponse{Results: make([]result, 0, len(predictions))}
+	for i, p := range predictions {
+		confidence := p.Confidence
+		response.Results = append(response.Results, result{
+			Index:      payload.Transactions[i].Index,
+			Category:   p.Category,
+			Confidence: &confidence,
+			Reason:     p.Reason,
+		})
+	}
+
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
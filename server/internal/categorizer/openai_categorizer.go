@@ -10,6 +10,12 @@ import (
 	"strings"
 )
 
+// Prompter sends a text prompt to a language model. *openai.OpenAIClient satisfies it; OfflinePrompter is a
+// deterministic stand-in used for evaluation.
+type Prompter interface {
+	PromptText(prompt string) (string, error)
+}
+
 type workspacePayload struct {
 	AllowedCategories []string                    `json:"allowed_categories"`
 	LabeledExamples   []trainingcsv.Example       `json:"labeled_examples"`
@@ -35,9 +41,10 @@ type categorizationResponse struct {
 }
 
 type result struct {
-	Index    int    `json:"index"`
-	Category string `json:"category"`
-	Reason   string `json:"reason"`
+	Index      int      `json:"index"`
+	Category   string   `json:"category"`
+	Confidence *float64 `json:"confidence"`
+	Reason     string   `json:"reason"`
 }
 
 func CategorizeWithOpenAI(client *openAiService.OpenAIClient, transactions []models.Transaction, examples []trainingcsv.Example, examplesPerCategory int) ([]string, error) {
@@ -127,16 +134,15 @@ func pickFallbackCategory(allowedCategories []string) string {
 }
 
 // CategorizeWithWorkspaceExamples classifies transactions using categorized rows from the same workspace ("labeled_examples")
-// plus the workspace category taxonomy ("allowed_categories").
-func CategorizeWithWorkspaceExamples(client *openAiService.OpenAIClient, transactions []models.Transaction, labeledExamples []trainingcsv.Example, allowedCategories []string) ([]string, error) {
-	if len(transactions) == 0 {
-		return nil, nil
-	}
-	if len(allowedCategories) == 0 {
-		return nil, fmt.Errorf("allowed categories empty")
-	}
-	fallback := pickFallbackCategory(allowedCategories)
+// plus the workspace category taxonomy ("allowed_categories"). Rows are sent in batches of DefaultBatchOptions, each
+// with the same examples. It returns one Prediction per transaction, in input order.
+func CategorizeWithWorkspaceExamples(client Prompter, transactions []models.Transaction, labeledExamples []trainingcsv.Example, allowedCategories []string) ([]Prediction, error) {
+	return CategorizeInBatches(client, transactions, labeledExamples, nil, allowedCategories, DefaultBatchOptions())
+}
 
+// promptWorkspaceBatch sends a single categorization prompt. Rows the model skipped are returned zero-valued
+// (empty Category) so the caller can retry them.
+func promptWorkspaceBatch(client Prompter, transactions []models.Transaction, labeledExamples []trainingcsv.Example, allowedCategories []string, fallback string) ([]Prediction, error) {
 	payload := workspacePayload{
 		AllowedCategories: allowedCategories,
 		LabeledExamples:   labeledExamples,
@@ -160,12 +166,13 @@ func CategorizeWithWorkspaceExamples(client *openAiService.OpenAIClient, transac
 	prompt := fmt.Sprintf(`You classify bank transactions into one category for this user's workspace.
 
 Rules:
-1. field "labeled_examples" contains real transactions this workspace already categorized (most recent rows). Prefer the same category when wording, merchants, amounts, or types clearly match patterns you see there.
+1. field "labeled_examples" contains real transactions this workspace already categorized (rows similar to the ones below plus a few per category). Prefer the same category when wording, merchants, amounts, or types clearly match patterns you see there.
 2. Use only categories listed in "allowed_categories" (exact string match including spacing and casing).
 3. If unsure, prefer category "%s" when it fits "unknown / miscellaneous / needs review"-style buckets; otherwise pick the closest fit.
-4. Keep indexes unchanged.
-5. Return only valid JSON (no markdown, no extra text) with this format:
-{"results":[{"index":0,"category":"string","reason":"short reason"}]}
+4. Keep indexes unchanged and return exactly one result per transaction.
+5. "confidence" is your probability (0.0 to 1.0) that the chosen category is correct. Use low values when guessing.
+6. Return only valid JSON (no markdown, no extra text) with this format:
+{"results":[{"index":0,"category":"string","confidence":0.9,"reason":"short reason"}]}
 
 DATA:
 %s`, models.MissingCategoryName, string(payloadJSON))
@@ -175,25 +182,35 @@ DATA:
 		return nil, err
 	}
 
+	return parseWorkspaceResponse(raw, len(transactions), allowedCategories, fallback)
+}
+
+// parseWorkspaceResponse maps the model response onto one Prediction per transaction index.
+// Unknown categories become fallback predictions; indexes the model skipped are left zero for the caller to fill.
+func parseWorkspaceResponse(raw string, count int, allowedCategories []string, fallback string) ([]Prediction, error) {
 	var parsed categorizationResponse
-	if err = json.Unmarshal([]byte(cleanJSON(raw)), &parsed); err != nil {
+	if err := json.Unmarshal([]byte(cleanJSON(raw)), &parsed); err != nil {
 		return nil, fmt.Errorf("failed to parse categorization response: %w", err)
 	}
 
-	mapped := make([]string, len(transactions))
+	mapped := make([]Prediction, count)
 	for _, res := range parsed.Results {
-		if res.Index < 0 || res.Index >= len(transactions) {
+		if res.Index < 0 || res.Index >= count {
 			continue
 		}
 		if !contains(allowedCategories, res.Category) {
+			mapped[res.Index] = fallbackPrediction(fallback, fmt.Sprintf("model answered unknown category %q", res.Category))
 			continue
 		}
-		mapped[res.Index] = res.Category
-	}
-
-	for i := range mapped {
-		if mapped[i] == "" {
-			mapped[i] = fallback
+		confidence := defaultLLMConfidence
+		if res.Confidence != nil {
+			confidence = clampConfidence(*res.Confidence)
+		}
+		mapped[res.Index] = Prediction{
+			Category:   res.Category,
+			Confidence: confidence,
+			Reason:     strings.TrimSpace(res.Reason),
+			Source:     SourceLLM,
 		}
 	}
 
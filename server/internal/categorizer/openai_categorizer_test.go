@@ -0,0 +1,40 @@
+package categorizer
+
+import "testing"
+
+func TestParseWorkspaceResponse(t *testing.T) {
+	allowed := []string{"Comida", "Transporte", "Missing"}
+	raw := "```json\n" + `{"results":[
+		{"index":0,"category":"Comida","confidence":0.92,"reason":"supermarket"},
+		{"index":1,"category":"Viajes","confidence":0.8,"reason":"trip"},
+		{"index":3,"category":"Transporte","reason":"sube"},
+		{"index":7,"category":"Comida","confidence":1}
+	]}` + "\n```"
+
+	got, err := parseWorkspaceResponse(raw, 4, allowed, "Missing")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 predictions, got %d", len(got))
+	}
+
+	if got[0].Category != "Comida" || got[0].Source != SourceLLM || got[0].Confidence != 0.92 || got[0].Reason != "supermarket" {
+		t.Fatalf("unexpected prediction 0: %#v", got[0])
+	}
+	if !got[1].IsFallback() || got[1].Category != "Missing" || got[1].Confidence != 0 {
+		t.Fatalf("expected unknown category to fall back, got %#v", got[1])
+	}
+	if got[2] != (Prediction{}) {
+		t.Fatalf("expected skipped index to be left empty, got %#v", got[2])
+	}
+	if got[3].Source != SourceLLM || got[3].Confidence != defaultLLMConfidence {
+		t.Fatalf("expected default confidence when omitted, got %#v", got[3])
+	}
+}
+
+func TestParseWorkspaceResponse_invalidJSON(t *testing.T) {
+	if _, err := parseWorkspaceResponse("not json", 1, []string{"Missing"}, "Missing"); err == nil {
+		t.Fatal("expected error for invalid response")
+	}
+}
@@ -0,0 +1,58 @@
+package categorizer
+
+import openAiService "etl-banks-ar/internal/openai"
+
+// Source identifies which categorizer backend produced a prediction.
+type Source string
+
+const (
+	SourceRule     Source = "rule"
+	SourceLocal    Source = "local"
+	SourceLLM      Source = "llm"
+	SourceFallback Source = "fallback"
+)
+
+// WorkspacePromptVersion identifies the workspace categorization prompt. Bump it whenever the prompt
+// or example selection changes so recorded feedback can be compared across versions.
+const WorkspacePromptVersion = "workspace-v3"
+
+// ModelVersion returns the identifier stored with feedback for predictions made by CategorizeWithWorkspaceExamples
+// with examples picked by the given selection strategy.
+func ModelVersion(exampleStrategy string) string {
+	return string(openAiService.TextModel) + "/" + WorkspacePromptVersion + "/" + exampleStrategy
+}
+
+// defaultLLMConfidence is used when the model returns a valid category without a confidence value.
+const defaultLLMConfidence = 0.5
+
+// Prediction is the categorizer output for a single transaction.
+type Prediction struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"` // 0..1
+	Reason     string  `json:"reason"`
+	Source     Source  `json:"source"`
+}
+
+// IsFallback reports whether no backend produced a usable category for the row.
+func (p Prediction) IsFallback() bool {
+	return p.Source == SourceFallback
+}
+
+func fallbackPrediction(category string, reason string) Prediction {
+	return Prediction{
+		Category:   category,
+		Confidence: 0,
+		Reason:     reason,
+		Source:     SourceFallback,
+	}
+}
+
+func clampConfidence(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return value
+}
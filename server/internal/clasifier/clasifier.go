@@ -15,7 +15,7 @@ const K = 5
 
 func ClassifyTransactions(transactions []models.Transaction, classifiedTransactions []models.Transaction) ([]models.Transaction, error) {
 	fmt.Println("Classifying transactions...")
-	for _, transaction := range transactions {
+	for i, transaction := range transactions {
 		fmt.Println("Transaction: ", transaction.Description)
 		neighbors, err := findKNearest(transaction, classifiedTransactions, K)
 		if err != nil {
@@ -25,7 +25,9 @@ func ClassifyTransactions(transactions []models.Transaction, classifiedTransacti
 		fmt.Println("Category: ", category)
 		fmt.Println("Average similarity: ", strconv.FormatFloat(averageSimilarity, 'f', -1, 64))
 		fmt.Println("Success: ", success)
-		transaction.Category = sql.NullString{String: category, Valid: true}
+		if success {
+			transactions[i].Category = sql.NullString{String: category, Valid: true}
+		}
 	}
 
 	return transactions, nil
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"etl-banks-ar/internal/models"
 	openAiService "etl-banks-ar/internal/openai"
+	"etl-banks-ar/internal/services"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -29,12 +30,15 @@ func (c *TransactionController) CreateTransaction(transaction *models.Transactio
 		return err
 	}
 	transaction.EmbeddingJSON = string(embeddingJSON)
+	if err := services.SyncCategoryID(c.db, transaction); err != nil {
+		return err
+	}
 	return c.db.Create(transaction).Error
 }
 
 func (c *TransactionController) GetTransaction(id uint) (*models.Transaction, error) {
 	var transaction models.Transaction
-	if err := c.db.First(&transaction, id).Error; err != nil {
+	if err := c.db.Preload("CategoryRef").First(&transaction, id).Error; err != nil {
 		return nil, err
 	}
 
@@ -42,6 +46,9 @@ func (c *TransactionController) GetTransaction(id uint) (*models.Transaction, er
 }
 
 func (c *TransactionController) UpdateTransaction(transaction *models.Transaction) error {
+	if err := services.SyncCategoryID(c.db, transaction); err != nil {
+		return err
+	}
 	return c.db.Save(transaction).Error
 }
 
@@ -63,7 +70,8 @@ func (c *TransactionController) CreateEmbedding(transaction *models.Transaction)
 
 func (c *TransactionController) GetClassifiedTransactions() ([]models.Transaction, error) {
 	var transactions []models.Transaction
-	if err := c.db.Where("embedding_json IS NOT NULL AND embedding_json != ''").Find(&transactions).Error; err != nil {
+	// CategoryRef carries the label each neighbour votes with.
+	if err := c.db.Preload("CategoryRef").Where("embedding_json IS NOT NULL AND embedding_json != ''").Find(&transactions).Error; err != nil {
 		return nil, err
 	}
 	for _, transaction := range transactions {
@@ -42,6 +42,27 @@ func Migrate() {
 		&models.Transaction{},
 		&models.RecurringExpense{},
 		&models.ExchangeRate{},
+		&models.CategorizationFeedback{},
+		&models.CategoryRule{},
+		&models.RecategorizationJob{},
+		&models.RecategorizationChange{},
+		&models.Tag{},
+		&models.TransactionAllocation{},
+		&models.ImportBatch{},
+		&models.SavedView{},
+		&models.Account{},
+		&models.TransferLink{},
+		&models.CardPayment{},
+		&models.RefundLink{},
+		&models.Budget{},
+		&models.BudgetTemplate{},
+		&models.BudgetAlert{},
+		&models.CPIValue{},
+		&models.SplitRule{},
+		&models.SplitShare{},
+		&models.SettlementPayment{},
+		&models.Owner{},
+		&models.OwnerAlias{},
 	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to migrate database: %v", err))
@@ -51,4 +72,10 @@ func Migrate() {
 	if err := categoryService.BackfillMissingCategories(); err != nil {
 		panic(fmt.Sprintf("failed to seed Missing categories: %v", err))
 	}
+	if err := categoryService.BackfillTransactionCategoryIDs(); err != nil {
+		panic(fmt.Sprintf("failed to link transactions to categories: %v", err))
+	}
+	if err := services.NewOwnerService(db).BackfillOwners(); err != nil {
+		panic(fmt.Sprintf("failed to link transactions to owners: %v", err))
+	}
 }
@@ -0,0 +1,68 @@
+package evaluation
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"etl-banks-ar/internal/categorizer"
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+// Run categorizes every fold's test rows with backend, using the fold's train rows as examples,
+// and scores the predictions against the labeled categories.
+func Run(backend categorizer.Categorizer, folds []Fold, split string) (Report, error) {
+	scorer := NewScorer()
+	for i, fold := range folds {
+		// Only the train rows' labels are known up front; test labels must not leak into the taxonomy.
+		allowed := AllowedCategories(fold.Train)
+		predictions, err := backend.Categorize(ToTransactions(fold.Test), fold.Train, allowed)
+		if err != nil {
+			return Report{}, fmt.Errorf("fold %d: %w", i+1, err)
+		}
+		if len(predictions) != len(fold.Test) {
+			return Report{}, fmt.Errorf("fold %d: expected %d predictions, got %d", i+1, len(fold.Test), len(predictions))
+		}
+		for j, ex := range fold.Test {
+			scorer.Add(ex.Category, predictions[j].Category, predictions[j].IsFallback())
+		}
+	}
+	return scorer.Report(backend.Name(), split, len(folds)), nil
+}
+
+// AllowedCategories is the taxonomy seen across the given example sets plus the reserved Missing category,
+// mirroring how a workspace always has Missing available.
+func AllowedCategories(sets ...[]trainingcsv.Example) []string {
+	unique := map[string]struct{}{models.MissingCategoryName: {}}
+	for _, set := range sets {
+		for _, ex := range set {
+			if name := strings.TrimSpace(ex.Category); name != "" {
+				unique[name] = struct{}{}
+			}
+		}
+	}
+	out := make([]string, 0, len(unique))
+	for name := range unique {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ToTransactions converts labeled examples into unlabeled transactions for prediction.
+func ToTransactions(examples []trainingcsv.Example) []models.Transaction {
+	out := make([]models.Transaction, len(examples))
+	for i, ex := range examples {
+		date, _ := trainingcsv.ParseDate(ex.Date)
+		out[i] = models.Transaction{
+			Date:         date,
+			Description:  sql.NullString{String: ex.Description, Valid: ex.Description != ""},
+			Amount:       sql.NullFloat64{Float64: ex.Amount, Valid: true},
+			BalanceAfter: sql.NullFloat64{Float64: ex.BalanceAfter, Valid: true},
+			Type:         sql.NullString{String: ex.Type, Valid: ex.Type != ""},
+		}
+	}
+	return out
+}
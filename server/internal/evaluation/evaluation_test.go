@@ -0,0 +1,142 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"etl-banks-ar/internal/categorizer"
+	"etl-banks-ar/internal/models"
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+func TestScorerReport(t *testing.T) {
+	s := NewScorer()
+	s.Add("Comida", "Comida", false)
+	s.Add("Comida", "Missing", true)
+	s.Add("Transporte", "Transporte", false)
+	s.Add("Transporte", "Comida", false)
+
+	r := s.Report("local", "kfold", 2)
+	if r.Total != 4 || r.Correct != 2 || r.Fallbacks != 1 {
+		t.Fatalf("unexpected counts: %+v", r)
+	}
+	if r.Accuracy != 0.5 {
+		t.Fatalf("expected accuracy 0.5, got %v", r.Accuracy)
+	}
+
+	wantLabels := []string{"Comida", "Missing", "Transporte"}
+	for i, label := range wantLabels {
+		if r.Labels[i] != label {
+			t.Fatalf("expected labels %v, got %v", wantLabels, r.Labels)
+		}
+	}
+	if r.Confusion[0][0] != 1 || r.Confusion[0][1] != 1 || r.Confusion[2][0] != 1 || r.Confusion[2][2] != 1 {
+		t.Fatalf("unexpected confusion matrix: %v", r.Confusion)
+	}
+
+	// Comida: P=1/2 R=1/2 F1=0.5; Missing: F1=0; Transporte: P=1 R=1/2 F1=2/3.
+	wantMacro := (0.5 + 0 + 2.0/3.0) / 3
+	if math.Abs(r.MacroF1-wantMacro) > 1e-9 {
+		t.Fatalf("expected macro F1 %v, got %v", wantMacro, r.MacroF1)
+	}
+}
+
+func TestKFoldCoversEveryRowOnce(t *testing.T) {
+	examples := make([]trainingcsv.Example, 7)
+	for i := range examples {
+		examples[i] = trainingcsv.Example{Description: string(rune('a' + i)), Category: "x"}
+	}
+
+	folds, err := KFold(examples, 3, 42)
+	if err != nil {
+		t.Fatalf("kfold: %v", err)
+	}
+	seen := map[string]int{}
+	for _, f := range folds {
+		if len(f.Train)+len(f.Test) != len(examples) {
+			t.Fatalf("fold does not partition the data: %d + %d", len(f.Train), len(f.Test))
+		}
+		for _, ex := range f.Test {
+			seen[ex.Description]++
+		}
+	}
+	for _, ex := range examples {
+		if seen[ex.Description] != 1 {
+			t.Fatalf("row %q tested %d times", ex.Description, seen[ex.Description])
+		}
+	}
+}
+
+func TestTimeSplitTrainsOnOldestRows(t *testing.T) {
+	examples := []trainingcsv.Example{
+		{Date: "2026-03-01"}, {Date: "2026-01-01"}, {Date: "2026-04-01"}, {Date: "2026-02-01"},
+	}
+	folds, err := TimeSplit(examples, 0.5)
+	if err != nil {
+		t.Fatalf("time split: %v", err)
+	}
+	if folds[0].Train[1].Date != "2026-02-01" || folds[0].Test[0].Date != "2026-03-01" {
+		t.Fatalf("unexpected split: %+v", folds[0])
+	}
+}
+
+func TestTimeSplitOrdersByParsedDate(t *testing.T) {
+	// Day-first dates sort wrongly as strings: "01/03/2026" < "15/01/2026".
+	examples := []trainingcsv.Example{
+		{Date: "01/03/2026", Description: "march"},
+		{Date: "15/01/2026", Description: "january"},
+		{Date: "2026-04-10", Description: "april"},
+		{Date: "10/02/2026", Description: "february"},
+	}
+	folds, err := TimeSplit(examples, 0.5)
+	if err != nil {
+		t.Fatalf("time split: %v", err)
+	}
+	got := []string{}
+	for _, ex := range append(folds[0].Train, folds[0].Test...) {
+		got = append(got, ex.Description)
+	}
+	want := []string{"january", "february", "march", "april"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTimeSplitRejectsUnparseableDates(t *testing.T) {
+	examples := []trainingcsv.Example{{Date: "2026-01-01"}, {Date: "soon"}}
+	if _, err := TimeSplit(examples, 0.5); err == nil {
+		t.Fatal("expected an error for an unparseable date")
+	}
+}
+
+type recordingCategorizer struct {
+	allowed []string
+}
+
+func (r *recordingCategorizer) Name() string { return "recording" }
+
+func (r *recordingCategorizer) Categorize(transactions []models.Transaction, _ []trainingcsv.Example, allowed []string) ([]categorizer.Prediction, error) {
+	r.allowed = allowed
+	return make([]categorizer.Prediction, len(transactions)), nil
+}
+
+func TestRunAllowsOnlyTrainCategories(t *testing.T) {
+	backend := &recordingCategorizer{}
+	folds := []Fold{{
+		Train: []trainingcsv.Example{{Category: "Comida"}, {Category: "Transporte"}},
+		Test:  []trainingcsv.Example{{Category: "Viajes"}},
+	}}
+	if _, err := Run(backend, folds, "time"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, name := range backend.allowed {
+		if name == "Viajes" {
+			t.Fatalf("test-only label leaked into allowed categories: %v", backend.allowed)
+		}
+	}
+	if len(backend.allowed) != 3 {
+		t.Fatalf("allowed = %v, want Comida, Missing, Transporte", backend.allowed)
+	}
+}
@@ -0,0 +1,159 @@
+package evaluation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ClassMetrics are precision/recall/F1 for a single category.
+type ClassMetrics struct {
+	Category  string  `json:"category"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"` // number of gold rows with this category
+}
+
+// Report summarizes one evaluation run across all folds.
+type Report struct {
+	Backend   string         `json:"backend"`
+	Split     string         `json:"split"`
+	Folds     int            `json:"folds"`
+	Total     int            `json:"total"`
+	Correct   int            `json:"correct"`
+	Fallbacks int            `json:"fallbacks"`
+	Accuracy  float64        `json:"accuracy"`
+	MacroF1   float64        `json:"macro_f1"`
+	Classes   []ClassMetrics `json:"classes"`
+	// Labels orders the rows (gold) and columns (predicted) of Confusion.
+	Labels    []string `json:"labels"`
+	Confusion [][]int  `json:"confusion"`
+}
+
+// Scorer accumulates gold/predicted pairs.
+type Scorer struct {
+	pairs     map[[2]string]int
+	total     int
+	correct   int
+	fallbacks int
+}
+
+func NewScorer() *Scorer {
+	return &Scorer{pairs: make(map[[2]string]int)}
+}
+
+// Add records one prediction. fallback marks rows no backend could label.
+func (s *Scorer) Add(gold, predicted string, fallback bool) {
+	s.pairs[[2]string{gold, predicted}]++
+	s.total++
+	if gold == predicted {
+		s.correct++
+	}
+	if fallback {
+		s.fallbacks++
+	}
+}
+
+// Report computes accuracy, per-class metrics, macro-F1 and the confusion matrix.
+func (s *Scorer) Report(backend, split string, folds int) Report {
+	labelSet := make(map[string]struct{})
+	for pair := range s.pairs {
+		labelSet[pair[0]] = struct{}{}
+		labelSet[pair[1]] = struct{}{}
+	}
+	labels := make([]string, 0, len(labelSet))
+	for label := range labelSet {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	position := make(map[string]int, len(labels))
+	for i, label := range labels {
+		position[label] = i
+	}
+	confusion := make([][]int, len(labels))
+	for i := range confusion {
+		confusion[i] = make([]int, len(labels))
+	}
+	for pair, count := range s.pairs {
+		confusion[position[pair[0]]][position[pair[1]]] += count
+	}
+
+	classes := make([]ClassMetrics, 0, len(labels))
+	var f1Sum float64
+	for i, label := range labels {
+		tp := confusion[i][i]
+		var goldCount, predictedCount int
+		for j := range labels {
+			goldCount += confusion[i][j]
+			predictedCount += confusion[j][i]
+		}
+		m := ClassMetrics{Category: label, Support: goldCount}
+		if predictedCount > 0 {
+			m.Precision = float64(tp) / float64(predictedCount)
+		}
+		if goldCount > 0 {
+			m.Recall = float64(tp) / float64(goldCount)
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		f1Sum += m.F1
+		classes = append(classes, m)
+	}
+
+	report := Report{
+		Backend:   backend,
+		Split:     split,
+		Folds:     folds,
+		Total:     s.total,
+		Correct:   s.correct,
+		Fallbacks: s.fallbacks,
+		Classes:   classes,
+		Labels:    labels,
+		Confusion: confusion,
+	}
+	if s.total > 0 {
+		report.Accuracy = float64(s.correct) / float64(s.total)
+	}
+	if len(labels) > 0 {
+		report.MacroF1 = f1Sum / float64(len(labels))
+	}
+	return report
+}
+
+// WriteText prints the report as a human-readable table.
+func (r Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "backend=%s split=%s folds=%d\n", r.Backend, r.Split, r.Folds)
+	fmt.Fprintf(w, "rows=%d correct=%d fallbacks=%d\n", r.Total, r.Correct, r.Fallbacks)
+	fmt.Fprintf(w, "accuracy=%.4f macro_f1=%.4f\n\n", r.Accuracy, r.MacroF1)
+
+	width := len("category")
+	for _, label := range r.Labels {
+		if len(label) > width {
+			width = len(label)
+		}
+	}
+
+	fmt.Fprintf(w, "%-*s  %9s  %6s  %6s  %7s\n", width, "category", "precision", "recall", "f1", "support")
+	for _, c := range r.Classes {
+		fmt.Fprintf(w, "%-*s  %9.3f  %6.3f  %6.3f  %7d\n", width, c.Category, c.Precision, c.Recall, c.F1, c.Support)
+	}
+
+	fmt.Fprintf(w, "\nconfusion matrix (rows = gold, columns = predicted)\n")
+	fmt.Fprintf(w, "%-*s", width, "")
+	for i := range r.Labels {
+		fmt.Fprintf(w, " %5d", i)
+	}
+	fmt.Fprintln(w)
+	for i, row := range r.Confusion {
+		fmt.Fprintf(w, "%-*s", width, r.Labels[i])
+		for _, count := range row {
+			fmt.Fprintf(w, " %5d", count)
+		}
+		fmt.Fprintf(w, "  [%d]\n", i)
+	}
+	fmt.Fprintln(w, strings.Repeat("-", width+6*len(r.Labels)))
+}
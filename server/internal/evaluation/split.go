@@ -0,0 +1,75 @@
+package evaluation
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"etl-banks-ar/internal/trainingcsv"
+)
+
+// Fold is one train/test partition of the labeled data.
+type Fold struct {
+	Train []trainingcsv.Example
+	Test  []trainingcsv.Example
+}
+
+// KFold shuffles examples with seed and partitions them into k folds; each fold is the test set once.
+func KFold(examples []trainingcsv.Example, k int, seed int64) ([]Fold, error) {
+	if k < 2 {
+		return nil, fmt.Errorf("k must be at least 2, got %d", k)
+	}
+	if len(examples) < k {
+		return nil, fmt.Errorf("need at least %d examples for %d folds, got %d", k, k, len(examples))
+	}
+
+	order := rand.New(rand.NewSource(seed)).Perm(len(examples))
+	folds := make([]Fold, k)
+	for pos, idx := range order {
+		target := pos % k
+		for f := range folds {
+			if f == target {
+				folds[f].Test = append(folds[f].Test, examples[idx])
+			} else {
+				folds[f].Train = append(folds[f].Train, examples[idx])
+			}
+		}
+	}
+	return folds, nil
+}
+
+// TimeSplit orders examples by date and trains on the oldest trainFraction, testing on the rest.
+// This mirrors production, where only past rows are available as examples.
+func TimeSplit(examples []trainingcsv.Example, trainFraction float64) ([]Fold, error) {
+	if trainFraction <= 0 || trainFraction >= 1 {
+		return nil, fmt.Errorf("train fraction must be between 0 and 1, got %v", trainFraction)
+	}
+
+	type datedExample struct {
+		date    time.Time
+		example trainingcsv.Example
+	}
+	dated := make([]datedExample, len(examples))
+	for i, ex := range examples {
+		date, err := trainingcsv.ParseDate(ex.Date)
+		if err != nil {
+			return nil, fmt.Errorf("example %d (%q): %w", i+1, ex.Description, err)
+		}
+		dated[i] = datedExample{date: date, example: ex}
+	}
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].date.Before(dated[j].date)
+	})
+
+	sorted := make([]trainingcsv.Example, len(dated))
+	for i, d := range dated {
+		sorted[i] = d.example
+	}
+
+	cut := int(float64(len(sorted)) * trainFraction)
+	if cut == 0 || cut == len(sorted) {
+		return nil, fmt.Errorf("not enough examples (%d) for a %.2f time split", len(sorted), trainFraction)
+	}
+	return []Fold{{Train: sorted[:cut], Test: sorted[cut:]}}, nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Account types.
+const (
+	AccountTypeBank   = "bank"
+	AccountTypeCard   = "card"
+	AccountTypeWallet = "wallet"
+	AccountTypeCash   = "cash"
+)
+
+// Account is where money sits or is owed: a bank account, a credit card, a wallet such as Mercado Pago, or
+// cash. Its balance is the opening balance plus the credits and minus the debits of its transactions dated
+// on or after OpeningDate (all of them when OpeningDate is nil). A card's balance is negative while owed.
+type Account struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	WorkspaceID    uint       `gorm:"not null;index" json:"workspace_id"`
+	Name           string     `gorm:"size:255;not null" json:"name"`
+	Type           string     `gorm:"size:20;not null" json:"type"` // bank | card | wallet | cash
+	Currency       string     `gorm:"size:3;not null;default:'ARS'" json:"currency"`
+	Institution    string     `gorm:"size:255" json:"institution"`
+	OwnerUserID    *uint      `gorm:"index" json:"owner_user_id"` // member holding the account; nil if joint
+	OpeningBalance float64    `json:"opening_balance"`
+	OpeningDate    *time.Time `json:"opening_date"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
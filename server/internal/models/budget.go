@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Budget is the planned spending of one month for either a category (including its subcategories) or an
+// area; exactly one of CategoryID and AreaID is set. With Rollover, what is left unspent (or overspent)
+// at the end of the month carries into the next month's budget for the same target. Target keys that
+// target so a month holds at most one budget for it.
+type Budget struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index:idx_budget_ws_month;uniqueIndex:idx_budget_ws_month_target" json:"workspace_id"`
+	Month       string    `gorm:"size:7;not null;index:idx_budget_ws_month;uniqueIndex:idx_budget_ws_month_target" json:"month"` // YYYY-MM
+	CategoryID  *uint     `gorm:"index" json:"category_id"`
+	AreaID      *uint     `gorm:"index" json:"area_id"`
+	Target      string    `gorm:"size:32;not null;default:'';uniqueIndex:idx_budget_ws_month_target" json:"-"` // "category:<id>" or "area:<id>"
+	Amount      float64   `gorm:"not null" json:"amount"`
+	Rollover    bool      `json:"rollover"`
+	TemplateID  *uint     `gorm:"index" json:"template_id"` // template the budget was created from
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BudgetTemplate is a recurring budget. Each month from StartMonth through EndMonth (open-ended when
+// empty) gets a Budget from the template, unless one already exists for the same target.
+type BudgetTemplate struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index" json:"workspace_id"`
+	CategoryID  *uint     `gorm:"index" json:"category_id"`
+	AreaID      *uint     `gorm:"index" json:"area_id"`
+	Amount      float64   `gorm:"not null" json:"amount"`
+	Rollover    bool      `json:"rollover"`
+	StartMonth  string    `gorm:"size:7;not null" json:"start_month"`
+	EndMonth    string    `gorm:"size:7" json:"end_month"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BudgetAlert records that spending crossed a threshold (percent of the available budget). Each threshold
+// fires once per budget.
+type BudgetAlert struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID  uint      `gorm:"not null;index" json:"workspace_id"`
+	BudgetID     uint      `gorm:"not null;uniqueIndex:idx_budget_threshold" json:"budget_id"`
+	Month        string    `gorm:"size:7;not null;index" json:"month"`
+	Threshold    int       `gorm:"not null;uniqueIndex:idx_budget_threshold" json:"threshold"`
+	Spent        float64   `json:"spent"`
+	Available    float64   `json:"available"`
+	Acknowledged bool      `json:"acknowledged"`
+	CreatedAt    time.Time `json:"created_at"`
+}
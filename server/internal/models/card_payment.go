@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// CardPayment marks a bank debit as the settlement of a credit card bill. The card purchases it pays for
+// are already imported from the card statement, so the payment is not an expense and summaries leave it
+// out. CardTransactionID optionally points at the matching "payment received" credit on the card side,
+// which is left out too. ImportBatchID is the card statement being paid.
+type CardPayment struct {
+	ID                uint         `gorm:"primaryKey" json:"id"`
+	WorkspaceID       uint         `gorm:"not null;index" json:"workspace_id"`
+	TransactionID     uint         `gorm:"not null;uniqueIndex" json:"transaction_id"`
+	Transaction       *Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	CardAccountID     uint         `gorm:"not null;index" json:"card_account_id"`
+	CardTransactionID *uint        `gorm:"uniqueIndex" json:"card_transaction_id"`
+	ImportBatchID     *uint        `gorm:"index" json:"import_batch_id"`
+	CreatedBy         uint         `json:"created_by"`
+	CreatedAt         time.Time    `json:"created_at"`
+}
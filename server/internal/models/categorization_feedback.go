@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// CategorizationFeedback records what the categorizer predicted for a confirmed row versus the label the user kept.
+type CategorizationFeedback struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID       uint      `gorm:"not null;index" json:"workspace_id"`
+	TransactionID     uint      `gorm:"not null;index" json:"transaction_id"`
+	PredictedCategory string    `gorm:"size:255;not null" json:"predicted_category"`
+	FinalCategory     string    `gorm:"size:255;not null" json:"final_category"`
+	Source            string    `gorm:"size:50" json:"source"` // rule | local | llm | fallback
+	Confidence        float64   `json:"confidence"`
+	ModelVersion      string    `gorm:"size:100;index" json:"model_version"`
+	Correct           bool      `gorm:"not null" json:"correct"`
+	CreatedAt         time.Time `json:"created_at"`
+}
@@ -9,6 +9,7 @@ type Category struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	WorkspaceID uint      `gorm:"uniqueIndex:idx_ws_cat_name;not null" json:"workspace_id"`
 	Name        string    `gorm:"size:255;uniqueIndex:idx_ws_cat_name;not null" json:"name"`
+	ParentID    *uint     `gorm:"index" json:"parent_id"` // optional; subcategories roll up into their parent
 	AreaID      *uint     `json:"area_id"`
 	Area        *Area     `gorm:"foreignKey:AreaID" json:"area,omitempty"`
 	Color       string    `gorm:"size:50" json:"color"`
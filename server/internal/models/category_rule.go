@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CategoryRule assigns Category to transactions whose description contains Pattern (case-insensitive).
+// Rules are evaluated by ascending Priority, then ID; the first match wins.
+type CategoryRule struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index" json:"workspace_id"`
+	Pattern     string    `gorm:"size:255;not null" json:"pattern"`
+	Type        string    `gorm:"size:10" json:"type"` // optional: debit | credit
+	CategoryID  uint      `gorm:"not null;index" json:"category_id"`
+	CategoryRef *Category `gorm:"foreignKey:CategoryID" json:"-"`
+	Category    string    `gorm:"-" json:"category"` // name of CategoryRef, filled on read
+	Priority    int       `gorm:"not null;default:0" json:"priority"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (r *CategoryRule) AfterFind(tx *gorm.DB) error {
+	if r.CategoryRef != nil {
+		r.Category = r.CategoryRef.Name
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CPIValue is one month of a consumer price index series such as INDEC's IPC. Only the ratio between two
+// months matters, so any index base works as long as the whole series uses the same one.
+type CPIValue struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"uniqueIndex:idx_cpi_ws_month" json:"workspace_id"`
+	Month       string    `gorm:"size:7;uniqueIndex:idx_cpi_ws_month" json:"month"` // YYYY-MM format
+	Value       float64   `json:"value"`
+	Source      string    `gorm:"size:50" json:"source"` // csv, manual or the provider name
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ImportBatch groups the transactions saved by one confirmed statement upload. For card statements,
+// StatementBalance is the total due printed on the statement; card payments are checked against it.
+type ImportBatch struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID      uint      `gorm:"not null;index" json:"workspace_id"`
+	CreatedBy        uint      `json:"created_by"`
+	FileName         string    `gorm:"size:255" json:"file_name"`
+	AccountID        *uint     `gorm:"index" json:"account_id"`
+	StatementBalance *float64  `json:"statement_balance"`
+	RowCount         int       `json:"row_count"`
+	CreatedAt        time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Owner is a person transactions are attributed to. An owner linked to a workspace member through MemberID
+// is that member; one without is an external person, such as a relative who shares expenses but has no
+// account. Owner names are still stored on transactions, allocations, recurring expenses and settlements as
+// the owner's display name; renames and merges rewrite them.
+type Owner struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint             `gorm:"not null;uniqueIndex:idx_owner_ws_name" json:"workspace_id"`
+	Name        string           `gorm:"size:255;not null;uniqueIndex:idx_owner_ws_name" json:"name"`
+	MemberID    *uint            `gorm:"index" json:"member_id"` // WorkspaceMember.ID; nil for an external person
+	Member      *WorkspaceMember `gorm:"foreignKey:MemberID" json:"-"`
+	Aliases     []OwnerAlias     `gorm:"foreignKey:OwnerID" json:"aliases"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// OwnerAlias is another spelling that resolves to an owner, such as the name of an owner merged into it.
+type OwnerAlias struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;uniqueIndex:idx_owner_alias_ws_name" json:"workspace_id"`
+	OwnerID     uint      `gorm:"not null;index" json:"owner_id"`
+	Name        string    `gorm:"size:255;not null;uniqueIndex:idx_owner_alias_ws_name" json:"name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	RecategorizationStatusPreview = "preview"
+	RecategorizationStatusApplied = "applied"
+)
+
+// RecategorizationJob is a reviewable batch of proposed category changes for historical transactions.
+type RecategorizationJob struct {
+	ID               uint                     `gorm:"primaryKey" json:"id"`
+	WorkspaceID      uint                     `gorm:"not null;index" json:"workspace_id"`
+	CreatedBy        uint                     `json:"created_by"`
+	Strategy         string                   `gorm:"size:20;not null" json:"strategy"` // rules | local | llm
+	FilterJSON       string                   `gorm:"type:text" json:"-"`
+	IncludeConfirmed bool                     `json:"include_confirmed"`
+	Status           string                   `gorm:"size:20;not null;default:'preview'" json:"status"`
+	MatchedCount     int                      `json:"matched_count"`
+	ChangeCount      int                      `json:"change_count"`
+	AppliedCount     int                      `json:"applied_count"`
+	AppliedAt        *time.Time               `json:"applied_at,omitempty"`
+	Changes          []RecategorizationChange `gorm:"foreignKey:JobID" json:"changes,omitempty"`
+	CreatedAt        time.Time                `json:"created_at"`
+	UpdatedAt        time.Time                `json:"updated_at"`
+}
+
+// RecategorizationChange is one proposed category change within a job.
+type RecategorizationChange struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	JobID         uint    `gorm:"not null;index" json:"job_id"`
+	TransactionID uint    `gorm:"not null;index" json:"transaction_id"`
+	Date          string  `gorm:"size:10" json:"date"`
+	Description   string  `gorm:"size:512" json:"description"`
+	Amount        float64 `json:"amount"`
+	OldCategoryID *uint   `json:"old_category_id"`
+	OldCategory   string  `gorm:"size:255" json:"old_category"`
+	NewCategoryID *uint   `json:"new_category_id"`
+	NewCategory   string  `gorm:"size:255;not null" json:"new_category"`
+	Confidence    float64 `json:"confidence"`
+	Source        string  `gorm:"size:20" json:"source"`
+	Reason        string  `gorm:"size:512" json:"reason"`
+	Applied       bool    `json:"applied"`
+	Skipped       bool    `json:"skipped"` // the transaction was re-categorized after the preview
+}
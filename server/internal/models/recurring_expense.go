@@ -11,6 +11,7 @@ type RecurringExpense struct {
 	Category     *Category  `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
 	Owner        string     `gorm:"size:255" json:"owner"`
 	DueDay       int        `gorm:"not null" json:"due_day"`
+	AccountID    *uint      `gorm:"index" json:"account_id"` // account it is paid from, for cash flow forecasts
 	LastPaidDate *time.Time `json:"last_paid_date"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
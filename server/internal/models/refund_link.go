@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RefundLink ties a refund or chargeback credit to the purchase it reverses. Summaries count a linked
+// refund as negative spending in the purchase's category, area and owner rather than as income. A purchase
+// may have several partial refunds; a credit refunds at most one purchase.
+type RefundLink struct {
+	ID                    uint         `gorm:"primaryKey" json:"id"`
+	WorkspaceID           uint         `gorm:"not null;index" json:"workspace_id"`
+	RefundTransactionID   uint         `gorm:"not null;uniqueIndex" json:"refund_transaction_id"`
+	RefundTransaction     *Transaction `gorm:"foreignKey:RefundTransactionID" json:"refund_transaction,omitempty"`
+	OriginalTransactionID uint         `gorm:"not null;index" json:"original_transaction_id"`
+	OriginalTransaction   *Transaction `gorm:"foreignKey:OriginalTransactionID" json:"original_transaction,omitempty"`
+	CreatedBy             uint         `json:"created_by"`
+	CreatedAt             time.Time    `json:"created_at"`
+}
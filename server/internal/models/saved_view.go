@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SavedView is a named transaction search. Views with a UserID are private to that member; views without
+// one are shared with the whole workspace.
+type SavedView struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index" json:"workspace_id"`
+	UserID      *uint     `gorm:"index" json:"user_id"`
+	CreatedBy   uint      `json:"created_by"`
+	Name        string    `gorm:"size:255;not null" json:"name"`
+	FilterJSON  string    `gorm:"type:text" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
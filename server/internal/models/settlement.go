@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// SplitRule says how the workspace's owners share spending in a category and its subcategories. A rule
+// without CategoryID is the workspace default for categories without their own rule. Spending that no rule
+// covers is personal and never settled.
+type SplitRule struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint         `gorm:"not null;index" json:"workspace_id"`
+	CategoryID  *uint        `gorm:"index" json:"category_id"`
+	Shares      []SplitShare `gorm:"foreignKey:SplitRuleID" json:"shares"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// SplitShare is one owner's percentage of a split rule; the shares of a rule add up to 100.
+type SplitShare struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	SplitRuleID uint    `gorm:"not null;index" json:"split_rule_id"`
+	Owner       string  `gorm:"size:255;not null" json:"owner"`
+	Percent     float64 `gorm:"not null" json:"percent"`
+}
+
+// SettlementPayment records FromOwner paying ToOwner back for shared spending.
+type SettlementPayment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index" json:"workspace_id"`
+	FromOwner   string    `gorm:"size:255;not null" json:"from_owner"`
+	ToOwner     string    `gorm:"size:255;not null" json:"to_owner"`
+	Amount      float64   `gorm:"not null" json:"amount"`
+	Date        time.Time `gorm:"not null;index" json:"date"`
+	Note        string    `gorm:"size:255" json:"note"`
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Tag is a free-form, workspace-scoped label. Unlike categories, a transaction can carry any number of tags.
+type Tag struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"uniqueIndex:idx_ws_tag_name;not null" json:"workspace_id"`
+	Name        string    `gorm:"size:100;uniqueIndex:idx_ws_tag_name;not null" json:"name"`
+	Color       string    `gorm:"size:50" json:"color"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
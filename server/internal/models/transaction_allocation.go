@@ -0,0 +1,32 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TransactionAllocation is one part of a split transaction. When a transaction has allocations, summaries
+// count the allocations instead of the parent row; their amounts always add up to the parent amount.
+type TransactionAllocation struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	TransactionID uint           `gorm:"not null;index" json:"transaction_id"`
+	WorkspaceID   uint           `gorm:"not null;index" json:"workspace_id"`
+	Amount        float64        `gorm:"not null" json:"amount"`
+	CategoryID    *uint          `gorm:"index" json:"category_id"`
+	CategoryRef   *Category      `gorm:"foreignKey:CategoryID" json:"-"`
+	Category      sql.NullString `gorm:"-" json:"category"` // not persisted; see AfterFind
+	AreaID        *uint          `json:"area_id"`
+	Owner         string         `gorm:"size:255" json:"owner"` // empty inherits the parent's owner
+	Note          string         `gorm:"size:255" json:"note"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+func (a *TransactionAllocation) AfterFind(tx *gorm.DB) error {
+	if a.CategoryRef != nil {
+		a.Category = sql.NullString{String: a.CategoryRef.Name, Valid: true}
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Define the expected JSON structure
@@ -19,7 +21,7 @@ type Transaction struct {
 	Amount        sql.NullFloat64 `json:"amount"`
 	BalanceAfter  sql.NullFloat64 `json:"balance_after"`
 	Type          sql.NullString  `json:"type"` // "debit" | "credit"
-	Category      sql.NullString  `json:"category"`
+	Category      sql.NullString  `gorm:"-" json:"category"`
 	Owner         sql.NullString  `json:"owner"`
 	AreaID        *uint           `json:"area_id"`
 	Area          *Area           `gorm:"foreignKey:AreaID" json:"area,omitempty"`
@@ -27,4 +29,24 @@ type Transaction struct {
 	UserConfirmed bool            `gorm:"column:user_confirmed" json:"user_confirmed"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
+
+	// Category is not persisted: it carries the name of CategoryRef (see AfterFind), and Owner the name of
+	// OwnerID, both kept in sync by the services.
+	CategoryID    *uint                   `gorm:"index" json:"category_id"`
+	CategoryRef   *Category               `gorm:"foreignKey:CategoryID" json:"-"`
+	OwnerID       *uint                   `gorm:"index" json:"owner_id"`
+	Tags          []Tag                   `gorm:"many2many:transaction_tags" json:"tags,omitempty"`
+	Allocations   []TransactionAllocation `gorm:"foreignKey:TransactionID" json:"allocations,omitempty"`
+	ImportBatchID *uint                   `gorm:"index" json:"import_batch_id"`
+	AccountID     *uint                   `gorm:"index" json:"account_id"`
+}
+
+// AfterFind exposes the current name of the referenced category through Category, so renames show up on
+// every historical transaction and older clients keep receiving a name. CategoryRef must be preloaded for
+// the name to be available; on writes, services resolve Category back to CategoryID.
+func (t *Transaction) AfterFind(tx *gorm.DB) error {
+	if t.CategoryRef != nil {
+		t.Category = sql.NullString{String: t.CategoryRef.Name, Valid: true}
+	}
+	return nil
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TransferLink pairs the two sides of money moved between the workspace's own accounts: the debit leaving
+// one account and the credit arriving in another. Linked transactions are neither spending nor income, so
+// summaries leave them out. Each transaction belongs to at most one link.
+type TransferLink struct {
+	ID                  uint         `gorm:"primaryKey" json:"id"`
+	WorkspaceID         uint         `gorm:"not null;index" json:"workspace_id"`
+	DebitTransactionID  uint         `gorm:"not null;uniqueIndex" json:"debit_transaction_id"`
+	DebitTransaction    *Transaction `gorm:"foreignKey:DebitTransactionID" json:"debit_transaction,omitempty"`
+	CreditTransactionID uint         `gorm:"not null;uniqueIndex" json:"credit_transaction_id"`
+	CreditTransaction   *Transaction `gorm:"foreignKey:CreditTransactionID" json:"credit_transaction,omitempty"`
+	CreatedBy           uint         `json:"created_by"`
+	CreatedAt           time.Time    `json:"created_at"`
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/openai/openai-go/responses"
 )
 
+// TextModel is the model used for plain text prompts such as categorization.
+const TextModel = openai.ChatModelGPT4o
+
 type OpenAIClient struct {
 	Client  *openai.Client
 	Context context.Context
@@ -96,7 +99,7 @@ func (c *OpenAIClient) GetEmbedding(text string) ([]float64, error) {
 
 func (c *OpenAIClient) PromptText(prompt string) (string, error) {
 	params := responses.ResponseNewParams{
-		Model: openai.ChatModelGPT4o,
+		Model: TextModel,
 		Input: responses.ResponseNewParamsInputUnion{
 			OfInputItemList: responses.ResponseInputParam{
 				responses.ResponseInputItemParamOfMessage(
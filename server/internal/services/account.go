@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAccount  = errors.New("invalid account")
+	ErrAccountNotFound = errors.New("account not found in this workspace")
+)
+
+type AccountService struct {
+	db *gorm.DB
+}
+
+func NewAccountService(db *gorm.DB) *AccountService {
+	return &AccountService{db: db}
+}
+
+func (s *AccountService) List(workspaceID uint) ([]models.Account, error) {
+	var accounts []models.Account
+	err := s.db.Where("workspace_id = ?", workspaceID).Order("name ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+func (s *AccountService) FindByID(id, workspaceID uint) (*models.Account, error) {
+	var account models.Account
+	err := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&account).Error
+	return &account, err
+}
+
+// Save validates and creates or updates the account.
+func (s *AccountService) Save(account *models.Account) error {
+	if err := s.validate(account); err != nil {
+		return err
+	}
+	return s.db.Save(account).Error
+}
+
+func (s *AccountService) validate(account *models.Account) error {
+	account.Name = strings.TrimSpace(account.Name)
+	account.Institution = strings.TrimSpace(account.Institution)
+	account.Currency = strings.ToUpper(strings.TrimSpace(account.Currency))
+	if account.Currency == "" {
+		account.Currency = "ARS"
+	}
+
+	if account.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAccount)
+	}
+	switch account.Type {
+	case models.AccountTypeBank, models.AccountTypeCard, models.AccountTypeWallet, models.AccountTypeCash:
+	default:
+		return fmt.Errorf("%w: type must be bank, card, wallet or cash", ErrInvalidAccount)
+	}
+	if len(account.Currency) != 3 {
+		return fmt.Errorf("%w: currency must be a 3-letter code", ErrInvalidAccount)
+	}
+	if account.OwnerUserID != nil {
+		var count int64
+		if err := s.db.Model(&models.WorkspaceMember{}).
+			Where("workspace_id = ? AND user_id = ?", account.WorkspaceID, *account.OwnerUserID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: owner is not a member of this workspace", ErrInvalidAccount)
+		}
+	}
+	return nil
+}
+
+// Delete removes the account. Its transactions, import batches and recurring expenses are kept but no longer
+// assigned to it.
+func (s *AccountService) Delete(id, workspaceID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var account models.Account
+		if err := tx.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&account).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Transaction{}).Where("account_id = ?", account.ID).
+			Update("account_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ImportBatch{}).Where("account_id = ?", account.ID).
+			Update("account_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RecurringExpense{}).Where("account_id = ?", account.ID).
+			Update("account_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&account).Error
+	})
+}
+
+type AccountBalance struct {
+	Account        models.Account `json:"account"`
+	AsOf           string         `json:"as_of"`
+	OpeningBalance float64        `json:"opening_balance"`
+	Credits        float64        `json:"credits"`
+	Debits         float64        `json:"debits"`
+	Balance        float64        `json:"balance"`
+	Count          int64          `json:"count"`
+}
+
+// Balance returns the account balance at the end of the day asOf.
+func (s *AccountService) Balance(id, workspaceID uint, asOf time.Time) (*AccountBalance, error) {
+	account, err := s.FindByID(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.balances(workspaceID, []models.Account{*account}, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return &balances[0], nil
+}
+
+// Balances returns the balance of every workspace account at the end of the day asOf.
+func (s *AccountService) Balances(workspaceID uint, asOf time.Time) ([]AccountBalance, error) {
+	accounts, err := s.List(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.balances(workspaceID, accounts, asOf)
+}
+
+func (s *AccountService) balances(workspaceID uint, accounts []models.Account, asOf time.Time) ([]AccountBalance, error) {
+	balances := make([]AccountBalance, 0, len(accounts))
+	for _, account := range accounts {
+		var totals accountTotals
+		if err := accountTotalsQuery(s.db, workspaceID, account, asOf).Scan(&totals).Error; err != nil {
+			return nil, err
+		}
+		balances = append(balances, newAccountBalance(account, asOf, totals))
+	}
+	return balances, nil
+}
+
+type accountTotals struct {
+	Credits float64
+	Debits  float64
+	Count   int64
+}
+
+// accountTotalsQuery sums the account's transactions from OpeningDate, when set, to the end of the day asOf.
+func accountTotalsQuery(db *gorm.DB, workspaceID uint, account models.Account, asOf time.Time) *gorm.DB {
+	end := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location()).AddDate(0, 0, 1)
+	query := db.Model(&models.Transaction{}).
+		Select(`COALESCE(SUM(CASE WHEN type = 'credit' THEN amount ELSE 0 END), 0) as credits,
+			COALESCE(SUM(CASE WHEN type = 'debit' THEN amount ELSE 0 END), 0) as debits,
+			COUNT(*) as count`).
+		Where("workspace_id = ? AND account_id = ? AND date < ?", workspaceID, account.ID, end)
+	if account.OpeningDate != nil {
+		query = query.Where("date >= ?", *account.OpeningDate)
+	}
+	return query
+}
+
+func newAccountBalance(account models.Account, asOf time.Time, totals accountTotals) AccountBalance {
+	return AccountBalance{
+		Account:        account,
+		AsOf:           asOf.Format("2006-01-02"),
+		OpeningBalance: account.OpeningBalance,
+		Credits:        totals.Credits,
+		Debits:         totals.Debits,
+		Balance:        account.OpeningBalance + totals.Credits - totals.Debits,
+		Count:          totals.Count,
+	}
+}
+
+// validateAccountID checks that id, when set, names an account of the workspace.
+func validateAccountID(db *gorm.DB, workspaceID uint, id *uint) error {
+	if id == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.Account{}).Where("id = ? AND workspace_id = ?", *id, workspaceID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestNewAccountBalance(t *testing.T) {
+	account := models.Account{ID: 2, Name: "Visa", OpeningBalance: 1500}
+	asOf := time.Date(2026, 3, 31, 15, 0, 0, 0, time.UTC)
+
+	balance := newAccountBalance(account, asOf, accountTotals{Credits: 400, Debits: 2500, Count: 5})
+	if balance.Balance != -600 || balance.OpeningBalance != 1500 || balance.Count != 5 {
+		t.Fatalf("balance = %+v", balance)
+	}
+	if balance.AsOf != "2026-03-31" {
+		t.Fatalf("as of = %q", balance.AsOf)
+	}
+
+	if empty := newAccountBalance(account, asOf, accountTotals{}); empty.Balance != 1500 {
+		t.Fatalf("balance without transactions = %v", empty.Balance)
+	}
+}
+
+func TestAccountTotalsQuery(t *testing.T) {
+	db := dryRunDB(t)
+	asOf := time.Date(2026, 3, 31, 15, 0, 0, 0, time.UTC)
+	toSQL := func(account models.Account) string {
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return accountTotalsQuery(tx, 4, account, asOf).Scan(&accountTotals{})
+		})
+	}
+
+	all := toSQL(models.Account{ID: 2})
+	if !strings.Contains(all, "workspace_id = 4 AND account_id = 2 AND date < '2026-04-01 00:00:00'") {
+		t.Errorf("SQL does not end at the day after asOf:\n%s", all)
+	}
+	if strings.Contains(all, "date >=") {
+		t.Errorf("account without opening date should sum every transaction:\n%s", all)
+	}
+
+	opened := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	cut := toSQL(models.Account{ID: 2, OpeningDate: &opened})
+	if !strings.Contains(cut, "date >= '2026-01-15 00:00:00'") {
+		t.Errorf("SQL does not start at the opening date:\n%s", cut)
+	}
+}
+
+func TestAccountValidate(t *testing.T) {
+	service := &AccountService{db: dryRunDB(t)}
+
+	account := &models.Account{Name: "  Galicia ", Type: models.AccountTypeBank, Institution: " Banco Galicia "}
+	if err := service.validate(account); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if account.Name != "Galicia" || account.Institution != "Banco Galicia" || account.Currency != "ARS" {
+		t.Fatalf("normalised = %+v", account)
+	}
+
+	usd := &models.Account{Name: "Wise", Type: models.AccountTypeWallet, Currency: " usd "}
+	if err := service.validate(usd); err != nil || usd.Currency != "USD" {
+		t.Fatalf("currency = %q, %v", usd.Currency, err)
+	}
+
+	member := uint(8)
+	for name, account := range map[string]*models.Account{
+		"blank name":    {Name: " ", Type: models.AccountTypeCash},
+		"unknown type":  {Name: "Savings", Type: "savings"},
+		"currency code": {Name: "Cash", Type: models.AccountTypeCash, Currency: "PESOS"},
+		"non-member":    {Name: "Cash", Type: models.AccountTypeCash, OwnerUserID: &member},
+	} {
+		if err := service.validate(account); !errors.Is(err, ErrInvalidAccount) {
+			t.Errorf("%s: expected ErrInvalidAccount, got %v", name, err)
+		}
+	}
+}
@@ -61,9 +61,12 @@ type AreaSummaryResponse struct {
 	Month      string            `json:"month"`
 	TotalSpent float64           `json:"total_spent"`
 	Areas      []AreaSummaryItem `json:"areas"`
+	RealTerms  *RealTerms        `json:"real_terms,omitempty"`
 }
 
-func (s *AreaService) GetMonthlySummary(workspaceID uint, month string) (*AreaSummaryResponse, error) {
+// GetMonthlySummary groups the month's debits by effective area, listing categories at the given taxonomy level.
+// A non-nil deflator restates amounts in real terms.
+func (s *AreaService) GetMonthlySummary(workspaceID uint, month string, level int, deflator *Deflator) (*AreaSummaryResponse, error) {
 	startDate, err := time.Parse("2006-01", month)
 	if err != nil {
 		return nil, err
@@ -83,14 +86,14 @@ func (s *AreaService) GetMonthlySummary(workspaceID uint, month string) (*AreaSu
 	// Query transactions joined with categories to get effective area
 	query := `
 		SELECT
-			t.id,
+			t.transaction_id as id,
 			COALESCE(t.amount, 0) as amount,
-			COALESCE(t.category, '') as category,
+			COALESCE(c.name, '') as category,
 			t.area_id as txn_area_id,
 			c.id as category_id,
 			c.area_id as category_area
-		FROM transactions t
-		LEFT JOIN categories c ON c.name = t.category AND c.workspace_id = t.workspace_id
+		FROM (` + ledgerLinesSQL + `) t
+		LEFT JOIN categories c ON c.id = t.category_id
 		WHERE t.workspace_id = ?
 			AND t.date >= ?
 			AND t.date < ?
@@ -98,6 +101,24 @@ func (s *AreaService) GetMonthlySummary(workspaceID uint, month string) (*AreaSu
 	`
 	s.db.Raw(query, workspaceID, startDate, endDate).Scan(&transactions)
 
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		txn := &transactions[i]
+		txn.Amount = deflator.Restate(txn.Amount, startDate)
+		if txn.CategoryID == nil {
+			continue
+		}
+		// Subcategories without their own area inherit it from the nearest ancestor.
+		txn.CategoryArea = tree.areaID(*txn.CategoryID)
+		if group, ok := tree.group(*txn.CategoryID, level); ok {
+			txn.CategoryID = &group.ID
+			txn.Category = group.Name
+		}
+	}
+
 	// Get all areas for the workspace
 	areas, _ := s.List(workspaceID)
 	areaMap := make(map[uint]*models.Area)
@@ -173,6 +194,7 @@ func (s *AreaService) GetMonthlySummary(workspaceID uint, month string) (*AreaSu
 		Month:      month,
 		TotalSpent: totalSpent,
 		Areas:      []AreaSummaryItem{},
+		RealTerms:  deflator.RealTerms(),
 	}
 
 	for areaID, data := range areaSummaries {
@@ -253,9 +275,12 @@ type YearlyAreaSummaryResponse struct {
 	Year       string           `json:"year"`
 	TotalSpent float64          `json:"total_spent"`
 	Areas      []YearlyAreaItem `json:"areas"`
+	RealTerms  *RealTerms       `json:"real_terms,omitempty"`
 }
 
-func (s *AreaService) GetYearlySummary(workspaceID uint, year string) (*YearlyAreaSummaryResponse, error) {
+// GetYearlySummary groups the year's debits by effective area, listing categories at the given taxonomy level.
+// A non-nil deflator restates each month's amounts in real terms.
+func (s *AreaService) GetYearlySummary(workspaceID uint, year string, level int, deflator *Deflator) (*YearlyAreaSummaryResponse, error) {
 	startDate, err := time.Parse("2006", year)
 	if err != nil {
 		return nil, err
@@ -270,20 +295,20 @@ func (s *AreaService) GetYearlySummary(workspaceID uint, year string) (*YearlyAr
 		TxnAreaID    *uint
 		CategoryID   *uint
 		CategoryArea *uint
-		Month        string
+		Date         time.Time
 	}
 
 	query := `
 		SELECT
-			t.id,
+			t.transaction_id as id,
 			COALESCE(t.amount, 0) as amount,
-			COALESCE(t.category, '') as category,
+			COALESCE(c.name, '') as category,
 			t.area_id as txn_area_id,
 			c.id as category_id,
 			c.area_id as category_area,
-			DATE_FORMAT(t.date, '%Y-%m') as month
-		FROM transactions t
-		LEFT JOIN categories c ON c.name = t.category AND c.workspace_id = t.workspace_id
+			t.date
+		FROM (` + ledgerLinesSQL + `) t
+		LEFT JOIN categories c ON c.id = t.category_id
 		WHERE t.workspace_id = ?
 			AND t.date >= ?
 			AND t.date < ?
@@ -291,6 +316,24 @@ func (s *AreaService) GetYearlySummary(workspaceID uint, year string) (*YearlyAr
 	`
 	s.db.Raw(query, workspaceID, startDate, endDate).Scan(&transactions)
 
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		txn := &transactions[i]
+		txn.Amount = deflator.Restate(txn.Amount, txn.Date)
+		if txn.CategoryID == nil {
+			continue
+		}
+		// Subcategories without their own area inherit it from the nearest ancestor.
+		txn.CategoryArea = tree.areaID(*txn.CategoryID)
+		if group, ok := tree.group(*txn.CategoryID, level); ok {
+			txn.CategoryID = &group.ID
+			txn.Category = group.Name
+		}
+	}
+
 	// Get all areas for the workspace
 	areas, _ := s.List(workspaceID)
 	areaMap := make(map[uint]*models.Area)
@@ -360,12 +403,9 @@ func (s *AreaService) GetYearlySummary(workspaceID uint, year string) (*YearlyAr
 		target.amount += txn.Amount
 
 		// Update monthly amount
-		txnMonth, err := time.Parse("2006-01", txn.Month)
-		if err == nil {
-			monthIndex := int(txnMonth.Month()) - 1
-			if monthIndex >= 0 && monthIndex < len(target.monthly) {
-				target.monthly[monthIndex].Amount += txn.Amount
-			}
+		monthIndex := int(txn.Date.Month()) - 1
+		if monthIndex >= 0 && monthIndex < len(target.monthly) {
+			target.monthly[monthIndex].Amount += txn.Amount
 		}
 
 		// Update category data
@@ -388,6 +428,7 @@ func (s *AreaService) GetYearlySummary(workspaceID uint, year string) (*YearlyAr
 		Year:       year,
 		TotalSpent: totalSpent,
 		Areas:      []YearlyAreaItem{},
+		RealTerms:  deflator.RealTerms(),
 	}
 
 	for areaID, data := range areaSummaries {
@@ -0,0 +1,543 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BudgetAlertThresholds are the percentages of the available budget that raise an alert.
+var BudgetAlertThresholds = []int{80, 100}
+
+// maxRolloverMonths bounds how far back rollover chains are followed.
+const maxRolloverMonths = 24
+
+var ErrInvalidBudget = errors.New("invalid budget")
+
+// Budget statuses in BudgetStatus.
+const (
+	BudgetOK       = "ok"
+	BudgetWarning  = "warning"  // past the first alert threshold
+	BudgetExceeded = "exceeded" // at or past 100%
+)
+
+// BudgetStatus is one budget against the month's actual spending.
+type BudgetStatus struct {
+	Budget      models.Budget `json:"budget"`
+	TargetName  string        `json:"target_name"`
+	CarriedOver float64       `json:"carried_over"`
+	Available   float64       `json:"available"` // amount plus carried over
+	Spent       float64       `json:"spent"`
+	Remaining   float64       `json:"remaining"`
+	Percentage  float64       `json:"percentage"`
+	Status      string        `json:"status"`
+}
+
+type BudgetReport struct {
+	Month          string         `json:"month"`
+	TotalAvailable float64        `json:"total_available"`
+	TotalSpent     float64        `json:"total_spent"`
+	Budgets        []BudgetStatus `json:"budgets"`
+}
+
+type BudgetService struct {
+	db          *gorm.DB
+	areaService *AreaService
+}
+
+func NewBudgetService(db *gorm.DB, areaService *AreaService) *BudgetService {
+	return &BudgetService{db: db, areaService: areaService}
+}
+
+// List returns the month's stored budgets. Budgets due from templates appear once ApplyTemplates, a template
+// save or a new transaction in the month has created them.
+func (s *BudgetService) List(workspaceID uint, month string) ([]models.Budget, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidBudget)
+	}
+	var budgets []models.Budget
+	err := s.db.Where("workspace_id = ? AND month = ?", workspaceID, month).Order("id ASC").Find(&budgets).Error
+	return budgets, err
+}
+
+func (s *BudgetService) FindByID(id, workspaceID uint) (*models.Budget, error) {
+	var budget models.Budget
+	err := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&budget).Error
+	return &budget, err
+}
+
+// Save validates and creates or updates a budget. A month has at most one budget per target.
+func (s *BudgetService) Save(budget *models.Budget) error {
+	if _, err := time.Parse("2006-01", budget.Month); err != nil {
+		return fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidBudget)
+	}
+	if err := validateBudgetTarget(s.db, budget.WorkspaceID, budget.CategoryID, budget.AreaID, budget.Amount); err != nil {
+		return err
+	}
+
+	var count int64
+	query := budgetTargetQuery(s.db.Model(&models.Budget{}), budget.CategoryID, budget.AreaID).
+		Where("workspace_id = ? AND month = ? AND id <> ?", budget.WorkspaceID, budget.Month, budget.ID)
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s already has a budget for this target", ErrInvalidBudget, budget.Month)
+	}
+	budget.Target = budgetTarget(budget.CategoryID, budget.AreaID)
+	return s.db.Save(budget).Error
+}
+
+// Delete removes a budget and its alerts.
+func (s *BudgetService) Delete(id, workspaceID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var budget models.Budget
+		if err := tx.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&budget).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("budget_id = ?", budget.ID).Delete(&models.BudgetAlert{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&budget).Error
+	})
+}
+
+func (s *BudgetService) ListTemplates(workspaceID uint) ([]models.BudgetTemplate, error) {
+	var templates []models.BudgetTemplate
+	err := s.db.Where("workspace_id = ?", workspaceID).Order("start_month ASC, id ASC").Find(&templates).Error
+	return templates, err
+}
+
+func (s *BudgetService) FindTemplateByID(id, workspaceID uint) (*models.BudgetTemplate, error) {
+	var template models.BudgetTemplate
+	err := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&template).Error
+	return &template, err
+}
+
+// SaveTemplate validates and creates or updates a template, then creates the current month's budget from it
+// if due. Budgets already created from it keep their amounts; later months pick up the change.
+func (s *BudgetService) SaveTemplate(template *models.BudgetTemplate) error {
+	start, err := time.Parse("2006-01", template.StartMonth)
+	if err != nil {
+		return fmt.Errorf("%w: start_month must be YYYY-MM", ErrInvalidBudget)
+	}
+	if template.EndMonth != "" {
+		end, err := time.Parse("2006-01", template.EndMonth)
+		if err != nil {
+			return fmt.Errorf("%w: end_month must be YYYY-MM", ErrInvalidBudget)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("%w: end_month is before start_month", ErrInvalidBudget)
+		}
+	}
+	if err := validateBudgetTarget(s.db, template.WorkspaceID, template.CategoryID, template.AreaID, template.Amount); err != nil {
+		return err
+	}
+	if err := s.db.Save(template).Error; err != nil {
+		return err
+	}
+	return applyTemplates(s.db, template.WorkspaceID, time.Now().Format("2006-01"))
+}
+
+// DeleteTemplate removes a template. Budgets created from it stay.
+func (s *BudgetService) DeleteTemplate(id, workspaceID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var template models.BudgetTemplate
+		if err := tx.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&template).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Budget{}).Where("template_id = ?", template.ID).Update("template_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&template).Error
+	})
+}
+
+func validateBudgetTarget(db *gorm.DB, workspaceID uint, categoryID, areaID *uint, amount float64) error {
+	if (categoryID == nil) == (areaID == nil) {
+		return fmt.Errorf("%w: set either category_id or area_id", ErrInvalidBudget)
+	}
+	if amount < 0 {
+		return fmt.Errorf("%w: amount must not be negative", ErrInvalidBudget)
+	}
+
+	var count int64
+	var err error
+	if categoryID != nil {
+		err = db.Model(&models.Category{}).Where("id = ? AND workspace_id = ?", *categoryID, workspaceID).Count(&count).Error
+	} else {
+		err = db.Model(&models.Area{}).Where("id = ? AND workspace_id = ?", *areaID, workspaceID).Count(&count).Error
+	}
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: category or area not found in this workspace", ErrInvalidBudget)
+	}
+	return nil
+}
+
+func budgetTargetQuery(query *gorm.DB, categoryID, areaID *uint) *gorm.DB {
+	if categoryID != nil {
+		return query.Where("category_id = ? AND area_id IS NULL", *categoryID)
+	}
+	return query.Where("area_id = ? AND category_id IS NULL", *areaID)
+}
+
+// budgetTarget is the Budget.Target key of a category or area budget.
+func budgetTarget(categoryID, areaID *uint) string {
+	if categoryID != nil {
+		return fmt.Sprintf("category:%d", *categoryID)
+	}
+	if areaID != nil {
+		return fmt.Sprintf("area:%d", *areaID)
+	}
+	return ""
+}
+
+// ApplyTemplates creates the month's budgets due from templates and returns all of the month's budgets.
+func (s *BudgetService) ApplyTemplates(workspaceID uint, month string) ([]models.Budget, error) {
+	if err := applyTemplates(s.db, workspaceID, month); err != nil {
+		return nil, err
+	}
+	return s.List(workspaceID, month)
+}
+
+// applyTemplates creates the month's budgets from templates covering it, skipping targets that already
+// have a budget that month.
+func applyTemplates(db *gorm.DB, workspaceID uint, month string) error {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidBudget)
+	}
+
+	var templates []models.BudgetTemplate
+	if err := db.Where("workspace_id = ? AND start_month <= ? AND (end_month = '' OR end_month IS NULL OR end_month >= ?)",
+		workspaceID, month, month).Find(&templates).Error; err != nil {
+		return err
+	}
+	for _, template := range templates {
+		templateID := template.ID
+		budget := models.Budget{
+			WorkspaceID: workspaceID,
+			Month:       month,
+			CategoryID:  template.CategoryID,
+			AreaID:      template.AreaID,
+			Target:      budgetTarget(template.CategoryID, template.AreaID),
+			Amount:      template.Amount,
+			Rollover:    template.Rollover,
+			TemplateID:  &templateID,
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&budget).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// monthlySpending is the spending of one month by leaf category and by effective area, taken from the
+// area summary so budgets and the area dashboard always agree.
+type monthlySpending struct {
+	byCategory map[uint]float64
+	byArea     map[uint]float64
+}
+
+// Report compares each of the month's budgets with actual spending. Category budgets include spending in
+// their subcategories; area budgets use the transaction's area, else its category's.
+func (s *BudgetService) Report(workspaceID uint, month string) (*BudgetReport, error) {
+	budgets, err := s.List(workspaceID, month)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	var areas []models.Area
+	if err := s.db.Where("workspace_id = ?", workspaceID).Find(&areas).Error; err != nil {
+		return nil, err
+	}
+	areaNames := make(map[uint]string, len(areas))
+	for _, a := range areas {
+		areaNames[a.ID] = a.Name
+	}
+
+	statuses, err := s.statuses(workspaceID, month, budgets, tree)
+	if err != nil {
+		return nil, err
+	}
+	report := &BudgetReport{Month: month, Budgets: []BudgetStatus{}}
+	for _, status := range statuses {
+		if status.Budget.CategoryID != nil {
+			if c, ok := tree.byID[*status.Budget.CategoryID]; ok {
+				status.TargetName = c.Name
+			}
+		} else {
+			status.TargetName = areaNames[*status.Budget.AreaID]
+		}
+		report.Budgets = append(report.Budgets, status)
+		report.TotalAvailable += status.Available
+		report.TotalSpent += status.Spent
+	}
+	sort.SliceStable(report.Budgets, func(i, j int) bool { return report.Budgets[i].TargetName < report.Budgets[j].TargetName })
+	return report, nil
+}
+
+// statuses compares each of budgets, all of month, with the month's spending.
+func (s *BudgetService) statuses(workspaceID uint, month string, budgets []models.Budget, tree *categoryTree) ([]BudgetStatus, error) {
+	spending := map[string]*monthlySpending{}
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		spent, err := s.spent(workspaceID, month, budget, tree, spending)
+		if err != nil {
+			return nil, err
+		}
+		carried, err := s.carriedOver(workspaceID, budget, tree, spending)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, newBudgetStatus(budget, carried, spent))
+	}
+	return statuses, nil
+}
+
+func newBudgetStatus(budget models.Budget, carried, spent float64) BudgetStatus {
+	status := BudgetStatus{
+		Budget:      budget,
+		CarriedOver: carried,
+		Available:   budget.Amount + carried,
+		Spent:       spent,
+		Status:      BudgetOK,
+	}
+	status.Remaining = status.Available - spent
+	if status.Available > 0 {
+		status.Percentage = spent / status.Available * 100
+	} else if spent > 0 {
+		status.Percentage = 100
+	}
+	switch {
+	case status.Percentage >= 100:
+		status.Status = BudgetExceeded
+	case status.Percentage >= float64(BudgetAlertThresholds[0]):
+		status.Status = BudgetWarning
+	}
+	return status
+}
+
+func (s *BudgetService) monthSpending(workspaceID uint, month string, cache map[string]*monthlySpending) (*monthlySpending, error) {
+	if cached, ok := cache[month]; ok {
+		return cached, nil
+	}
+	summary, err := s.areaService.GetMonthlySummary(workspaceID, month, CategoryLevelLeaf, nil)
+	if err != nil {
+		return nil, err
+	}
+	spending := &monthlySpending{byCategory: map[uint]float64{}, byArea: map[uint]float64{}}
+	for _, area := range summary.Areas {
+		if area.AreaID != nil {
+			spending.byArea[*area.AreaID] += area.Amount
+		}
+		for _, c := range area.Categories {
+			if c.CategoryID != 0 {
+				spending.byCategory[c.CategoryID] += c.Amount
+			}
+		}
+	}
+	cache[month] = spending
+	return spending, nil
+}
+
+func (s *BudgetService) spent(workspaceID uint, month string, budget models.Budget, tree *categoryTree, cache map[string]*monthlySpending) (float64, error) {
+	spending, err := s.monthSpending(workspaceID, month, cache)
+	if err != nil {
+		return 0, err
+	}
+	if budget.AreaID != nil {
+		return spending.byArea[*budget.AreaID], nil
+	}
+	total := 0.0
+	for categoryID, amount := range spending.byCategory {
+		for _, ancestor := range tree.path(categoryID) {
+			if ancestor.ID == *budget.CategoryID {
+				total += amount
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// carriedOver is what the previous month's budget for the same target passes on when it rolls over:
+// its own available amount minus its spending.
+func (s *BudgetService) carriedOver(workspaceID uint, budget models.Budget, tree *categoryTree, cache map[string]*monthlySpending) (float64, error) {
+	var chain []models.Budget
+	current := budget
+	for len(chain) < maxRolloverMonths {
+		start, _ := time.Parse("2006-01", current.Month)
+		previousMonth := start.AddDate(0, -1, 0).Format("2006-01")
+		var previous models.Budget
+		err := budgetTargetQuery(s.db, current.CategoryID, current.AreaID).
+			Where("workspace_id = ? AND month = ?", workspaceID, previousMonth).
+			First(&previous).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !previous.Rollover {
+			break
+		}
+		chain = append(chain, previous)
+		current = previous
+	}
+
+	// Walk forward from the oldest rolled-over month.
+	carried := 0.0
+	for i := len(chain) - 1; i >= 0; i-- {
+		spent, err := s.spent(workspaceID, chain[i].Month, chain[i], tree, cache)
+		if err != nil {
+			return 0, err
+		}
+		carried = chain[i].Amount + carried - spent
+	}
+	return carried, nil
+}
+
+// EvaluateAlerts records an alert for each threshold newly crossed by the month's budgets that transactions
+// count towards, after creating the month's budgets due from templates, and returns the new alerts.
+func (s *BudgetService) EvaluateAlerts(workspaceID uint, month string, transactions []models.Transaction) ([]models.BudgetAlert, error) {
+	if err := applyTemplates(s.db, workspaceID, month); err != nil {
+		return nil, err
+	}
+	budgets, err := s.List(workspaceID, month)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	budgets = touchedBudgets(budgets, tree, transactions)
+	if len(budgets) == 0 {
+		return []models.BudgetAlert{}, nil
+	}
+	statuses, err := s.statuses(workspaceID, month, budgets, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	created := []models.BudgetAlert{}
+	for _, status := range statuses {
+		for _, threshold := range BudgetAlertThresholds {
+			if status.Percentage < float64(threshold) {
+				continue
+			}
+			var count int64
+			if err := s.db.Model(&models.BudgetAlert{}).
+				Where("budget_id = ? AND threshold = ?", status.Budget.ID, threshold).
+				Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				continue
+			}
+			alert := models.BudgetAlert{
+				WorkspaceID: workspaceID,
+				BudgetID:    status.Budget.ID,
+				Month:       month,
+				Threshold:   threshold,
+				Spent:       status.Spent,
+				Available:   status.Available,
+			}
+			if err := s.db.Create(&alert).Error; err != nil {
+				return nil, err
+			}
+			created = append(created, alert)
+		}
+	}
+	return created, nil
+}
+
+// touchedBudgets keeps the budgets that transactions count towards: category budgets on the category or
+// one of its ancestors, and area budgets on the transaction's area, else its category's.
+func touchedBudgets(budgets []models.Budget, tree *categoryTree, transactions []models.Transaction) []models.Budget {
+	categories := map[uint]bool{}
+	areas := map[uint]bool{}
+	for _, t := range transactions {
+		if t.CategoryID != nil {
+			for _, ancestor := range tree.path(*t.CategoryID) {
+				categories[ancestor.ID] = true
+			}
+		}
+		area := t.AreaID
+		if area == nil && t.CategoryID != nil {
+			area = tree.areaID(*t.CategoryID)
+		}
+		if area != nil {
+			areas[*area] = true
+		}
+	}
+
+	var touched []models.Budget
+	for _, budget := range budgets {
+		if (budget.CategoryID != nil && categories[*budget.CategoryID]) || (budget.AreaID != nil && areas[*budget.AreaID]) {
+			touched = append(touched, budget)
+		}
+	}
+	return touched
+}
+
+// ListAlerts returns the workspace's alerts, newest first, optionally for one month and only unacknowledged.
+func (s *BudgetService) ListAlerts(workspaceID uint, month string, pendingOnly bool) ([]models.BudgetAlert, error) {
+	query := s.db.Where("workspace_id = ?", workspaceID)
+	if month != "" {
+		query = query.Where("month = ?", month)
+	}
+	if pendingOnly {
+		query = query.Where("acknowledged = ?", false)
+	}
+	alerts := []models.BudgetAlert{}
+	err := query.Order("created_at DESC, id DESC").Find(&alerts).Error
+	return alerts, err
+}
+
+func (s *BudgetService) AcknowledgeAlert(id, workspaceID uint) error {
+	result := s.db.Model(&models.BudgetAlert{}).
+		Where("id = ? AND workspace_id = ?", id, workspaceID).
+		Update("acknowledged", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// evaluateBudgetAlerts runs alert evaluation for the months of transactions. Callers run it in the
+// transaction that saves them, so the alerts are raised together with the writes or not at all.
+func evaluateBudgetAlerts(db *gorm.DB, workspaceID uint, transactions ...models.Transaction) error {
+	byMonth := map[string][]models.Transaction{}
+	var months []string
+	for _, t := range transactions {
+		month := t.Date.Format("2006-01")
+		if _, ok := byMonth[month]; !ok {
+			months = append(months, month)
+		}
+		byMonth[month] = append(byMonth[month], t)
+	}
+
+	service := NewBudgetService(db, NewAreaService(db))
+	for _, month := range months {
+		if _, err := service.EvaluateAlerts(workspaceID, month, byMonth[month]); err != nil {
+			return fmt.Errorf("budget alerts for %s: %w", month, err)
+		}
+	}
+	return nil
+}
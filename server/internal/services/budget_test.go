@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+)
+
+func TestNewBudgetStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      float64
+		carried     float64
+		spent       float64
+		wantStatus  string
+		wantPercent float64
+	}{
+		{"under budget", 1000, 0, 500, BudgetOK, 50},
+		{"past warning threshold", 1000, 0, 800, BudgetWarning, 80},
+		{"exceeded", 1000, 0, 1200, BudgetExceeded, 120},
+		{"rollover raises what is available", 1000, 600, 1200, BudgetOK, 75},
+		{"overspent rollover lowers it", 1000, -200, 800, BudgetExceeded, 100},
+		{"spending without a budget", 0, 0, 10, BudgetExceeded, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := newBudgetStatus(models.Budget{Amount: tt.amount}, tt.carried, tt.spent)
+			if status.Status != tt.wantStatus || status.Percentage != tt.wantPercent {
+				t.Fatalf("got %s at %.2f%%, want %s at %.2f%%", status.Status, status.Percentage, tt.wantStatus, tt.wantPercent)
+			}
+			if status.Remaining != tt.amount+tt.carried-tt.spent {
+				t.Fatalf("remaining = %.2f, want %.2f", status.Remaining, tt.amount+tt.carried-tt.spent)
+			}
+		})
+	}
+}
+
+func TestBudgetTarget(t *testing.T) {
+	if got := budgetTarget(uintPtr(4), nil); got != "category:4" {
+		t.Fatalf("category target = %q", got)
+	}
+	if got := budgetTarget(nil, uintPtr(10)); got != "area:10" {
+		t.Fatalf("area target = %q", got)
+	}
+}
+
+func TestTouchedBudgets(t *testing.T) {
+	tree := sampleCategoryTree()
+	budgets := []models.Budget{
+		{ID: 1, CategoryID: uintPtr(1)}, // Hogar, ancestor of Luz
+		{ID: 2, CategoryID: uintPtr(2)}, // Servicios, parent of Luz
+		{ID: 3, CategoryID: uintPtr(4)}, // Alquiler, a sibling branch
+		{ID: 4, AreaID: uintPtr(10)},    // Luz's area through Hogar
+		{ID: 5, AreaID: uintPtr(11)},    // Alquiler's area
+		{ID: 6, CategoryID: uintPtr(5)}, // Ocio
+		{ID: 7, AreaID: uintPtr(12)},    // the own area of the second transaction
+	}
+	transactions := []models.Transaction{
+		{CategoryID: uintPtr(3)},
+		{CategoryID: uintPtr(5), AreaID: uintPtr(12)},
+	}
+
+	var ids []uint
+	for _, budget := range touchedBudgets(budgets, tree, transactions) {
+		ids = append(ids, budget.ID)
+	}
+	if want := []uint{1, 2, 4, 6, 7}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("touched = %v, want %v", ids, want)
+	}
+
+	if touched := touchedBudgets(budgets, tree, []models.Transaction{{}}); len(touched) != 0 {
+		t.Fatalf("uncategorised transaction touched %v", touched)
+	}
+}
+
+func TestApplyTemplatesRejectsInvalidMonth(t *testing.T) {
+	if err := applyTemplates(dryRunDB(t), 3, "April"); !errors.Is(err, ErrInvalidBudget) {
+		t.Fatalf("expected invalid month to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// cardPaymentTolerance is how far a payment may be from the statement balance and still count as paid
+// in full, absorbing rounding on the statement.
+const cardPaymentTolerance = 1.0
+
+var ErrInvalidCardPayment = errors.New("invalid card payment")
+
+// cardPaymentPattern matches bank debits such as "PAGO VISA", "PAGO TARJETA MASTERCARD" or "PAG TC AMEX".
+var cardPaymentPattern = regexp.MustCompile(`(?i)\bpag(o|\.)?\b.*\b(visa|master(card)?|amex|american express|cabal|naranja|tarjeta|tc)\b`)
+
+// cardNetworks are the words used to suggest which card account a payment belongs to.
+var cardNetworks = []string{"visa", "master", "amex", "american express", "cabal", "naranja"}
+
+// Statement check statuses.
+const (
+	StatementPaid     = "paid"
+	StatementPartial  = "partial"
+	StatementOverpaid = "overpaid"
+	StatementUnknown  = "unknown" // no statement tied to the payment
+)
+
+// StatementCheck compares a card payment with the balance of the statement it pays.
+type StatementCheck struct {
+	Paid             float64  `json:"paid"`
+	StatementBalance *float64 `json:"statement_balance"`
+	Difference       *float64 `json:"difference"` // paid minus balance
+	Status           string   `json:"status"`
+}
+
+// CardPaymentDetail is a card payment with its statement check.
+type CardPaymentDetail struct {
+	models.CardPayment
+	Check StatementCheck `json:"check"`
+}
+
+// CardPaymentCandidate is an unmarked bank debit that looks like a card bill payment, with a suggested
+// card account and statement when one can be inferred.
+type CardPaymentCandidate struct {
+	Transaction   models.Transaction `json:"transaction"`
+	CardAccountID *uint              `json:"card_account_id"`
+	ImportBatchID *uint              `json:"import_batch_id"`
+	Check         *StatementCheck    `json:"check,omitempty"`
+}
+
+type CardPaymentInput struct {
+	TransactionID     uint  `json:"transaction_id" binding:"required"`
+	CardAccountID     uint  `json:"card_account_id" binding:"required"`
+	ImportBatchID     *uint `json:"import_batch_id"`
+	CardTransactionID *uint `json:"card_transaction_id"`
+}
+
+type CardPaymentService struct {
+	db *gorm.DB
+}
+
+func NewCardPaymentService(db *gorm.DB) *CardPaymentService {
+	return &CardPaymentService{db: db}
+}
+
+// List returns the workspace's card payments, newest first, each with its statement check.
+func (s *CardPaymentService) List(workspaceID uint) ([]CardPaymentDetail, error) {
+	var payments []models.CardPayment
+	if err := s.db.Preload("Transaction.CategoryRef").
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at DESC, id DESC").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	details := make([]CardPaymentDetail, 0, len(payments))
+	for _, p := range payments {
+		check, err := s.check(p)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, CardPaymentDetail{CardPayment: p, Check: *check})
+	}
+	return details, nil
+}
+
+// Create marks a bank debit as the payment of a card, optionally tied to a card statement and to the
+// matching credit on the card side.
+func (s *CardPaymentService) Create(workspaceID, userID uint, in CardPaymentInput) (*CardPaymentDetail, error) {
+	payment := models.CardPayment{
+		WorkspaceID:       workspaceID,
+		TransactionID:     in.TransactionID,
+		CardAccountID:     in.CardAccountID,
+		CardTransactionID: in.CardTransactionID,
+		ImportBatchID:     in.ImportBatchID,
+		CreatedBy:         userID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := validateCardPayment(tx, &payment); err != nil {
+			return err
+		}
+		return tx.Create(&payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("Transaction.CategoryRef").First(&payment, payment.ID).Error; err != nil {
+		return nil, err
+	}
+	check, err := s.check(payment)
+	if err != nil {
+		return nil, err
+	}
+	return &CardPaymentDetail{CardPayment: payment, Check: *check}, nil
+}
+
+func validateCardPayment(tx *gorm.DB, p *models.CardPayment) error {
+	var debit models.Transaction
+	if err := tx.Where("id = ? AND workspace_id = ?", p.TransactionID, p.WorkspaceID).First(&debit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: transaction not found", ErrInvalidCardPayment)
+		}
+		return err
+	}
+	if debit.Type.String != "debit" {
+		return fmt.Errorf("%w: the payment must be a debit", ErrInvalidCardPayment)
+	}
+
+	var card models.Account
+	if err := tx.Where("id = ? AND workspace_id = ?", p.CardAccountID, p.WorkspaceID).First(&card).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: card account not found", ErrInvalidCardPayment)
+		}
+		return err
+	}
+	if card.Type != models.AccountTypeCard {
+		return fmt.Errorf("%w: account %q is not a card", ErrInvalidCardPayment, card.Name)
+	}
+	if debit.AccountID != nil && *debit.AccountID == card.ID {
+		return fmt.Errorf("%w: the payment must come from another account", ErrInvalidCardPayment)
+	}
+
+	if p.ImportBatchID != nil {
+		var batch models.ImportBatch
+		if err := tx.Where("id = ? AND workspace_id = ?", *p.ImportBatchID, p.WorkspaceID).First(&batch).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: statement not found", ErrInvalidCardPayment)
+			}
+			return err
+		}
+		if batch.AccountID == nil || *batch.AccountID != card.ID {
+			return fmt.Errorf("%w: the statement was not imported for this card", ErrInvalidCardPayment)
+		}
+	}
+
+	ids := []uint{p.TransactionID}
+	if p.CardTransactionID != nil {
+		var credit models.Transaction
+		if err := tx.Where("id = ? AND workspace_id = ?", *p.CardTransactionID, p.WorkspaceID).First(&credit).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: card transaction not found", ErrInvalidCardPayment)
+			}
+			return err
+		}
+		if credit.Type.String != "credit" || (credit.AccountID != nil && *credit.AccountID != card.ID) {
+			return fmt.Errorf("%w: the card transaction must be a credit on the card", ErrInvalidCardPayment)
+		}
+		ids = append(ids, credit.ID)
+	}
+
+	linked, err := anyLinked(tx, ids...)
+	if err != nil {
+		return err
+	}
+	if linked {
+		return ErrTransactionLinked
+	}
+	return nil
+}
+
+// Delete unmarks a card payment; the debit counts as an expense again.
+func (s *CardPaymentService) Delete(id, workspaceID uint) error {
+	result := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.CardPayment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Candidates lists unmarked debits within [from, to) whose description looks like a card payment. The
+// card account is suggested from the card network named in the description, and the statement is that
+// card's most recent import ending on or before the payment date.
+func (s *CardPaymentService) Candidates(workspaceID uint, from, to *time.Time) ([]CardPaymentCandidate, error) {
+	query := s.db.Preload("CategoryRef").
+		Where("workspace_id = ? AND type = ?", workspaceID, "debit").
+		Where("id NOT IN (" + linkedTransactionIDsSQL + ")")
+	if from != nil {
+		query = query.Where("date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("date < ?", *to)
+	}
+	var debits []models.Transaction
+	if err := query.Order("date DESC, id DESC").Find(&debits).Error; err != nil {
+		return nil, err
+	}
+
+	var cards []models.Account
+	if err := s.db.Where("workspace_id = ? AND type = ?", workspaceID, models.AccountTypeCard).Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	candidates := []CardPaymentCandidate{}
+	for _, debit := range debits {
+		if !isCardPaymentDescription(debit.Description.String) {
+			continue
+		}
+		candidate := CardPaymentCandidate{Transaction: debit, CardAccountID: suggestCardAccount(debit.Description.String, cards)}
+		if candidate.CardAccountID != nil {
+			batchID, err := s.latestStatement(workspaceID, *candidate.CardAccountID, debit.Date)
+			if err != nil {
+				return nil, err
+			}
+			if batchID != nil {
+				candidate.ImportBatchID = batchID
+				check, err := s.check(models.CardPayment{TransactionID: debit.ID, ImportBatchID: batchID, Transaction: &debit})
+				if err != nil {
+					return nil, err
+				}
+				candidate.Check = check
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// latestStatement returns the card's import batch whose last transaction is the latest one on or before
+// paidOn, or nil when there is none.
+func (s *CardPaymentService) latestStatement(workspaceID, cardAccountID uint, paidOn time.Time) (*uint, error) {
+	var rows []struct {
+		ImportBatchID uint
+		LastDate      time.Time
+	}
+	err := s.db.Model(&models.Transaction{}).
+		Select("import_batch_id, MAX(date) as last_date").
+		Joins("JOIN import_batches b ON b.id = transactions.import_batch_id").
+		Where("transactions.workspace_id = ? AND b.account_id = ?", workspaceID, cardAccountID).
+		Group("import_batch_id").
+		Having("MAX(date) < ?", paidOn.AddDate(0, 0, 1)).
+		Order("last_date DESC").
+		Limit(1).
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0].ImportBatchID, nil
+}
+
+func isCardPaymentDescription(description string) bool {
+	return cardPaymentPattern.MatchString(description)
+}
+
+// suggestCardAccount picks the card account whose name or institution mentions the card network of the
+// description; a workspace with a single card always gets that card. Ambiguous matches yield nothing.
+func suggestCardAccount(description string, cards []models.Account) *uint {
+	if len(cards) == 1 {
+		id := cards[0].ID
+		return &id
+	}
+	lower := strings.ToLower(description)
+	var match *uint
+	for _, network := range cardNetworks {
+		if !strings.Contains(lower, network) {
+			continue
+		}
+		for i := range cards {
+			label := strings.ToLower(cards[i].Name + " " + cards[i].Institution)
+			if strings.Contains(label, network) {
+				if match != nil && *match != cards[i].ID {
+					return nil
+				}
+				id := cards[i].ID
+				match = &id
+			}
+		}
+	}
+	return match
+}
+
+// check compares the payment with its statement. The statement balance is the one recorded on the import
+// batch, or else the batch's debits minus its credits (excluding payments received on the card).
+func (s *CardPaymentService) check(p models.CardPayment) (*StatementCheck, error) {
+	paid := 0.0
+	if p.Transaction != nil {
+		paid = p.Transaction.Amount.Float64
+	} else {
+		var debit models.Transaction
+		if err := s.db.First(&debit, p.TransactionID).Error; err != nil {
+			return nil, err
+		}
+		paid = debit.Amount.Float64
+	}
+	check := &StatementCheck{Paid: paid, Status: StatementUnknown}
+	if p.ImportBatchID == nil {
+		return check, nil
+	}
+
+	var batch models.ImportBatch
+	if err := s.db.First(&batch, *p.ImportBatchID).Error; err != nil {
+		return nil, err
+	}
+	balance := batch.StatementBalance
+	if balance == nil {
+		var totals struct {
+			Debit  float64
+			Credit float64
+		}
+		err := s.db.Model(&models.Transaction{}).
+			Select(`COALESCE(SUM(CASE WHEN type = 'debit' THEN amount ELSE 0 END), 0) as debit,
+				COALESCE(SUM(CASE WHEN type = 'credit' THEN amount ELSE 0 END), 0) as credit`).
+			Where("import_batch_id = ?", batch.ID).
+			Where("id NOT IN (SELECT card_transaction_id FROM card_payments WHERE card_transaction_id IS NOT NULL)").
+			Scan(&totals).Error
+		if err != nil {
+			return nil, err
+		}
+		computed := totals.Debit - totals.Credit
+		balance = &computed
+	}
+
+	check.StatementBalance = balance
+	check.Status = statementStatus(paid, *balance)
+	difference := paid - *balance
+	check.Difference = &difference
+	return check, nil
+}
+
+func statementStatus(paid, balance float64) string {
+	switch {
+	case math.Abs(paid-balance) <= cardPaymentTolerance:
+		return StatementPaid
+	case paid < balance:
+		return StatementPartial
+	default:
+		return StatementOverpaid
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"etl-banks-ar/internal/models"
+)
+
+func TestIsCardPaymentDescription(t *testing.T) {
+	for _, description := range []string{"PAGO VISA", "Pago tarjeta Mastercard Galicia", "PAG TC AMEX"} {
+		if !isCardPaymentDescription(description) {
+			t.Errorf("%q should look like a card payment", description)
+		}
+	}
+	for _, description := range []string{"COMPRA VISA DEBITO SUPERMERCADO", "PAGO SERVICIOS EDENOR"} {
+		if isCardPaymentDescription(description) {
+			t.Errorf("%q should not look like a card payment", description)
+		}
+	}
+}
+
+func TestSuggestCardAccount(t *testing.T) {
+	cards := []models.Account{
+		{ID: 1, Name: "Visa Galicia"},
+		{ID: 2, Name: "Master", Institution: "BBVA"},
+	}
+	if id := suggestCardAccount("PAGO MASTERCARD", cards); id == nil || *id != 2 {
+		t.Fatalf("expected the Master card, got %v", id)
+	}
+	if id := suggestCardAccount("PAGO TARJETA", cards); id != nil {
+		t.Fatalf("expected no suggestion without a network, got %d", *id)
+	}
+	if id := suggestCardAccount("PAGO TARJETA", cards[:1]); id == nil || *id != 1 {
+		t.Fatalf("expected the only card, got %v", id)
+	}
+}
+
+func TestStatementStatus(t *testing.T) {
+	cases := map[string][2]float64{
+		StatementPaid:     {150000.4, 150000},
+		StatementPartial:  {50000, 150000},
+		StatementOverpaid: {160000, 150000},
+	}
+	for want, amounts := range cases {
+		if got := statementStatus(amounts[0], amounts[1]); got != want {
+			t.Errorf("statementStatus(%v, %v) = %s, want %s", amounts[0], amounts[1], got, want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const defaultConfusedPairsLimit = 10
+
+type CategorizationFeedbackService struct {
+	db *gorm.DB
+}
+
+func NewCategorizationFeedbackService(db *gorm.DB) *CategorizationFeedbackService {
+	return &CategorizationFeedbackService{db: db}
+}
+
+type AccuracyStats struct {
+	Total    int     `json:"total"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"` // 0..1
+}
+
+func (a *AccuracyStats) add(correct bool) {
+	a.Total++
+	if correct {
+		a.Correct++
+	}
+	a.Accuracy = float64(a.Correct) / float64(a.Total)
+}
+
+type MonthlyAccuracy struct {
+	Month string `json:"month"`
+	AccuracyStats
+}
+
+type GroupAccuracy struct {
+	Name string `json:"name"`
+	AccuracyStats
+}
+
+type ConfusedCategoryPair struct {
+	Predicted string `json:"predicted"`
+	Final     string `json:"final"`
+	Count     int    `json:"count"`
+}
+
+type CategorizationAccuracyReport struct {
+	From           string                 `json:"from"`
+	To             string                 `json:"to"`
+	Overall        AccuracyStats          `json:"overall"`
+	ByMonth        []MonthlyAccuracy      `json:"by_month"`
+	BySource       []GroupAccuracy        `json:"by_source"`
+	ByModelVersion []GroupAccuracy        `json:"by_model_version"`
+	ConfusedPairs  []ConfusedCategoryPair `json:"confused_pairs"`
+}
+
+// GetAccuracyReport aggregates feedback recorded in [from, to) by confirmation month, prediction source
+// and model version, and lists the most frequent predicted -> final corrections.
+func (s *CategorizationFeedbackService) GetAccuracyReport(workspaceID uint, from, to time.Time, pairsLimit int) (*CategorizationAccuracyReport, error) {
+	if pairsLimit <= 0 {
+		pairsLimit = defaultConfusedPairsLimit
+	}
+
+	var rows []models.CategorizationFeedback
+	err := s.db.Where("workspace_id = ? AND created_at >= ? AND created_at < ?", workspaceID, from, to).
+		Order("created_at ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return buildAccuracyReport(rows, from, to, pairsLimit), nil
+}
+
+// buildAccuracyReport folds feedback rows into the report returned by GetAccuracyReport.
+func buildAccuracyReport(rows []models.CategorizationFeedback, from, to time.Time, pairsLimit int) *CategorizationAccuracyReport {
+	report := &CategorizationAccuracyReport{
+		From:           from.Format("2006-01-02"),
+		To:             to.Format("2006-01-02"),
+		ByMonth:        []MonthlyAccuracy{},
+		BySource:       []GroupAccuracy{},
+		ByModelVersion: []GroupAccuracy{},
+		ConfusedPairs:  []ConfusedCategoryPair{},
+	}
+
+	months := map[string]*MonthlyAccuracy{}
+	sources := map[string]*GroupAccuracy{}
+	versions := map[string]*GroupAccuracy{}
+	pairs := map[[2]string]int{}
+
+	for _, row := range rows {
+		report.Overall.add(row.Correct)
+
+		month := row.CreatedAt.Format("2006-01")
+		if _, exists := months[month]; !exists {
+			months[month] = &MonthlyAccuracy{Month: month}
+		}
+		months[month].add(row.Correct)
+
+		addGroupAccuracy(sources, row.Source, row.Correct)
+		addGroupAccuracy(versions, row.ModelVersion, row.Correct)
+
+		if !row.Correct {
+			pairs[[2]string{row.PredictedCategory, row.FinalCategory}]++
+		}
+	}
+
+	for _, m := range months {
+		report.ByMonth = append(report.ByMonth, *m)
+	}
+	sort.Slice(report.ByMonth, func(i, j int) bool {
+		return report.ByMonth[i].Month < report.ByMonth[j].Month
+	})
+
+	report.BySource = sortedGroupAccuracy(sources)
+	report.ByModelVersion = sortedGroupAccuracy(versions)
+
+	for pair, count := range pairs {
+		report.ConfusedPairs = append(report.ConfusedPairs, ConfusedCategoryPair{
+			Predicted: pair[0],
+			Final:     pair[1],
+			Count:     count,
+		})
+	}
+	sort.Slice(report.ConfusedPairs, func(i, j int) bool {
+		a, b := report.ConfusedPairs[i], report.ConfusedPairs[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Predicted != b.Predicted {
+			return a.Predicted < b.Predicted
+		}
+		return a.Final < b.Final
+	})
+	if len(report.ConfusedPairs) > pairsLimit {
+		report.ConfusedPairs = report.ConfusedPairs[:pairsLimit]
+	}
+
+	return report
+}
+
+func addGroupAccuracy(groups map[string]*GroupAccuracy, name string, correct bool) {
+	if name == "" {
+		name = "unknown"
+	}
+	if _, exists := groups[name]; !exists {
+		groups[name] = &GroupAccuracy{Name: name}
+	}
+	groups[name].add(correct)
+}
+
+func sortedGroupAccuracy(groups map[string]*GroupAccuracy) []GroupAccuracy {
+	out := make([]GroupAccuracy, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
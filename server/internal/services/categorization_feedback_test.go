@@ -0,0 +1,181 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"etl-banks-ar/internal/models"
+)
+
+func feedbackRow(created string, predicted, final, source, version string) models.CategorizationFeedback {
+	return models.CategorizationFeedback{
+		PredictedCategory: predicted,
+		FinalCategory:     final,
+		Source:            source,
+		ModelVersion:      version,
+		Correct:           predicted == final,
+		CreatedAt:         feedbackDay(created),
+	}
+}
+
+func TestBuildAccuracyReport(t *testing.T) {
+	rows := []models.CategorizationFeedback{
+		feedbackRow("2024-01-05", "Supermercado", "Supermercado", "local", "v1"),
+		feedbackRow("2024-01-10", "Supermercado", "Restaurantes", "local", "v1"),
+		feedbackRow("2024-01-20", "Servicios", "Servicios", "llm", "v2"),
+		feedbackRow("2024-02-03", "Supermercado", "Restaurantes", "llm", ""),
+		feedbackRow("2024-02-04", "Transporte", "Combustible", "", "v2"),
+		feedbackRow("2024-02-05", "Servicios", "Servicios", "llm", "v2"),
+	}
+
+	report := buildAccuracyReport(rows, feedbackDay("2024-01-01"), feedbackDay("2024-03-01"), defaultConfusedPairsLimit)
+
+	if report.From != "2024-01-01" || report.To != "2024-03-01" {
+		t.Fatalf("range = %s..%s", report.From, report.To)
+	}
+	if o := report.Overall; o.Total != 6 || o.Correct != 3 || o.Accuracy != 0.5 {
+		t.Fatalf("overall = %+v, want 3/6", o)
+	}
+
+	months := []struct {
+		month          string
+		total, correct int
+	}{
+		{"2024-01", 3, 2},
+		{"2024-02", 3, 1},
+	}
+	if len(report.ByMonth) != len(months) {
+		t.Fatalf("by_month = %+v", report.ByMonth)
+	}
+	for i, want := range months {
+		got := report.ByMonth[i]
+		if got.Month != want.month || got.Total != want.total || got.Correct != want.correct {
+			t.Errorf("by_month[%d] = %+v, want %s %d/%d", i, got, want.month, want.correct, want.total)
+		}
+		if math.Abs(got.Accuracy-float64(want.correct)/float64(want.total)) > 1e-9 {
+			t.Errorf("by_month[%d] accuracy = %v", i, got.Accuracy)
+		}
+	}
+
+	groups := []struct {
+		name   string
+		got    []GroupAccuracy
+		expect []GroupAccuracy
+	}{
+		{"by_source", report.BySource, []GroupAccuracy{
+			{Name: "llm", AccuracyStats: AccuracyStats{Total: 3, Correct: 2, Accuracy: 2.0 / 3}},
+			{Name: "local", AccuracyStats: AccuracyStats{Total: 2, Correct: 1, Accuracy: 0.5}},
+			{Name: "unknown", AccuracyStats: AccuracyStats{Total: 1, Correct: 0, Accuracy: 0}},
+		}},
+		{"by_model_version", report.ByModelVersion, []GroupAccuracy{
+			{Name: "unknown", AccuracyStats: AccuracyStats{Total: 1, Correct: 0, Accuracy: 0}},
+			{Name: "v1", AccuracyStats: AccuracyStats{Total: 2, Correct: 1, Accuracy: 0.5}},
+			{Name: "v2", AccuracyStats: AccuracyStats{Total: 3, Correct: 2, Accuracy: 2.0 / 3}},
+		}},
+	}
+	for _, g := range groups {
+		if len(g.got) != len(g.expect) {
+			t.Fatalf("%s = %+v", g.name, g.got)
+		}
+		for i := range g.expect {
+			if g.got[i] != g.expect[i] {
+				t.Errorf("%s[%d] = %+v, want %+v", g.name, i, g.got[i], g.expect[i])
+			}
+		}
+	}
+
+	wantPairs := []ConfusedCategoryPair{
+		{Predicted: "Supermercado", Final: "Restaurantes", Count: 2},
+		{Predicted: "Transporte", Final: "Combustible", Count: 1},
+	}
+	if len(report.ConfusedPairs) != len(wantPairs) {
+		t.Fatalf("confused_pairs = %+v", report.ConfusedPairs)
+	}
+	for i, want := range wantPairs {
+		if report.ConfusedPairs[i] != want {
+			t.Errorf("confused_pairs[%d] = %+v, want %+v", i, report.ConfusedPairs[i], want)
+		}
+	}
+}
+
+func TestBuildAccuracyReportConfusedPairs(t *testing.T) {
+	rows := []models.CategorizationFeedback{
+		feedbackRow("2024-01-01", "B", "X", "local", "v1"),
+		feedbackRow("2024-01-02", "A", "Y", "local", "v1"),
+		feedbackRow("2024-01-03", "A", "X", "local", "v1"),
+		feedbackRow("2024-01-04", "C", "Z", "local", "v1"),
+		feedbackRow("2024-01-05", "C", "Z", "local", "v1"),
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []ConfusedCategoryPair
+	}{
+		{"count desc then predicted then final", 10, []ConfusedCategoryPair{
+			{Predicted: "C", Final: "Z", Count: 2},
+			{Predicted: "A", Final: "X", Count: 1},
+			{Predicted: "A", Final: "Y", Count: 1},
+			{Predicted: "B", Final: "X", Count: 1},
+		}},
+		{"truncated to limit", 2, []ConfusedCategoryPair{
+			{Predicted: "C", Final: "Z", Count: 2},
+			{Predicted: "A", Final: "X", Count: 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildAccuracyReport(rows, feedbackDay("2024-01-01"), feedbackDay("2024-02-01"), tt.limit).ConfusedPairs
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("pair %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildAccuracyReportEmpty(t *testing.T) {
+	report := buildAccuracyReport(nil, feedbackDay("2024-01-01"), feedbackDay("2024-02-01"), 10)
+	if report.Overall.Total != 0 || report.Overall.Accuracy != 0 {
+		t.Fatalf("overall = %+v, want zero", report.Overall)
+	}
+	if report.ByMonth == nil || report.BySource == nil || report.ByModelVersion == nil || report.ConfusedPairs == nil {
+		t.Fatal("empty report should serialise lists as [] rather than null")
+	}
+}
+
+func TestBuildCategorizationFeedback(t *testing.T) {
+	inputs := []ConfirmTransactionInput{
+		{Category: "Supermercado", PredictedCategory: "Supermercado", PredictionSource: "local", PredictionConfidence: 0.9, ModelVersion: "v1"},
+		{Category: "Varios"}, // added by hand in the preview
+		{Category: " Restaurantes ", PredictedCategory: " Supermercado ", PredictionSource: "llm", ModelVersion: "v2"},
+		{Category: "Servicios", PredictedCategory: "   "},
+		{Category: "Servicios", PredictedCategory: "Servicios"}, // no created row to pair with
+	}
+	created := []models.Transaction{{ID: 11}, {ID: 12}, {ID: 13}, {ID: 14}}
+
+	feedback := buildCategorizationFeedback(7, inputs, created)
+
+	want := []models.CategorizationFeedback{
+		{WorkspaceID: 7, TransactionID: 11, PredictedCategory: "Supermercado", FinalCategory: "Supermercado", Source: "local", Confidence: 0.9, ModelVersion: "v1", Correct: true},
+		{WorkspaceID: 7, TransactionID: 13, PredictedCategory: "Supermercado", FinalCategory: "Restaurantes", Source: "llm", ModelVersion: "v2", Correct: false},
+	}
+	if len(feedback) != len(want) {
+		t.Fatalf("got %d feedback rows, want %d: %+v", len(feedback), len(want), feedback)
+	}
+	for i := range want {
+		if feedback[i] != want[i] {
+			t.Errorf("feedback[%d] = %+v, want %+v", i, feedback[i], want[i])
+		}
+	}
+}
+
+func feedbackDay(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
@@ -1,6 +1,10 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"etl-banks-ar/internal/models"
 
 	"gorm.io/gorm"
@@ -52,6 +56,36 @@ func (s *CategoryService) UpdatePartial(categoryID uint, workspaceID uint, updat
 		Error
 }
 
+// ValidateParent checks that categoryID (0 for a new category) may be nested under parentID: the parent must
+// belong to the workspace, the move must not create a cycle, and the result must respect MaxCategoryDepth.
+// The Missing category stays top-level and cannot have subcategories, and a category targeted by rules cannot
+// become a parent, since rules only fire on leaf categories.
+func (s *CategoryService) ValidateParent(workspaceID, categoryID, parentID uint) error {
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return err
+	}
+	if parent, ok := tree.byID[parentID]; ok && parent.Name == models.MissingCategoryName {
+		return ErrMissingCategoryNesting
+	}
+	if category, ok := tree.byID[categoryID]; ok && category.Name == models.MissingCategoryName {
+		return ErrMissingCategoryNesting
+	}
+	if err := tree.validateParent(categoryID, parentID); err != nil {
+		return err
+	}
+	var rules int64
+	if err := s.db.Model(&models.CategoryRule{}).
+		Where("workspace_id = ? AND category_id = ?", workspaceID, parentID).
+		Count(&rules).Error; err != nil {
+		return err
+	}
+	if rules > 0 {
+		return ErrCategoryParentHasRules
+	}
+	return nil
+}
+
 // AreaBelongs reports whether areaID exists within the workspace.
 func (s *CategoryService) AreaBelongs(workspaceID uint, areaID uint) (bool, error) {
 	var count int64
@@ -62,8 +96,144 @@ func (s *CategoryService) AreaBelongs(workspaceID uint, areaID uint) (bool, erro
 	return count > 0, err
 }
 
-func (s *CategoryService) Delete(id, workspaceID uint) error {
-	return s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.Category{}).Error
+var (
+	ErrCategoryNotFound         = errors.New("category not found")
+	ErrMissingCategoryProtected = errors.New("the Missing category cannot be deleted or merged away")
+	ErrCategoryMergeSelf        = errors.New("a category cannot be merged into itself")
+	ErrMissingCategoryNesting   = errors.New("the Missing category cannot have a parent or subcategories")
+	ErrCategoryParentHasRules   = errors.New("rules target the parent category; move them to a subcategory first")
+)
+
+// CategoryReassignment reports how many rows were moved from the source category to the target when a
+// category is deleted or merged.
+type CategoryReassignment struct {
+	SourceID          uint   `json:"source_id"`
+	TargetID          uint   `json:"target_id"`
+	TargetName        string `json:"target_name"`
+	Transactions      int64  `json:"transactions"`
+	Allocations       int64  `json:"allocations"`
+	RecurringExpenses int64  `json:"recurring_expenses"`
+	RulesMoved        int64  `json:"rules_moved"`
+	RulesDeleted      int64  `json:"rules_deleted"`
+}
+
+// Delete removes a category after moving its transactions and recurring expenses to reassignTo, or to the
+// workspace's Missing category when reassignTo is nil. Rules are deleted when the target is Missing, since
+// a rule that assigns Missing would only create review work.
+func (s *CategoryService) Delete(id, workspaceID uint, reassignTo *uint) (*CategoryReassignment, error) {
+	if reassignTo == nil {
+		if err := s.EnsureMissingCategory(workspaceID); err != nil {
+			return nil, err
+		}
+		missing, err := s.FindByName(models.MissingCategoryName, workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		reassignTo = &missing.ID
+	}
+	return s.fold(id, *reassignTo, workspaceID)
+}
+
+// Merge folds category sourceID into targetID: every transaction, allocation, recurring expense and rule of the source
+// moves to the target and the source is deleted, all in one DB transaction.
+func (s *CategoryService) Merge(sourceID, targetID, workspaceID uint) (*CategoryReassignment, error) {
+	return s.fold(sourceID, targetID, workspaceID)
+}
+
+func (s *CategoryService) fold(sourceID, targetID, workspaceID uint) (*CategoryReassignment, error) {
+	if sourceID == targetID {
+		return nil, ErrCategoryMergeSelf
+	}
+	result := &CategoryReassignment{SourceID: sourceID, TargetID: targetID}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var source, target models.Category
+		if err := tx.Where("id = ? AND workspace_id = ?", sourceID, workspaceID).First(&source).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+		if source.Name == models.MissingCategoryName {
+			return ErrMissingCategoryProtected
+		}
+		if err := tx.Where("id = ? AND workspace_id = ?", targetID, workspaceID).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: target %d", ErrCategoryNotFound, targetID)
+			}
+			return err
+		}
+		result.TargetName = target.Name
+
+		moved := tx.Model(&models.Transaction{}).
+			Where("workspace_id = ? AND category_id = ?", workspaceID, sourceID).
+			Update("category_id", targetID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.Transactions = moved.RowsAffected
+
+		moved = tx.Model(&models.TransactionAllocation{}).
+			Where("workspace_id = ? AND category_id = ?", workspaceID, sourceID).
+			Update("category_id", targetID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.Allocations = moved.RowsAffected
+
+		moved = tx.Model(&models.RecurringExpense{}).
+			Where("workspace_id = ? AND category_id = ?", workspaceID, sourceID).
+			Update("category_id", targetID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.RecurringExpenses = moved.RowsAffected
+
+		rules := tx.Where("workspace_id = ? AND category_id = ?", workspaceID, sourceID)
+		if target.Name == models.MissingCategoryName {
+			deleted := rules.Delete(&models.CategoryRule{})
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			result.RulesDeleted = deleted.RowsAffected
+		} else {
+			moved = rules.Model(&models.CategoryRule{}).Update("category_id", targetID)
+			if moved.Error != nil {
+				return moved.Error
+			}
+			result.RulesMoved = moved.RowsAffected
+		}
+
+		// Subcategories move up one level rather than under the target, which could exceed MaxCategoryDepth.
+		if err := tx.Model(&models.Category{}).
+			Where("workspace_id = ? AND parent_id = ?", workspaceID, sourceID).
+			Update("parent_id", source.ParentID).Error; err != nil {
+			return err
+		}
+
+		// Keep pending recategorization previews applicable after the source row is gone, including changes
+		// proposed by name before the source category existed.
+		pending := tx.Model(&models.RecategorizationChange{}).
+			Where("applied = ? AND skipped = ?", false, false).
+			Where("job_id IN (?)", tx.Model(&models.RecategorizationJob{}).Select("id").Where("workspace_id = ?", workspaceID)).
+			Session(&gorm.Session{})
+		if err := pending.
+			Where("new_category_id = ? OR (new_category_id IS NULL AND new_category = ?)", sourceID, source.Name).
+			Updates(map[string]interface{}{"new_category_id": targetID, "new_category": target.Name}).Error; err != nil {
+			return err
+		}
+		if err := pending.
+			Where("old_category_id = ?", sourceID).
+			Updates(map[string]interface{}{"old_category_id": targetID, "old_category": target.Name}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 func (s *CategoryService) ListByArea(areaID, workspaceID uint) ([]models.Category, error) {
@@ -103,3 +273,118 @@ func (s *CategoryService) BackfillMissingCategories() error {
 	}
 	return nil
 }
+
+// BackfillTransactionCategoryIDs points transactions imported before category_id existed at the category
+// row matching their legacy free-text category, creating rows for names that were never registered. The
+// legacy column is then renamed to category_legacy rather than dropped, so the text stays around to check
+// the mapping against; dropping it is left to a manual migration. It runs once: later category edits and
+// deletes are never undone by a restart.
+func (s *CategoryService) BackfillTransactionCategoryIDs() error {
+	migrator := s.db.Migrator()
+	if !migrator.HasColumn(&models.Transaction{}, "category") {
+		return nil
+	}
+
+	var legacy []struct {
+		WorkspaceID uint
+		Category    string
+	}
+	err := s.db.Table("transactions").
+		Select("DISTINCT workspace_id, category").
+		Where("category_id IS NULL AND category IS NOT NULL AND category != ''").
+		Scan(&legacy).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range legacy {
+		name := strings.TrimSpace(row.Category)
+		if name == "" {
+			continue
+		}
+		category := models.Category{WorkspaceID: row.WorkspaceID, Name: name}
+		if err := s.db.Where("workspace_id = ? AND name = ?", row.WorkspaceID, name).FirstOrCreate(&category).Error; err != nil {
+			return err
+		}
+	}
+
+	err = s.db.Exec(`
+		UPDATE transactions
+		SET category_id = (
+			SELECT c.id FROM categories c
+			WHERE c.workspace_id = transactions.workspace_id AND c.name = TRIM(transactions.category)
+		)
+		WHERE category_id IS NULL AND category IS NOT NULL AND category != ''`).Error
+	if err != nil {
+		return err
+	}
+
+	return migrator.RenameColumn(&models.Transaction{}, "category", "category_legacy")
+}
+
+// resolveCategoryID returns the id of the workspace category called name, or nil for an empty name. Names
+// of no category are rejected with ErrCategoryNotFound, so a typo never creates a category.
+func resolveCategoryID(db *gorm.DB, workspaceID uint, name string) (*uint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	var ids []uint
+	if err := db.Model(&models.Category{}).
+		Where("workspace_id = ? AND name = ?", workspaceID, name).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryNotFound, name)
+	}
+	return &ids[0], nil
+}
+
+// categoryResolver caches name lookups while resolving many rows of one workspace.
+type categoryResolver struct {
+	db          *gorm.DB
+	workspaceID uint
+	ids         map[string]*uint
+}
+
+func newCategoryResolver(db *gorm.DB, workspaceID uint) *categoryResolver {
+	return &categoryResolver{db: db, workspaceID: workspaceID, ids: map[string]*uint{}}
+}
+
+func (r *categoryResolver) resolve(name string) (*uint, error) {
+	key := strings.TrimSpace(name)
+	if id, ok := r.ids[key]; ok {
+		return id, nil
+	}
+	id, err := resolveCategoryID(r.db, r.workspaceID, key)
+	if err != nil {
+		return nil, err
+	}
+	r.ids[key] = id
+	return id, nil
+}
+
+// syncCategoryID sets t.CategoryID from the name in t.Category. CategoryRef is cleared so a stale preloaded
+// association cannot overwrite the new foreign key on save.
+func syncCategoryID(db *gorm.DB, t *models.Transaction) error {
+	id, err := resolveCategoryID(db, t.WorkspaceID, t.Category.String)
+	if err != nil {
+		return err
+	}
+	if !t.Category.Valid {
+		id = nil
+	}
+	t.CategoryID = id
+	t.CategoryRef = nil
+	return nil
+}
+
+// SyncCategoryID is syncCategoryID for writers outside the services package, such as the embedding controller.
+func SyncCategoryID(db *gorm.DB, t *models.Transaction) error {
+	return syncCategoryID(db, t)
+}
+
+// categoryIDsByName is a subquery selecting the ids of the named workspace categories.
+func categoryIDsByName(db *gorm.DB, workspaceID uint, names []string) *gorm.DB {
+	return db.Model(&models.Category{}).Select("id").Where("workspace_id = ? AND name IN ?", workspaceID, names)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"etl-banks-ar/internal/categorizer"
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRuleCategoryNotFound = errors.New("rule category not found in this workspace")
+	ErrRuleCategoryNotLeaf  = errors.New("rules must target a category without subcategories")
+)
+
+type CategoryRuleService struct {
+	db *gorm.DB
+}
+
+func NewCategoryRuleService(db *gorm.DB) *CategoryRuleService {
+	return &CategoryRuleService{db: db}
+}
+
+func (s *CategoryRuleService) List(workspaceID uint) ([]models.CategoryRule, error) {
+	var rules []models.CategoryRule
+	err := s.db.Preload("CategoryRef").Where("workspace_id = ?", workspaceID).Order("priority ASC, id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (s *CategoryRuleService) FindByID(id, workspaceID uint) (*models.CategoryRule, error) {
+	var rule models.CategoryRule
+	err := s.db.Preload("CategoryRef").Where("id = ? AND workspace_id = ?", id, workspaceID).First(&rule).Error
+	return &rule, err
+}
+
+func (s *CategoryRuleService) Create(rule *models.CategoryRule) error {
+	if err := s.validate(rule); err != nil {
+		return err
+	}
+	return s.db.Create(rule).Error
+}
+
+func (s *CategoryRuleService) Update(rule *models.CategoryRule) error {
+	if err := s.validate(rule); err != nil {
+		return err
+	}
+	return s.db.Save(rule).Error
+}
+
+func (s *CategoryRuleService) Delete(id, workspaceID uint) error {
+	return s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.CategoryRule{}).Error
+}
+
+// Rules returns the workspace rules in evaluation order, ready for categorizer.RuleCategorizer.
+func (s *CategoryRuleService) Rules(workspaceID uint) ([]categorizer.Rule, error) {
+	rows, err := s.List(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]categorizer.Rule, 0, len(rows))
+	for _, r := range rows {
+		rules = append(rules, categorizer.Rule{Pattern: r.Pattern, Type: r.Type, Category: r.Category})
+	}
+	return rules, nil
+}
+
+func (s *CategoryRuleService) validate(rule *models.CategoryRule) error {
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	rule.Category = strings.TrimSpace(rule.Category)
+	if rule.Pattern == "" {
+		return errors.New("pattern is required")
+	}
+	if rule.Type != "" && rule.Type != "debit" && rule.Type != "credit" {
+		return errors.New("type must be debit or credit")
+	}
+	var category models.Category
+	err := s.db.Where("workspace_id = ? AND name = ?", rule.WorkspaceID, rule.Category).First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRuleCategoryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// Predictions only ever name leaf categories, so a rule on a parent would never fire.
+	var children int64
+	if err := s.db.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children).Error; err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrRuleCategoryNotLeaf
+	}
+	rule.CategoryID = category.ID
+	rule.CategoryRef = nil
+	return nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxCategoryDepth is the deepest a category may sit in the taxonomy; top-level categories have depth 1.
+const MaxCategoryDepth = 3
+
+// CategoryLevelLeaf asks summaries to report categories as assigned, without rolling up to parents.
+const CategoryLevelLeaf = 0
+
+var (
+	ErrCategoryParentNotFound = errors.New("parent category not found in this workspace")
+	ErrCategoryCycle          = errors.New("a category cannot be nested under itself or one of its subcategories")
+	ErrCategoryTooDeep        = fmt.Errorf("categories can be nested at most %d levels deep", MaxCategoryDepth)
+)
+
+// ParseCategoryLevel reads the level query parameter of summary endpoints: empty means top-level (1),
+// "leaf" means no roll-up, and a number N groups at depth N.
+func ParseCategoryLevel(raw string) (int, error) {
+	switch raw {
+	case "":
+		return 1, nil
+	case "leaf":
+		return CategoryLevelLeaf, nil
+	}
+	level, err := strconv.Atoi(raw)
+	if err != nil || level < 1 || level > MaxCategoryDepth {
+		return 0, fmt.Errorf("level must be \"leaf\" or a number between 1 and %d", MaxCategoryDepth)
+	}
+	return level, nil
+}
+
+// categoryTree is an in-memory view of a workspace's category hierarchy.
+type categoryTree struct {
+	byID     map[uint]models.Category
+	children map[uint][]uint
+}
+
+func loadCategoryTree(db *gorm.DB, workspaceID uint) (*categoryTree, error) {
+	var categories []models.Category
+	if err := db.Where("workspace_id = ?", workspaceID).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return newCategoryTree(categories), nil
+}
+
+func newCategoryTree(categories []models.Category) *categoryTree {
+	tree := &categoryTree{
+		byID:     make(map[uint]models.Category, len(categories)),
+		children: map[uint][]uint{},
+	}
+	for _, c := range categories {
+		tree.byID[c.ID] = c
+		if c.ParentID != nil {
+			tree.children[*c.ParentID] = append(tree.children[*c.ParentID], c.ID)
+		}
+	}
+	return tree
+}
+
+// path returns the ancestors of id from the root down to id itself. Broken or cyclic parent links end the walk.
+func (t *categoryTree) path(id uint) []models.Category {
+	var reversed []models.Category
+	seen := map[uint]bool{}
+	for {
+		c, ok := t.byID[id]
+		if !ok || seen[id] {
+			break
+		}
+		seen[id] = true
+		reversed = append(reversed, c)
+		if c.ParentID == nil {
+			break
+		}
+		id = *c.ParentID
+	}
+	path := make([]models.Category, len(reversed))
+	for i, c := range reversed {
+		path[len(reversed)-1-i] = c
+	}
+	return path
+}
+
+func (t *categoryTree) depth(id uint) int {
+	return len(t.path(id))
+}
+
+// height is the number of levels in the subtree rooted at id, counting id itself.
+func (t *categoryTree) height(id uint) int {
+	h := 0
+	for _, child := range t.children[id] {
+		if ch := t.height(child); ch > h {
+			h = ch
+		}
+	}
+	return h + 1
+}
+
+func (t *categoryTree) isLeaf(id uint) bool {
+	return len(t.children[id]) == 0
+}
+
+// group returns the category that id rolls up to at level; shallower categories report themselves.
+func (t *categoryTree) group(id uint, level int) (models.Category, bool) {
+	path := t.path(id)
+	if len(path) == 0 {
+		return models.Category{}, false
+	}
+	if level == CategoryLevelLeaf || level >= len(path) {
+		return path[len(path)-1], true
+	}
+	return path[level-1], true
+}
+
+// areaID is the category's own area, or the nearest ancestor's when the category has none.
+func (t *categoryTree) areaID(id uint) *uint {
+	path := t.path(id)
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].AreaID != nil {
+			return path[i].AreaID
+		}
+	}
+	return nil
+}
+
+// validateParent checks that categoryID (0 for a new category) can be placed under parentID.
+func (t *categoryTree) validateParent(categoryID, parentID uint) error {
+	if _, ok := t.byID[parentID]; !ok {
+		return ErrCategoryParentNotFound
+	}
+	height := 1
+	if categoryID != 0 {
+		for _, ancestor := range t.path(parentID) {
+			if ancestor.ID == categoryID {
+				return ErrCategoryCycle
+			}
+		}
+		height = t.height(categoryID)
+	}
+	if t.depth(parentID)+height > MaxCategoryDepth {
+		return ErrCategoryTooDeep
+	}
+	return nil
+}
+
+// leafNames returns the names of categories without subcategories, which are the ones predictions target.
+func leafNames(categories []models.Category) []string {
+	tree := newCategoryTree(categories)
+	names := make([]string, 0, len(categories))
+	for _, c := range categories {
+		if tree.isLeaf(c.ID) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
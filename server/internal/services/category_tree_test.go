@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+// Hogar(1, area 10) > Servicios(2) > Luz(3); Hogar > Alquiler(4, area 11); Ocio(5); Missing(6).
+func sampleCategoryTree() *categoryTree {
+	return newCategoryTree([]models.Category{
+		{ID: 1, Name: "Hogar", AreaID: uintPtr(10)},
+		{ID: 2, Name: "Servicios", ParentID: uintPtr(1)},
+		{ID: 3, Name: "Luz", ParentID: uintPtr(2)},
+		{ID: 4, Name: "Alquiler", ParentID: uintPtr(1), AreaID: uintPtr(11)},
+		{ID: 5, Name: "Ocio"},
+		{ID: 6, Name: models.MissingCategoryName},
+	})
+}
+
+func categoryIDs(categories []models.Category) []uint {
+	ids := make([]uint, len(categories))
+	for i, c := range categories {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func TestParseCategoryLevel(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{"", 1, false},
+		{"leaf", CategoryLevelLeaf, false},
+		{"2", 2, false},
+		{"3", 3, false},
+		{"0", 0, true},
+		{"4", 0, true},
+		{"top", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCategoryLevel(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCategoryLevel(%q) = %d, %v; want %d, err=%v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCategoryTreePathAndDepth(t *testing.T) {
+	tree := sampleCategoryTree()
+
+	tests := []struct {
+		id     uint
+		path   []uint
+		height int
+	}{
+		{3, []uint{1, 2, 3}, 1},
+		{2, []uint{1, 2}, 2},
+		{1, []uint{1}, 3},
+		{5, []uint{5}, 1},
+		{99, []uint{}, 1},
+	}
+	for _, tt := range tests {
+		if got := categoryIDs(tree.path(tt.id)); !reflect.DeepEqual(got, tt.path) {
+			t.Errorf("path(%d) = %v, want %v", tt.id, got, tt.path)
+		}
+		if got := tree.depth(tt.id); got != len(tt.path) {
+			t.Errorf("depth(%d) = %d, want %d", tt.id, got, len(tt.path))
+		}
+		if got := tree.height(tt.id); got != tt.height {
+			t.Errorf("height(%d) = %d, want %d", tt.id, got, tt.height)
+		}
+	}
+}
+
+func TestCategoryTreePathStopsOnCycle(t *testing.T) {
+	tree := newCategoryTree([]models.Category{
+		{ID: 1, Name: "A", ParentID: uintPtr(2)},
+		{ID: 2, Name: "B", ParentID: uintPtr(1)},
+	})
+	if got := categoryIDs(tree.path(1)); !reflect.DeepEqual(got, []uint{2, 1}) {
+		t.Fatalf("path = %v, want the walk to stop at the cycle", got)
+	}
+}
+
+func TestCategoryTreeGroup(t *testing.T) {
+	tree := sampleCategoryTree()
+
+	tests := []struct {
+		id    uint
+		level int
+		want  uint
+		found bool
+	}{
+		{3, 1, 1, true},
+		{3, 2, 2, true},
+		{3, 3, 3, true},
+		{3, CategoryLevelLeaf, 3, true},
+		{4, 3, 4, true}, // shallower than the level reports itself
+		{5, 2, 5, true},
+		{99, 1, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := tree.group(tt.id, tt.level)
+		if ok != tt.found || got.ID != tt.want {
+			t.Errorf("group(%d, %d) = %d, %v; want %d, %v", tt.id, tt.level, got.ID, ok, tt.want, tt.found)
+		}
+	}
+}
+
+func TestCategoryTreeAreaID(t *testing.T) {
+	tree := sampleCategoryTree()
+
+	tests := []struct {
+		id   uint
+		want *uint
+	}{
+		{3, uintPtr(10)}, // inherited from Hogar through Servicios
+		{4, uintPtr(11)}, // own area wins over the parent's
+		{5, nil},
+	}
+	for _, tt := range tests {
+		got := tree.areaID(tt.id)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("areaID(%d) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryTreeValidateParent(t *testing.T) {
+	tree := sampleCategoryTree()
+
+	tests := []struct {
+		name       string
+		categoryID uint
+		parentID   uint
+		want       error
+	}{
+		{"new category under a level-2 parent", 0, 2, nil},
+		{"new category below the max depth", 0, 3, ErrCategoryTooDeep},
+		{"unknown parent", 0, 99, ErrCategoryParentNotFound},
+		{"under itself", 2, 2, ErrCategoryCycle},
+		{"under its own descendant", 1, 3, ErrCategoryCycle},
+		{"subtree fits under another root", 2, 5, nil},
+		{"subtree would get too deep", 1, 5, ErrCategoryTooDeep},
+		{"leaf moved under another root", 4, 5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tree.validateParent(tt.categoryID, tt.parentID); !errors.Is(err, tt.want) {
+				t.Fatalf("validateParent(%d, %d) = %v, want %v", tt.categoryID, tt.parentID, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeafNames(t *testing.T) {
+	got := leafNames([]models.Category{
+		{ID: 1, Name: "Hogar"},
+		{ID: 2, Name: "Servicios", ParentID: uintPtr(1)},
+		{ID: 3, Name: "Luz", ParentID: uintPtr(2)},
+		{ID: 4, Name: "Alquiler", ParentID: uintPtr(1)},
+		{ID: 5, Name: "Ocio"},
+	})
+	sort.Strings(got)
+	if want := []string{"Alquiler", "Luz", "Ocio"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("leafNames = %v, want %v", got, want)
+	}
+}
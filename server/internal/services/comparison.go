@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"etl-banks-ar/internal/models"
+)
+
+var ErrInvalidComparison = errors.New("invalid comparison")
+
+// Comparison statuses of a category or area present in only one of the periods.
+const (
+	ComparisonNew         = "new"         // spending only in the current period
+	ComparisonDisappeared = "disappeared" // spending only in the previous period
+)
+
+// DateRange is a half-open [From, To) period.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+type PeriodTotals struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"` // inclusive
+	Spending float64 `json:"spending"`
+	Income   float64 `json:"income"`
+	Net      float64 `json:"net"`
+}
+
+// ComparisonDelta is one figure in both periods. ChangePercentage is nil when the previous value is zero.
+type ComparisonDelta struct {
+	ID               *uint    `json:"id,omitempty"`
+	Name             string   `json:"name"`
+	Current          float64  `json:"current"`
+	Previous         float64  `json:"previous"`
+	Change           float64  `json:"change"`
+	ChangePercentage *float64 `json:"change_percentage"`
+	Status           string   `json:"status,omitempty"`
+}
+
+type PeriodComparison struct {
+	Current    PeriodTotals      `json:"current"`
+	Previous   PeriodTotals      `json:"previous"`
+	Spending   ComparisonDelta   `json:"spending"`
+	Income     ComparisonDelta   `json:"income"`
+	Net        ComparisonDelta   `json:"net"`
+	ByCategory []ComparisonDelta `json:"by_category"`
+	ByArea     []ComparisonDelta `json:"by_area"`
+	RealTerms  *RealTerms        `json:"real_terms,omitempty"`
+}
+
+// periodSpending is one period's totals with spending by category and by effective area.
+type periodSpending struct {
+	spending, income float64
+	byCategory       map[uint]float64 // 0 is uncategorized
+	byArea           map[uint]float64 // 0 is no area
+}
+
+// Compare sets current against previous. Categories are grouped at the given taxonomy level and, like areas,
+// compared on spending; a transaction's area overrides its category's. A non-nil deflator restates both
+// periods in real terms.
+func (s *TransactionService) Compare(workspaceID uint, current, previous DateRange, level int, deflator *Deflator) (*PeriodComparison, error) {
+	if !current.From.Before(current.To) || !previous.From.Before(previous.To) {
+		return nil, fmt.Errorf("%w: each period must start before it ends", ErrInvalidComparison)
+	}
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := s.periodSpending(workspaceID, current, level, tree, deflator)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.periodSpending(workspaceID, previous, level, tree, deflator)
+	if err != nil {
+		return nil, err
+	}
+
+	var areas []models.Area
+	if err := s.db.Where("workspace_id = ?", workspaceID).Find(&areas).Error; err != nil {
+		return nil, err
+	}
+	areaNames := map[uint]string{0: "Uncategorized"}
+	for _, a := range areas {
+		areaNames[a.ID] = a.Name
+	}
+	categoryNames := map[uint]string{0: "Uncategorized"}
+	for id, c := range tree.byID {
+		categoryNames[id] = c.Name
+	}
+
+	return &PeriodComparison{
+		Current:    periodTotals(current, cur),
+		Previous:   periodTotals(previous, prev),
+		Spending:   compareAmounts(nil, "spending", cur.spending, prev.spending),
+		Income:     compareAmounts(nil, "income", cur.income, prev.income),
+		Net:        compareAmounts(nil, "net", cur.income-cur.spending, prev.income-prev.spending),
+		ByCategory: compareGroups(cur.byCategory, prev.byCategory, categoryNames),
+		ByArea:     compareGroups(cur.byArea, prev.byArea, areaNames),
+		RealTerms:  deflator.RealTerms(),
+	}, nil
+}
+
+// periodLine is the ledger total of one day, type, category and area.
+type periodLine struct {
+	Date       time.Time
+	Type       string
+	CategoryID *uint
+	AreaID     *uint
+	Amount     float64
+}
+
+func (s *TransactionService) periodSpending(workspaceID uint, period DateRange, level int, tree *categoryTree, deflator *Deflator) (*periodSpending, error) {
+	var rows []periodLine
+	err := ledgerLines(s.db).
+		Select("date, type, category_id, area_id, COALESCE(SUM(amount), 0) as amount").
+		Where("workspace_id = ? AND date >= ? AND date < ?", workspaceID, period.From, period.To).
+		Group("date, type, category_id, area_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return foldPeriodSpending(rows, level, tree, deflator), nil
+}
+
+// foldPeriodSpending totals rows, restated by deflator, into spending and income, with spending grouped by
+// category at level and by effective area.
+func foldPeriodSpending(rows []periodLine, level int, tree *categoryTree, deflator *Deflator) *periodSpending {
+	result := &periodSpending{byCategory: map[uint]float64{}, byArea: map[uint]float64{}}
+	for _, row := range rows {
+		amount := deflator.Restate(row.Amount, row.Date)
+		if row.Type == "credit" {
+			result.income += amount
+			continue
+		}
+		if row.Type != "debit" {
+			continue
+		}
+		result.spending += amount
+
+		var categoryID, areaID uint
+		if row.CategoryID != nil {
+			if group, ok := tree.group(*row.CategoryID, level); ok {
+				categoryID = group.ID
+			}
+		}
+		if row.AreaID != nil {
+			areaID = *row.AreaID
+		} else if row.CategoryID != nil {
+			if id := tree.areaID(*row.CategoryID); id != nil {
+				areaID = *id
+			}
+		}
+		result.byCategory[categoryID] += amount
+		result.byArea[areaID] += amount
+	}
+	return result
+}
+
+func periodTotals(period DateRange, spending *periodSpending) PeriodTotals {
+	return PeriodTotals{
+		From:     period.From.Format("2006-01-02"),
+		To:       period.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		Spending: spending.spending,
+		Income:   spending.income,
+		Net:      spending.income - spending.spending,
+	}
+}
+
+func compareAmounts(id *uint, name string, current, previous float64) ComparisonDelta {
+	delta := ComparisonDelta{ID: id, Name: name, Current: current, Previous: previous, Change: current - previous}
+	if previous != 0 {
+		percentage := (current - previous) / previous * 100
+		delta.ChangePercentage = &percentage
+	}
+	return delta
+}
+
+// compareGroups pairs the groups of both periods, flagging those present in only one, largest change first.
+func compareGroups(current, previous map[uint]float64, names map[uint]string) []ComparisonDelta {
+	ids := map[uint]bool{}
+	for id := range current {
+		ids[id] = true
+	}
+	for id := range previous {
+		ids[id] = true
+	}
+
+	deltas := make([]ComparisonDelta, 0, len(ids))
+	for id := range ids {
+		var idRef *uint
+		if id != 0 {
+			idRef = new(uint)
+			*idRef = id
+		}
+		delta := compareAmounts(idRef, names[id], current[id], previous[id])
+		_, inCurrent := current[id]
+		_, inPrevious := previous[id]
+		switch {
+		case inCurrent && !inPrevious:
+			delta.Status = ComparisonNew
+		case inPrevious && !inCurrent:
+			delta.Status = ComparisonDisappeared
+		}
+		deltas = append(deltas, delta)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		ci, cj := math.Abs(deltas[i].Change), math.Abs(deltas[j].Change)
+		if ci != cj {
+			return ci > cj
+		}
+		return deltas[i].Name < deltas[j].Name
+	})
+	return deltas
+}
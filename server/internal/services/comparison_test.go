@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompareGroups(t *testing.T) {
+	names := map[uint]string{0: "Uncategorized", 1: "Food", 2: "Travel", 3: "Gym"}
+	current := map[uint]float64{1: 150, 2: 500}
+	previous := map[uint]float64{1: 100, 3: 40}
+
+	deltas := compareGroups(current, previous, names)
+	if len(deltas) != 3 {
+		t.Fatalf("got %d deltas, want 3", len(deltas))
+	}
+	byName := map[string]ComparisonDelta{}
+	for _, d := range deltas {
+		byName[d.Name] = d
+	}
+
+	if d := byName["Food"]; d.Change != 50 || d.ChangePercentage == nil || *d.ChangePercentage != 50 || d.Status != "" {
+		t.Fatalf("unexpected Food delta: %+v", d)
+	}
+	if d := byName["Travel"]; d.Status != ComparisonNew || d.ChangePercentage != nil {
+		t.Fatalf("Travel should be new with no percentage: %+v", d)
+	}
+	if d := byName["Gym"]; d.Status != ComparisonDisappeared || d.Change != -40 {
+		t.Fatalf("Gym should have disappeared: %+v", d)
+	}
+	if deltas[0].Name != "Travel" {
+		t.Fatalf("largest change should come first, got %s", deltas[0].Name)
+	}
+}
+
+func TestFoldPeriodSpending(t *testing.T) {
+	tree := sampleCategoryTree()
+	jan := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+	rows := []periodLine{
+		{Date: jan, Type: "debit", CategoryID: uintPtr(3), Amount: 100},                     // Luz, area 10 through Hogar
+		{Date: feb, Type: "debit", CategoryID: uintPtr(4), AreaID: uintPtr(12), Amount: 50}, // own area wins
+		{Date: feb, Type: "debit", Amount: 10},                                              // uncategorized
+		{Date: jan, Type: "credit", Amount: 1000},
+	}
+
+	nominal := foldPeriodSpending(rows, 1, tree, nil)
+	if nominal.spending != 160 || nominal.income != 1000 {
+		t.Fatalf("spending = %v, income = %v", nominal.spending, nominal.income)
+	}
+	if want := map[uint]float64{1: 150, 0: 10}; !reflect.DeepEqual(nominal.byCategory, want) {
+		t.Fatalf("by category = %v, want %v", nominal.byCategory, want)
+	}
+	if want := map[uint]float64{10: 100, 12: 50, 0: 10}; !reflect.DeepEqual(nominal.byArea, want) {
+		t.Fatalf("by area = %v, want %v", nominal.byArea, want)
+	}
+
+	deflator, err := newDeflator(map[string]float64{"2024-01": 100, "2024-02": 110}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restated := foldPeriodSpending(rows, 1, tree, deflator)
+	if math.Abs(restated.spending-170) > 1e-9 || math.Abs(restated.income-1100) > 1e-9 {
+		t.Fatalf("real spending = %v, income = %v", restated.spending, restated.income)
+	}
+	if math.Abs(restated.byArea[10]-110) > 1e-9 || math.Abs(restated.byCategory[1]-160) > 1e-9 {
+		t.Fatalf("real groups = %v / %v", restated.byCategory, restated.byArea)
+	}
+}
+
+func TestCompareRejectsEmptyPeriods(t *testing.T) {
+	service := &TransactionService{db: dryRunDB(t)}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := DateRange{From: day, To: day.AddDate(0, 1, 0)}
+
+	if _, err := service.Compare(1, DateRange{From: day, To: day}, valid, 1, nil); !errors.Is(err, ErrInvalidComparison) {
+		t.Fatalf("expected empty period to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCPI    = errors.New("invalid CPI series")
+	ErrNoCPISeries   = errors.New("no CPI series loaded for this workspace")
+	ErrNoCPIProvider = errors.New("no CPI provider configured")
+)
+
+// CPIPoint is one month of a CPI series before it is stored.
+type CPIPoint struct {
+	Month string  `json:"month"` // YYYY-MM
+	Value float64 `json:"value"`
+}
+
+// CPIProvider supplies a monthly CPI series from outside the workspace.
+type CPIProvider interface {
+	Name() string
+	Series() ([]CPIPoint, error)
+}
+
+// FileCPIProvider reads the series from a CSV file on disk, in the same format as a CSV import. It stands
+// in for the INDEC publication until the server can fetch it directly.
+type FileCPIProvider struct {
+	Path string
+}
+
+func NewFileCPIProvider(path string) *FileCPIProvider {
+	return &FileCPIProvider{Path: path}
+}
+
+func (p *FileCPIProvider) Name() string { return "file" }
+
+func (p *FileCPIProvider) Series() ([]CPIPoint, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseCPISeries(f)
+}
+
+// ParseCPISeries reads a two-column CSV of month and index value. Months may be YYYY-MM, YYYY-MM-DD or
+// MM/YYYY; values may use a decimal comma, as INDEC files do, in which case the delimiter is ';'. A
+// header row is skipped.
+func ParseCPISeries(r io.Reader) ([]CPIPoint, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimPrefix(string(raw), "\ufeff")
+	firstLine, _, _ := strings.Cut(content, "\n")
+
+	reader := csv.NewReader(strings.NewReader(content))
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCPI, err.Error())
+	}
+
+	seen := map[string]bool{}
+	points := []CPIPoint{}
+	for i, record := range records {
+		if len(record) < 2 || strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		month, ok := parseCPIMonth(record[0])
+		if !ok {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("%w: line %d: unrecognized month %q", ErrInvalidCPI, i+1, record[0])
+		}
+		value, err := parseCPIValue(record[1])
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("%w: line %d: value must be a positive number", ErrInvalidCPI, i+1)
+		}
+		if seen[month] {
+			return nil, fmt.Errorf("%w: line %d: %s appears twice", ErrInvalidCPI, i+1, month)
+		}
+		seen[month] = true
+		points = append(points, CPIPoint{Month: month, Value: value})
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%w: no rows", ErrInvalidCPI)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Month < points[j].Month })
+	return points, nil
+}
+
+func parseCPIMonth(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range []string{"2006-01", "2006-01-02", "01/2006", "1/2006"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.Format("2006-01"), true
+		}
+	}
+	return "", false
+}
+
+func parseCPIValue(raw string) (float64, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, ",") {
+		raw = strings.ReplaceAll(raw, ".", "")
+		raw = strings.ReplaceAll(raw, ",", ".")
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+type CPIService struct {
+	db       *gorm.DB
+	provider CPIProvider
+}
+
+// NewCPIService builds the service; provider may be nil when no provider is configured.
+func NewCPIService(db *gorm.DB, provider CPIProvider) *CPIService {
+	return &CPIService{db: db, provider: provider}
+}
+
+func (s *CPIService) List(workspaceID uint) ([]models.CPIValue, error) {
+	values := []models.CPIValue{}
+	err := s.db.Where("workspace_id = ?", workspaceID).Order("month ASC").Find(&values).Error
+	return values, err
+}
+
+// Import upserts points into the workspace series, tagging them with source, and returns how many were
+// stored.
+func (s *CPIService) Import(workspaceID uint, points []CPIPoint, source string) (int, error) {
+	values := make([]models.CPIValue, 0, len(points))
+	for _, p := range points {
+		if _, err := time.Parse("2006-01", p.Month); err != nil {
+			return 0, fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidCPI)
+		}
+		if p.Value <= 0 {
+			return 0, fmt.Errorf("%w: %s value must be positive", ErrInvalidCPI, p.Month)
+		}
+		values = append(values, models.CPIValue{WorkspaceID: workspaceID, Month: p.Month, Value: p.Value, Source: source})
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "month"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "source", "updated_at"}),
+	}).Create(&values).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(values), nil
+}
+
+// Sync imports the configured provider's series into the workspace.
+func (s *CPIService) Sync(workspaceID uint) (int, error) {
+	if s.provider == nil {
+		return 0, ErrNoCPIProvider
+	}
+	points, err := s.provider.Series()
+	if err != nil {
+		return 0, fmt.Errorf("%s provider: %w", s.provider.Name(), err)
+	}
+	return s.Import(workspaceID, points, s.provider.Name())
+}
+
+func (s *CPIService) Delete(workspaceID uint, month string) error {
+	return s.db.Where("workspace_id = ? AND month = ?", workspaceID, month).Delete(&models.CPIValue{}).Error
+}
+
+// Deflator loads the workspace series for restating amounts in pesos of baseMonth; an empty baseMonth
+// means the latest month in the series.
+func (s *CPIService) Deflator(workspaceID uint, baseMonth string) (*Deflator, error) {
+	values, err := s.List(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, ErrNoCPISeries
+	}
+	index := make(map[string]float64, len(values))
+	for _, v := range values {
+		index[v.Month] = v.Value
+	}
+	return newDeflator(index, baseMonth)
+}
+
+// RealTerms describes how a summary was restated. EstimatedMonths had no CPI value of their own and
+// borrowed the nearest earlier one (or the first one, before the series starts).
+type RealTerms struct {
+	BaseMonth       string   `json:"base_month"`
+	EstimatedMonths []string `json:"estimated_months,omitempty"`
+}
+
+// Deflator restates nominal amounts as constant pesos of a base month. A nil *Deflator leaves amounts
+// nominal, so summaries take one and callers pass nil for nominal figures.
+type Deflator struct {
+	baseMonth string
+	base      float64
+	months    []string // sorted
+	index     map[string]float64
+	estimated map[string]bool
+}
+
+func newDeflator(index map[string]float64, baseMonth string) (*Deflator, error) {
+	months := make([]string, 0, len(index))
+	for month := range index {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	if len(months) == 0 {
+		return nil, ErrNoCPISeries
+	}
+	if baseMonth == "" {
+		baseMonth = months[len(months)-1]
+	}
+	base, ok := index[baseMonth]
+	if !ok {
+		return nil, fmt.Errorf("%w: no CPI value for base month %s", ErrInvalidCPI, baseMonth)
+	}
+	return &Deflator{baseMonth: baseMonth, base: base, months: months, index: index, estimated: map[string]bool{}}, nil
+}
+
+// Factor is what a peso of month is worth in pesos of the base month.
+func (d *Deflator) Factor(month string) float64 {
+	if d == nil {
+		return 1
+	}
+	value, ok := d.index[month]
+	if !ok {
+		// The latest month is usually not published yet: carry the nearest earlier value forward.
+		i := sort.SearchStrings(d.months, month)
+		if i == 0 {
+			value = d.index[d.months[0]]
+		} else {
+			value = d.index[d.months[i-1]]
+		}
+		d.estimated[month] = true
+	}
+	return d.base / value
+}
+
+// Restate converts an amount dated date to pesos of the base month.
+func (d *Deflator) Restate(amount float64, date time.Time) float64 {
+	if d == nil {
+		return amount
+	}
+	return amount * d.Factor(date.Format("2006-01"))
+}
+
+// RealTerms reports the base month and the months restated with a borrowed CPI value; nil for nominal.
+func (d *Deflator) RealTerms() *RealTerms {
+	if d == nil {
+		return nil
+	}
+	terms := &RealTerms{BaseMonth: d.baseMonth}
+	for month := range d.estimated {
+		terms.EstimatedMonths = append(terms.EstimatedMonths, month)
+	}
+	sort.Strings(terms.EstimatedMonths)
+	return terms
+}
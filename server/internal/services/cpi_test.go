@@ -0,0 +1,54 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCPISeries(t *testing.T) {
+	csv := "Período;Nivel general\n2024-02;6.177,5\n01/2024;5.357,9\n"
+	points, err := ParseCPISeries(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[0].Month != "2024-01" || points[0].Value != 5357.9 || points[1].Value != 6177.5 {
+		t.Fatalf("unexpected points: %+v", points)
+	}
+
+	if _, err := ParseCPISeries(strings.NewReader("month,value\n2024-01,100\n2024-01,101\n")); err == nil {
+		t.Fatal("expected an error for a repeated month")
+	}
+	if _, err := ParseCPISeries(strings.NewReader("month,value\n2024-01,100\nfoo,101\n")); err == nil {
+		t.Fatal("expected an error for a bad month after the header")
+	}
+}
+
+func TestDeflatorRestate(t *testing.T) {
+	d, err := newDeflator(map[string]float64{"2024-01": 100, "2024-02": 110, "2024-03": 121}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if got := d.Restate(1000, jan); math.Abs(got-1210) > 1e-9 {
+		t.Fatalf("January restated in March pesos = %v, want 1210", got)
+	}
+	// April is not published yet and borrows March.
+	apr := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	if got := d.Restate(1000, apr); got != 1000 {
+		t.Fatalf("April restated = %v, want 1000", got)
+	}
+	if terms := d.RealTerms(); terms.BaseMonth != "2024-03" || len(terms.EstimatedMonths) != 1 || terms.EstimatedMonths[0] != "2024-04" {
+		t.Fatalf("unexpected real terms: %+v", terms)
+	}
+
+	var nominal *Deflator
+	if got := nominal.Restate(1000, jan); got != 1000 || nominal.RealTerms() != nil {
+		t.Fatal("a nil deflator must leave amounts nominal")
+	}
+	if _, err := newDeflator(map[string]float64{"2024-01": 100}, "2023-12"); err == nil {
+		t.Fatal("expected an error for a base month outside the series")
+	}
+}
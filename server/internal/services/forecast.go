@@ -0,0 +1,577 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of scheduled forecast items.
+const (
+	ForecastRecurring   = "recurring"   // a recurring expense falling due
+	ForecastIncome      = "income"      // a credit seen every month, such as a salary
+	ForecastInstallment = "installment" // a remaining installment of a card purchase
+)
+
+const (
+	DefaultForecastDays          = 30
+	MaxForecastDays              = 365
+	DefaultForecastHistoryMonths = 3
+	MaxForecastHistoryMonths     = 24
+)
+
+var ErrInvalidForecast = errors.New("invalid forecast")
+
+// installmentPattern matches the installment counter card statements print on a purchase paid in parts,
+// as in "C.03/12" or "CUOTA 3/6".
+var installmentPattern = regexp.MustCompile(`(?i)\b(?:c|cuotas?)\.?\s*(\d{1,2})\s*/\s*(\d{1,2})\b`)
+
+// ForecastQuery projects Days days after today from HistoryMonths months of history.
+type ForecastQuery struct {
+	Days          int
+	HistoryMonths int
+}
+
+func (q *ForecastQuery) normalize() error {
+	if q.Days == 0 {
+		q.Days = DefaultForecastDays
+	}
+	if q.HistoryMonths == 0 {
+		q.HistoryMonths = DefaultForecastHistoryMonths
+	}
+	if q.Days < 1 || q.Days > MaxForecastDays {
+		return fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidForecast, MaxForecastDays)
+	}
+	if q.HistoryMonths < 1 || q.HistoryMonths > MaxForecastHistoryMonths {
+		return fmt.Errorf("%w: history_months must be between 1 and %d", ErrInvalidForecast, MaxForecastHistoryMonths)
+	}
+	return nil
+}
+
+// ForecastItem is one scheduled flow. Amount is positive for money coming in.
+type ForecastItem struct {
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+type ForecastDay struct {
+	Date      string         `json:"date"`
+	Scheduled float64        `json:"scheduled"` // net of Items
+	Baseline  float64        `json:"baseline"`  // expected variable spending, zero or negative
+	Balance   float64        `json:"balance"`   // at the end of the day
+	Negative  bool           `json:"negative"`
+	Items     []ForecastItem `json:"items,omitempty"`
+}
+
+// AccountForecast is the projected daily balance of one account. Cards are never flagged negative, since
+// their balance is negative whenever something is owed. The line without AccountID collects flows not
+// assigned to any account; it starts at zero and is not flagged either.
+type AccountForecast struct {
+	AccountID         *uint         `json:"account_id"`
+	Name              string        `json:"name"`
+	Type              string        `json:"type,omitempty"`
+	Currency          string        `json:"currency,omitempty"`
+	StartingBalance   float64       `json:"starting_balance"`
+	DailyBaseline     float64       `json:"daily_baseline"`
+	EndingBalance     float64       `json:"ending_balance"`
+	LowestBalance     float64       `json:"lowest_balance"`
+	LowestDate        string        `json:"lowest_date"`
+	FirstNegativeDate *string       `json:"first_negative_date"`
+	NegativeDays      int           `json:"negative_days"`
+	Days              []ForecastDay `json:"days"`
+}
+
+type CashFlowForecast struct {
+	AsOf        string            `json:"as_of"` // starting balances are at the end of this day
+	From        string            `json:"from"`
+	To          string            `json:"to"` // inclusive
+	HistoryFrom string            `json:"history_from"`
+	Accounts    []AccountForecast `json:"accounts"`
+}
+
+type ForecastService struct {
+	db             *gorm.DB
+	accountService *AccountService
+}
+
+func NewForecastService(db *gorm.DB, accountService *AccountService) *ForecastService {
+	return &ForecastService{db: db, accountService: accountService}
+}
+
+// forecastFlow is a past transaction as the forecast reads it.
+type forecastFlow struct {
+	AccountID   *uint
+	CategoryID  *uint
+	Date        time.Time
+	Type        sql.NullString
+	Amount      sql.NullFloat64
+	Description sql.NullString
+}
+
+// accountPlan gathers what is known about one account before projecting it.
+type accountPlan struct {
+	forecast AccountForecast
+	flagged  bool
+	since    time.Time // start of the history used for the baseline
+	variable float64   // net variable flow over the history
+	items    map[string][]ForecastItem
+}
+
+// CashFlow projects the balance of every account for the q.Days days after today. Each day adds the
+// recurring expenses falling due, expected income (credits repeated monthly in the history), the remaining
+// installments of card purchases and a baseline of variable spending: the account's average daily net
+// outflow over the history once those scheduled flows are taken out. Unpaid recurring expenses already due
+// this month are placed on the first day. Recurring expenses without an account are drawn from their
+// owner's account or a default one (see recurringExpenseAccount), and past debits with a recurring
+// expense's category and amount, such as the transactions MarkPaid creates, are left out of the baseline.
+func (s *ForecastService) CashFlow(workspaceID uint, today time.Time, q ForecastQuery) (*CashFlowForecast, error) {
+	if err := q.normalize(); err != nil {
+		return nil, err
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	from := today.AddDate(0, 0, 1)
+	to := from.AddDate(0, 0, q.Days)
+	historyFrom := from.AddDate(0, -q.HistoryMonths, 0)
+
+	balances, err := s.accountService.Balances(workspaceID, today)
+	if err != nil {
+		return nil, err
+	}
+	plans := map[uint]*accountPlan{}
+	order := []uint{}
+	for _, b := range balances {
+		account := b.Account
+		since := historyFrom
+		if account.OpeningDate != nil && account.OpeningDate.After(since) {
+			since = *account.OpeningDate
+		}
+		id := account.ID
+		plans[id] = &accountPlan{
+			forecast: AccountForecast{
+				AccountID:       &id,
+				Name:            account.Name,
+				Type:            account.Type,
+				Currency:        account.Currency,
+				StartingBalance: b.Balance,
+			},
+			flagged: account.Type != models.AccountTypeCard,
+			since:   since,
+			items:   map[string][]ForecastItem{},
+		}
+		order = append(order, id)
+	}
+	plan := func(accountID *uint) *accountPlan {
+		var id uint
+		if accountID != nil {
+			id = *accountID
+		}
+		if p, ok := plans[id]; ok {
+			return p
+		}
+		// Unassigned flows, or an account that disappeared mid-query.
+		p := &accountPlan{forecast: AccountForecast{Name: "Unassigned"}, since: historyFrom, items: map[string][]ForecastItem{}}
+		plans[id] = p
+		order = append(order, id)
+		return p
+	}
+
+	var expenses []models.RecurringExpense
+	if err := s.db.Where("workspace_id = ?", workspaceID).Find(&expenses).Error; err != nil {
+		return nil, err
+	}
+	var ownerUsers []struct {
+		Name   string
+		UserID uint
+	}
+	if err := s.db.Table("owners").
+		Select("owners.name, workspace_members.user_id").
+		Joins("JOIN workspace_members ON workspace_members.id = owners.member_id").
+		Where("owners.workspace_id = ?", workspaceID).
+		Scan(&ownerUsers).Error; err != nil {
+		return nil, err
+	}
+	holders := map[string]uint{}
+	for _, o := range ownerUsers {
+		holders[ownerKey(o.Name)] = o.UserID
+	}
+	accounts := make([]models.Account, 0, len(balances))
+	for _, b := range balances {
+		accounts = append(accounts, b.Account)
+	}
+	recurringPayments := map[string]bool{}
+	for _, expense := range expenses {
+		recurringPayments[recurringPaymentKey(expense.CategoryID, expense.Amount)] = true
+		p := plan(recurringExpenseAccount(expense, accounts, holders))
+		for _, date := range recurringExpenseDates(expense.DueDay, expense.LastPaidDate, today, to) {
+			key := date.Format("2006-01-02")
+			p.items[key] = append(p.items[key], ForecastItem{Kind: ForecastRecurring, Description: expense.Name, Amount: -expense.Amount})
+		}
+	}
+
+	var history []forecastFlow
+	if err := s.db.Model(&models.Transaction{}).
+		Select("account_id, category_id, date, type, amount, description").
+		Where("workspace_id = ? AND date >= ? AND date < ?", workspaceID, historyFrom, from).
+		Order("date ASC, id ASC").
+		Scan(&history).Error; err != nil {
+		return nil, err
+	}
+
+	cardAccounts := map[uint]bool{}
+	for _, b := range balances {
+		if b.Account.Type == models.AccountTypeCard {
+			cardAccounts[b.Account.ID] = true
+		}
+	}
+	var credits, installments []forecastFlow
+	for _, flow := range history {
+		p := plan(flow.AccountID)
+		if flow.Date.Before(p.since) {
+			continue
+		}
+		amount := flow.Amount.Float64
+		switch flow.Type.String {
+		case "credit":
+			credits = append(credits, flow)
+			p.variable += amount
+		case "debit":
+			if recurringPayments[recurringPaymentKey(flow.CategoryID, amount)] {
+				continue // scheduled from the recurring expense
+			}
+			if flow.AccountID != nil && cardAccounts[*flow.AccountID] {
+				if _, _, _, ok := parseInstallment(flow.Description.String); ok {
+					installments = append(installments, flow)
+					continue
+				}
+			}
+			p.variable -= amount
+		}
+	}
+
+	for _, income := range recurringIncome(credits) {
+		p := plan(income.accountID)
+		p.variable -= income.total // scheduled instead of counted in the baseline
+		for _, date := range monthlyDates(income.last, from, to, 0) {
+			key := date.Format("2006-01-02")
+			p.items[key] = append(p.items[key], ForecastItem{Kind: ForecastIncome, Description: income.description, Amount: income.amount})
+		}
+	}
+
+	for _, installment := range outstandingInstallments(installments) {
+		p := plan(installment.accountID)
+		for _, date := range monthlyDates(installment.last, from, to, installment.remaining) {
+			number := installment.number + monthsBetween(installment.last, date)
+			key := date.Format("2006-01-02")
+			p.items[key] = append(p.items[key], ForecastItem{
+				Kind:        ForecastInstallment,
+				Description: fmt.Sprintf("%s (%d/%d)", installment.description, number, installment.total),
+				Amount:      -installment.amount,
+			})
+		}
+	}
+
+	forecast := &CashFlowForecast{
+		AsOf:        today.Format("2006-01-02"),
+		From:        from.Format("2006-01-02"),
+		To:          to.AddDate(0, 0, -1).Format("2006-01-02"),
+		HistoryFrom: historyFrom.Format("2006-01-02"),
+		Accounts:    make([]AccountForecast, 0, len(order)),
+	}
+	for _, id := range order {
+		p := plans[id]
+		if id == 0 && len(p.items) == 0 && p.variable == 0 {
+			continue
+		}
+		if days := from.Sub(p.since).Hours() / 24; days >= 1 && p.variable < 0 {
+			p.forecast.DailyBaseline = p.variable / math.Round(days)
+		}
+		projectAccount(&p.forecast, p.items, p.flagged, from, to)
+		forecast.Accounts = append(forecast.Accounts, p.forecast)
+	}
+	return forecast, nil
+}
+
+// projectAccount fills in the daily balances of f over [from, to).
+func projectAccount(f *AccountForecast, items map[string][]ForecastItem, flagged bool, from, to time.Time) {
+	balance := f.StartingBalance
+	f.LowestBalance = balance
+	f.LowestDate = from.AddDate(0, 0, -1).Format("2006-01-02")
+	f.Days = []ForecastDay{}
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		key := date.Format("2006-01-02")
+		day := ForecastDay{Date: key, Baseline: f.DailyBaseline, Items: items[key]}
+		for _, item := range day.Items {
+			day.Scheduled += item.Amount
+		}
+		balance += day.Scheduled + day.Baseline
+		day.Balance = balance
+		if flagged && balance < -settlementTolerance {
+			day.Negative = true
+			f.NegativeDays++
+			if f.FirstNegativeDate == nil {
+				first := key
+				f.FirstNegativeDate = &first
+			}
+		}
+		if balance < f.LowestBalance {
+			f.LowestBalance = balance
+			f.LowestDate = key
+		}
+		f.Days = append(f.Days, day)
+	}
+	f.EndingBalance = balance
+}
+
+// recurringExpenseAccount is the account a recurring expense is drawn from: its own, else the first
+// non-card account held by the member its owner is linked to (holders maps owner keys to user ids), else
+// the first joint non-card account, else the first non-card one. It is nil only when there is none.
+func recurringExpenseAccount(expense models.RecurringExpense, accounts []models.Account, holders map[string]uint) *uint {
+	if expense.AccountID != nil {
+		return expense.AccountID
+	}
+	var joint, fallback *uint
+	userID, held := holders[ownerKey(expense.Owner)]
+	for i := range accounts {
+		account := &accounts[i]
+		if account.Type == models.AccountTypeCard {
+			continue
+		}
+		if held && account.OwnerUserID != nil && *account.OwnerUserID == userID {
+			return &account.ID
+		}
+		if joint == nil && account.OwnerUserID == nil {
+			joint = &account.ID
+		}
+		if fallback == nil {
+			fallback = &account.ID
+		}
+	}
+	if joint != nil {
+		return joint
+	}
+	return fallback
+}
+
+// recurringPaymentKey identifies a payment of a recurring expense by category and amount, which is what
+// MarkPaid records and what a bank import of the same payment carries, whatever its description.
+func recurringPaymentKey(categoryID *uint, amount float64) string {
+	return fmt.Sprintf("%d|%.2f", derefUint(categoryID), amount)
+}
+
+// recurringExpenseDates returns when an expense due on dueDay of each month falls due after today and
+// before to. This month's occurrence is skipped once paid and moved to tomorrow while overdue.
+func recurringExpenseDates(dueDay int, lastPaid *time.Time, today, to time.Time) []time.Time {
+	var dates []time.Time
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	for ; month.Before(to); month = month.AddDate(0, 1, 0) {
+		date := dayOfMonth(month, dueDay)
+		if month.Year() == today.Year() && month.Month() == today.Month() {
+			if lastPaid != nil && lastPaid.Year() == today.Year() && lastPaid.Month() == today.Month() {
+				continue
+			}
+			if !date.After(today) {
+				date = today.AddDate(0, 0, 1)
+			}
+		}
+		if date.Before(to) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// monthlyDates returns the monthly repetitions of last that fall within [from, to), on the same day of the
+// month (or the month's last day). A positive limit caps how many repetitions after last are counted,
+// whether or not they fall within the range.
+func monthlyDates(last, from, to time.Time, limit int) []time.Time {
+	var dates []time.Time
+	for n := 1; limit <= 0 || n <= limit; n++ {
+		month := time.Date(last.Year(), last.Month()+time.Month(n), 1, 0, 0, 0, 0, from.Location())
+		date := dayOfMonth(month, last.Day())
+		if !date.Before(to) {
+			break
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// dayOfMonth is the given day of month's month, or its last day if the month is shorter.
+func dayOfMonth(month time.Time, day int) time.Time {
+	last := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, month.Location()).Day()
+	if day > last {
+		day = last
+	}
+	if day < 1 {
+		day = 1
+	}
+	return time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, month.Location())
+}
+
+// descriptionKey compares descriptions ignoring case, spacing and digits, so "SUELDO 03/2024" and
+// "Sueldo 04/2024" are the same flow.
+func descriptionKey(description string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return ' '
+		}
+		return r
+	}, description)
+	return strings.ToLower(strings.Join(strings.Fields(cleaned), " "))
+}
+
+// parseInstallment reads the installment counter of a card purchase description. stem is the description
+// without it, which identifies the purchase across statements.
+func parseInstallment(description string) (number, total int, stem string, ok bool) {
+	match := installmentPattern.FindStringSubmatchIndex(description)
+	if match == nil {
+		return 0, 0, "", false
+	}
+	number, _ = strconv.Atoi(description[match[2]:match[3]])
+	total, _ = strconv.Atoi(description[match[4]:match[5]])
+	if total < 2 || number < 1 || number > total {
+		return 0, 0, "", false
+	}
+	stem = strings.Join(strings.Fields(description[:match[0]]+" "+description[match[1]:]), " ")
+	return number, total, stem, true
+}
+
+type incomeSeries struct {
+	accountID   *uint
+	description string
+	amount      float64 // the latest one
+	total       float64 // over the history
+	last        time.Time
+}
+
+// recurringIncome finds the credits that arrive every month: same account and description in at least
+// two months, the last of them no earlier than the month before the latest credit, so income that stopped
+// is not projected.
+func recurringIncome(credits []forecastFlow) []incomeSeries {
+	type group struct {
+		series incomeSeries
+		months map[string]bool
+	}
+	groups := map[string]*group{}
+	keys := []string{}
+	latestMonth := ""
+	for _, c := range credits {
+		if month := c.Date.Format("2006-01"); month > latestMonth {
+			latestMonth = month
+		}
+	}
+	for _, c := range credits {
+		description := descriptionKey(c.Description.String)
+		if description == "" {
+			continue
+		}
+		key := description
+		if c.AccountID != nil {
+			key = strconv.FormatUint(uint64(*c.AccountID), 10) + "|" + key
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{series: incomeSeries{accountID: c.AccountID, description: strings.TrimSpace(c.Description.String)}, months: map[string]bool{}}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.months[c.Date.Format("2006-01")] = true
+		g.series.total += c.Amount.Float64
+		if !c.Date.Before(g.series.last) {
+			g.series.last = c.Date
+			g.series.amount = c.Amount.Float64
+			g.series.description = strings.TrimSpace(c.Description.String)
+		}
+	}
+
+	sort.Strings(keys)
+	var series []incomeSeries
+	for _, key := range keys {
+		g := groups[key]
+		if len(g.months) >= 2 && g.series.last.Format("2006-01") >= previousMonth(latestMonth) {
+			series = append(series, g.series)
+		}
+	}
+	return series
+}
+
+// previousMonth returns the YYYY-MM before month.
+func previousMonth(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return t.AddDate(0, -1, 0).Format("2006-01")
+}
+
+type installmentPlan struct {
+	accountID   *uint
+	description string
+	amount      float64
+	number      int // the latest one charged
+	total       int
+	remaining   int
+	last        time.Time
+}
+
+// outstandingInstallments keeps the latest installment charged of each card purchase that still has some
+// to go.
+func outstandingInstallments(charges []forecastFlow) []installmentPlan {
+	latest := map[string]*installmentPlan{}
+	keys := []string{}
+	for _, c := range charges {
+		number, total, stem, ok := parseInstallment(c.Description.String)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprintf("%d|%s|%d", derefUint(c.AccountID), strings.ToLower(stem), total)
+		current, seen := latest[key]
+		if !seen {
+			keys = append(keys, key)
+		}
+		if !seen || number > current.number || (number == current.number && c.Date.After(current.last)) {
+			latest[key] = &installmentPlan{
+				accountID:   c.AccountID,
+				description: stem,
+				amount:      c.Amount.Float64,
+				number:      number,
+				total:       total,
+				remaining:   total - number,
+				last:        c.Date,
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	var plans []installmentPlan
+	for _, key := range keys {
+		if p := latest[key]; p.remaining > 0 {
+			plans = append(plans, *p)
+		}
+	}
+	return plans
+}
+
+func derefUint(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"etl-banks-ar/internal/models"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestParseInstallment(t *testing.T) {
+	number, total, stem, ok := parseInstallment("FRAVEGA SA C.03/12")
+	if !ok || number != 3 || total != 12 || stem != "FRAVEGA SA" {
+		t.Fatalf("got %d/%d %q %v", number, total, stem, ok)
+	}
+	if number, total, _, ok := parseInstallment("Cuota 2/6 Garbarino"); !ok || number != 2 || total != 6 {
+		t.Fatalf("got %d/%d %v", number, total, ok)
+	}
+	for _, description := range []string{"SUPERMERCADO DIA", "C.13/12 BAD", "PAGO 1/1"} {
+		if _, _, _, ok := parseInstallment(description); ok {
+			t.Fatalf("%q is not an installment", description)
+		}
+	}
+}
+
+func TestRecurringExpenseDates(t *testing.T) {
+	today := day("2024-03-20")
+	to := day("2024-05-21")
+
+	dates := recurringExpenseDates(10, nil, today, to)
+	if len(dates) != 3 || !dates[0].Equal(day("2024-03-21")) || !dates[1].Equal(day("2024-04-10")) {
+		t.Fatalf("overdue payment should move to tomorrow: %v", dates)
+	}
+
+	paid := day("2024-03-09")
+	dates = recurringExpenseDates(31, &paid, today, to)
+	if len(dates) != 1 || !dates[0].Equal(day("2024-04-30")) {
+		t.Fatalf("paid month should be skipped and day 31 clamped: %v", dates)
+	}
+}
+
+func TestOutstandingInstallments(t *testing.T) {
+	card := uint(4)
+	charge := func(date, description string, amount float64) forecastFlow {
+		return forecastFlow{
+			AccountID:   &card,
+			Date:        day(date),
+			Amount:      sql.NullFloat64{Float64: amount, Valid: true},
+			Description: sql.NullString{String: description, Valid: true},
+		}
+	}
+	plans := outstandingInstallments([]forecastFlow{
+		charge("2024-01-15", "TV C.01/03", 1000),
+		charge("2024-02-15", "TV C.02/03", 1000),
+		charge("2024-02-20", "HELADERA C.06/06", 500),
+	})
+	if len(plans) != 1 || plans[0].description != "TV" || plans[0].remaining != 1 {
+		t.Fatalf("unexpected plans: %+v", plans)
+	}
+
+	dates := monthlyDates(plans[0].last, day("2024-03-01"), day("2024-06-01"), plans[0].remaining)
+	if len(dates) != 1 || !dates[0].Equal(day("2024-03-15")) {
+		t.Fatalf("only the last installment should remain: %v", dates)
+	}
+}
+
+func TestRecurringIncome(t *testing.T) {
+	bank := uint(1)
+	credit := func(date, description string, amount float64) forecastFlow {
+		return forecastFlow{
+			AccountID:   &bank,
+			Date:        day(date),
+			Amount:      sql.NullFloat64{Float64: amount, Valid: true},
+			Description: sql.NullString{String: description, Valid: true},
+		}
+	}
+	series := recurringIncome([]forecastFlow{
+		credit("2024-01-05", "SUELDO 01/2024", 900),
+		credit("2024-02-05", "SUELDO 02/2024", 1000),
+		credit("2024-02-11", "REINTEGRO", 30),
+		credit("2023-12-01", "ALQUILER COBRADO", 400),
+		credit("2023-11-01", "ALQUILER COBRADO", 400),
+	})
+	if len(series) != 1 || series[0].amount != 1000 || series[0].total != 1900 {
+		t.Fatalf("expected only the salary: %+v", series)
+	}
+}
+
+func TestProjectAccount(t *testing.T) {
+	f := AccountForecast{StartingBalance: 100, DailyBaseline: -10}
+	items := map[string][]ForecastItem{
+		"2024-03-02": {{Kind: ForecastRecurring, Description: "Rent", Amount: -150}},
+		"2024-03-03": {{Kind: ForecastIncome, Description: "Salary", Amount: 200}},
+	}
+	projectAccount(&f, items, true, day("2024-03-01"), day("2024-03-04"))
+
+	if len(f.Days) != 3 || f.Days[1].Balance != -70 || !f.Days[1].Negative || f.Days[2].Negative {
+		t.Fatalf("unexpected days: %+v", f.Days)
+	}
+	if f.NegativeDays != 1 || f.FirstNegativeDate == nil || *f.FirstNegativeDate != "2024-03-02" {
+		t.Fatalf("unexpected negative flags: %+v", f)
+	}
+	if f.EndingBalance != 120 || f.LowestBalance != -70 || f.LowestDate != "2024-03-02" {
+		t.Fatalf("unexpected summary: %+v", f)
+	}
+}
+
+func TestRecurringExpenseAccount(t *testing.T) {
+	accounts := []models.Account{
+		{ID: 1, Type: models.AccountTypeCard, OwnerUserID: uintPtr(20)},
+		{ID: 2, Type: models.AccountTypeBank, OwnerUserID: uintPtr(30)},
+		{ID: 3, Type: models.AccountTypeBank},
+		{ID: 4, Type: models.AccountTypeWallet, OwnerUserID: uintPtr(20)},
+	}
+	holders := map[string]uint{"ana": 20}
+
+	for name, tc := range map[string]struct {
+		expense models.RecurringExpense
+		want    uint
+	}{
+		"own account":     {models.RecurringExpense{Owner: "Ana", AccountID: uintPtr(2)}, 2},
+		"owner's account": {models.RecurringExpense{Owner: "Ana"}, 4},
+		"unlinked owner":  {models.RecurringExpense{Owner: "Juan"}, 3},
+		"no owner":        {models.RecurringExpense{}, 3},
+	} {
+		if got := recurringExpenseAccount(tc.expense, accounts, holders); got == nil || *got != tc.want {
+			t.Errorf("%s: account = %v, want %d", name, got, tc.want)
+		}
+	}
+
+	if got := recurringExpenseAccount(models.RecurringExpense{}, accounts[:2], nil); got == nil || *got != 2 {
+		t.Errorf("without a joint account = %v, want the first non-card one", got)
+	}
+	if got := recurringExpenseAccount(models.RecurringExpense{Owner: "Ana"}, accounts[:1], holders); got != nil {
+		t.Errorf("cards only = %v, want unassigned", *got)
+	}
+}
+
+func TestRecurringPaymentKey(t *testing.T) {
+	rent := uintPtr(4)
+	if recurringPaymentKey(rent, 350000) != recurringPaymentKey(uintPtr(4), 350000.001) {
+		t.Fatal("a payment with the expense's category and amount should match")
+	}
+	if recurringPaymentKey(rent, 350000) == recurringPaymentKey(uintPtr(5), 350000) {
+		t.Fatal("another category should not match")
+	}
+	if recurringPaymentKey(nil, 350000) == recurringPaymentKey(rent, 350000) {
+		t.Fatal("an uncategorized payment should not match a categorized expense")
+	}
+}
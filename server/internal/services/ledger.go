@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrTransactionLinked = errors.New("transaction is already part of a transfer, card payment or refund")
+
+// settledTransactionIDsSQL selects transactions that only move money between the workspace's own accounts:
+// both sides of linked transfers and card bill payments. They are neither spending nor income.
+const settledTransactionIDsSQL = `SELECT debit_transaction_id FROM transfer_links
+		UNION ALL SELECT credit_transaction_id FROM transfer_links
+		UNION ALL SELECT transaction_id FROM card_payments
+		UNION ALL SELECT card_transaction_id FROM card_payments WHERE card_transaction_id IS NOT NULL`
+
+// linkedTransactionIDsSQL adds both sides of refund links to the settled transactions. A transaction in
+// this set cannot join another transfer, card payment or refund link.
+const linkedTransactionIDsSQL = settledTransactionIDsSQL + `
+		UNION ALL SELECT refund_transaction_id FROM refund_links
+		UNION ALL SELECT original_transaction_id FROM refund_links`
+
+// ledgerLinesSQL yields one row per reportable amount: unsplit transactions as they are, and split
+// transactions as their allocations. Summaries read from it instead of the transactions table so a split
+// charge is attributed to each allocation's category, area and owner. Settled transactions (see
+// settledTransactionIDsSQL) are left out, and a linked refund becomes a negative debit in its purchase's
+// category, area and owner (but stays on its own account) so it nets against the spending instead of
+// counting as income. The refund of a split purchase is spread across the purchase's allocations in
+// proportion to their amounts. The columns mirror transactions.
+const ledgerLinesSQL = `
+	SELECT t.id AS transaction_id, t.workspace_id, t.date,
+		CASE WHEN r.id IS NULL THEN t.type ELSE 'debit' END AS type,
+		CASE WHEN r.id IS NULL THEN t.amount WHEN oa.id IS NULL THEN -t.amount
+			ELSE -t.amount * oa.amount / o.amount END AS amount,
+		CASE WHEN r.id IS NULL THEN t.category_id WHEN oa.id IS NULL THEN o.category_id
+			ELSE oa.category_id END AS category_id,
+		CASE WHEN r.id IS NULL THEN t.area_id WHEN oa.id IS NULL THEN o.area_id ELSE oa.area_id END AS area_id,
+		CASE WHEN r.id IS NULL THEN t.owner ELSE COALESCE(NULLIF(oa.owner, ''), o.owner) END AS owner,
+		t.account_id
+	FROM transactions t
+	LEFT JOIN refund_links r ON r.refund_transaction_id = t.id
+	LEFT JOIN transactions o ON o.id = r.original_transaction_id
+	LEFT JOIN transaction_allocations oa ON oa.transaction_id = o.id
+	WHERE NOT EXISTS (SELECT 1 FROM transaction_allocations a WHERE a.transaction_id = t.id)
+		AND t.id NOT IN (` + settledTransactionIDsSQL + `)
+	UNION ALL
+	SELECT t.id AS transaction_id, t.workspace_id, t.date, t.type, a.amount,
+		a.category_id, a.area_id, COALESCE(NULLIF(a.owner, ''), t.owner) AS owner, t.account_id
+	FROM transaction_allocations a
+	JOIN transactions t ON t.id = a.transaction_id
+	WHERE t.id NOT IN (` + settledTransactionIDsSQL + `)`
+
+// ledgerLines starts a query over ledgerLinesSQL aliased as "t".
+func ledgerLines(db *gorm.DB) *gorm.DB {
+	return db.Table("(" + ledgerLinesSQL + ") AS t")
+}
+
+// anyLinked reports whether any of ids is already part of a transfer, card payment or refund link.
+func anyLinked(tx *gorm.DB, ids ...uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Transaction{}).
+		Where("id IN ? AND id IN ("+linkedTransactionIDsSQL+")", ids).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidOwner = errors.New("invalid owner")
+	ErrOwnerInUse   = errors.New("owner is still in use")
+)
+
+// ownerKey is how owner names and aliases are compared: case and spacing are ignored, so "Juan" and
+// " juan" are the same owner.
+func ownerKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+type OwnerService struct {
+	db *gorm.DB
+}
+
+func NewOwnerService(db *gorm.DB) *OwnerService {
+	return &OwnerService{db: db}
+}
+
+func (s *OwnerService) List(workspaceID uint) ([]models.Owner, error) {
+	owners := []models.Owner{}
+	err := s.db.Preload("Aliases").Where("workspace_id = ?", workspaceID).Order("name ASC").Find(&owners).Error
+	return owners, err
+}
+
+func (s *OwnerService) FindByID(id, workspaceID uint) (*models.Owner, error) {
+	var owner models.Owner
+	err := s.db.Preload("Aliases").Where("id = ? AND workspace_id = ?", id, workspaceID).First(&owner).Error
+	return &owner, err
+}
+
+func (s *OwnerService) Create(owner *models.Owner) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := validateOwner(tx, owner); err != nil {
+			return err
+		}
+		return tx.Omit("Aliases", "Member").Create(owner).Error
+	})
+}
+
+// Update saves owner. A new name is written to every transaction, allocation, recurring expense, split
+// rule and settlement payment carrying the old one.
+func (s *OwnerService) Update(owner *models.Owner) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.Owner
+		if err := tx.Where("id = ? AND workspace_id = ?", owner.ID, owner.WorkspaceID).First(&stored).Error; err != nil {
+			return err
+		}
+		if err := validateOwner(tx, owner); err != nil {
+			return err
+		}
+		if err := tx.Omit("Aliases", "Member").Save(owner).Error; err != nil {
+			return err
+		}
+		if stored.Name == owner.Name {
+			return nil
+		}
+		if err := tx.Model(&models.Transaction{}).
+			Where("workspace_id = ? AND owner_id = ?", owner.WorkspaceID, owner.ID).
+			Update("owner", owner.Name).Error; err != nil {
+			return err
+		}
+		return rewriteOwnerName(tx, owner.WorkspaceID, stored.Name, owner.Name)
+	})
+}
+
+// Delete removes an owner nothing refers to; owners in use are merged into another instead.
+func (s *OwnerService) Delete(id, workspaceID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var owner models.Owner
+		if err := tx.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&owner).Error; err != nil {
+			return err
+		}
+		inUse, err := ownerInUse(tx, &owner)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return fmt.Errorf("%w: merge %s into another owner instead", ErrOwnerInUse, owner.Name)
+		}
+		if err := tx.Where("owner_id = ?", owner.ID).Delete(&models.OwnerAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&owner).Error
+	})
+}
+
+// Merge folds the source owners into the target: their transactions and every other reference move to
+// the target, their names and aliases become aliases of it so later imports resolve to the target, and
+// the sources are deleted. Two owners linked to different members cannot be merged.
+func (s *OwnerService) Merge(workspaceID, targetID uint, sourceIDs []uint) (*models.Owner, error) {
+	sourceIDs, err := mergeSourceIDs(targetID, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var target models.Owner
+		if err := tx.Where("id = ? AND workspace_id = ?", targetID, workspaceID).First(&target).Error; err != nil {
+			return err
+		}
+		for _, sourceID := range sourceIDs {
+			var source models.Owner
+			if err := tx.Where("id = ? AND workspace_id = ?", sourceID, workspaceID).First(&source).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: owner %d not found", ErrInvalidOwner, sourceID)
+				}
+				return err
+			}
+			if err := takeOwnerMember(&target, &source); err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.Transaction{}).
+				Where("workspace_id = ? AND owner_id = ?", workspaceID, source.ID).
+				Updates(map[string]interface{}{"owner_id": target.ID, "owner": target.Name}).Error; err != nil {
+				return err
+			}
+			if err := rewriteOwnerName(tx, workspaceID, source.Name, target.Name); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.OwnerAlias{}).Where("owner_id = ?", source.ID).
+				Update("owner_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&source).Error; err != nil {
+				return err
+			}
+			alias := models.OwnerAlias{WorkspaceID: workspaceID, OwnerID: target.ID, Name: source.Name}
+			if err := tx.Create(&alias).Error; err != nil {
+				return err
+			}
+		}
+		if err := combineSplitShares(tx, workspaceID, target.Name); err != nil {
+			return err
+		}
+		return tx.Omit("Aliases", "Member").Save(&target).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindByID(targetID, workspaceID)
+}
+
+// mergeSourceIDs drops the target and repeated ids from the owners to merge, failing when none are left.
+func mergeSourceIDs(targetID uint, sourceIDs []uint) ([]uint, error) {
+	seen := map[uint]bool{targetID: true}
+	ids := []uint{}
+	for _, id := range sourceIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: nothing to merge", ErrInvalidOwner)
+	}
+	return ids, nil
+}
+
+// takeOwnerMember links target to the member of a source being merged into it, failing when they are linked
+// to different members.
+func takeOwnerMember(target, source *models.Owner) error {
+	if source.MemberID == nil {
+		return nil
+	}
+	if target.MemberID != nil && *target.MemberID != *source.MemberID {
+		return fmt.Errorf("%w: %s and %s are different members", ErrInvalidOwner, source.Name, target.Name)
+	}
+	target.MemberID = source.MemberID
+	return nil
+}
+
+// BackfillOwners links transactions recorded before owners existed to owner rows. Spellings differing
+// only in case or spacing become one owner named after the most used spelling, and owners whose name
+// matches exactly one member's are linked to that member. Running it again only links rows left unlinked.
+func (s *OwnerService) BackfillOwners() error {
+	var usages []ownerUsage
+	for _, source := range ownerBackfillSources(s.db) {
+		var rows []ownerUsage
+		if err := source.Scan(&rows).Error; err != nil {
+			return err
+		}
+		usages = append(usages, rows...)
+	}
+	spellings := groupOwnerSpellings(usages)
+	if len(spellings) == 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for workspaceID, byKey := range spellings {
+			owners := newOwnerResolver(tx, workspaceID)
+			for _, names := range byKey {
+				owner, err := owners.resolveOrCreate(preferredSpelling(names))
+				if err != nil {
+					return err
+				}
+				for name := range names {
+					if name == owner.Name {
+						continue
+					}
+					if err := tx.Model(&models.Transaction{}).
+						Where("workspace_id = ? AND owner = ?", workspaceID, name).
+						Update("owner", owner.Name).Error; err != nil {
+						return err
+					}
+					if err := rewriteOwnerName(tx, workspaceID, name, owner.Name); err != nil {
+						return err
+					}
+				}
+				if err := combineSplitShares(tx, workspaceID, owner.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Exec(`
+			UPDATE transactions
+			SET owner_id = (
+				SELECT o.id FROM owners o
+				WHERE o.workspace_id = transactions.workspace_id AND o.name = transactions.owner
+			)
+			WHERE owner_id IS NULL AND owner IS NOT NULL AND owner != ''`).Error
+	})
+}
+
+type ownerUsage struct {
+	WorkspaceID uint
+	Name        string
+	Count       int
+}
+
+// ownerBackfillSources counts the owner names the backfill still has to link: transactions without an
+// owner_id, and rows storing owners by name whose name is not an owner's. Once everything is linked they
+// all come back empty and the backfill does nothing.
+func ownerBackfillSources(db *gorm.DB) []*gorm.DB {
+	unknown := func(workspace, column string) string {
+		return "NOT EXISTS (SELECT 1 FROM owners o WHERE o.workspace_id = " + workspace + " AND o.name = " + column + ")"
+	}
+	return []*gorm.DB{
+		db.Table("transactions").Select("workspace_id, owner as name, COUNT(*) as count").
+			Where("owner_id IS NULL AND owner IS NOT NULL AND owner != ''").Group("workspace_id, owner"),
+		db.Table("transaction_allocations").Select("workspace_id, owner as name, COUNT(*) as count").
+			Where("owner != ''").Where(unknown("transaction_allocations.workspace_id", "transaction_allocations.owner")).
+			Group("workspace_id, owner"),
+		db.Table("recurring_expenses").Select("workspace_id, owner as name, COUNT(*) as count").
+			Where("owner IS NOT NULL AND owner != ''").Where(unknown("recurring_expenses.workspace_id", "recurring_expenses.owner")).
+			Group("workspace_id, owner"),
+		db.Table("split_shares").Joins("JOIN split_rules ON split_rules.id = split_shares.split_rule_id").
+			Select("split_rules.workspace_id, split_shares.owner as name, COUNT(*) as count").
+			Where(unknown("split_rules.workspace_id", "split_shares.owner")).
+			Group("split_rules.workspace_id, split_shares.owner"),
+		db.Table("settlement_payments").Select("workspace_id, from_owner as name, COUNT(*) as count").
+			Where(unknown("settlement_payments.workspace_id", "settlement_payments.from_owner")).
+			Group("workspace_id, from_owner"),
+		db.Table("settlement_payments").Select("workspace_id, to_owner as name, COUNT(*) as count").
+			Where(unknown("settlement_payments.workspace_id", "settlement_payments.to_owner")).
+			Group("workspace_id, to_owner"),
+	}
+}
+
+// groupOwnerSpellings counts each spelling of an owner: spellings[workspace][key][name]. Blank names are
+// left out.
+func groupOwnerSpellings(usages []ownerUsage) map[uint]map[string]map[string]int {
+	spellings := map[uint]map[string]map[string]int{}
+	for _, u := range usages {
+		key := ownerKey(u.Name)
+		if key == "" {
+			continue
+		}
+		if spellings[u.WorkspaceID] == nil {
+			spellings[u.WorkspaceID] = map[string]map[string]int{}
+		}
+		if spellings[u.WorkspaceID][key] == nil {
+			spellings[u.WorkspaceID][key] = map[string]int{}
+		}
+		spellings[u.WorkspaceID][key][u.Name] += u.Count
+	}
+	return spellings
+}
+
+// preferredSpelling picks the most used of several spellings of one owner, alphabetically first on ties.
+func preferredSpelling(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return strings.TrimSpace(names[0])
+}
+
+// validateOwner trims the name and checks that it is free and that the member, if any, belongs to the
+// workspace and has no other owner.
+func validateOwner(tx *gorm.DB, owner *models.Owner) error {
+	owner.Name = strings.TrimSpace(owner.Name)
+	if owner.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidOwner)
+	}
+
+	resolver := newOwnerResolver(tx, owner.WorkspaceID)
+	if err := resolver.load(); err != nil {
+		return err
+	}
+	if existing, ok := resolver.byKey[ownerKey(owner.Name)]; ok && existing.ID != owner.ID {
+		return fmt.Errorf("%w: %s is already an owner or alias", ErrInvalidOwner, owner.Name)
+	}
+
+	if owner.MemberID != nil {
+		var count int64
+		if err := tx.Model(&models.WorkspaceMember{}).
+			Where("id = ? AND workspace_id = ?", *owner.MemberID, owner.WorkspaceID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: member not found in this workspace", ErrInvalidOwner)
+		}
+		if err := tx.Model(&models.Owner{}).
+			Where("member_id = ? AND id <> ?", *owner.MemberID, owner.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: member is already linked to another owner", ErrInvalidOwner)
+		}
+	}
+	return nil
+}
+
+func ownerInUse(tx *gorm.DB, owner *models.Owner) (bool, error) {
+	checks := []*gorm.DB{
+		tx.Model(&models.Transaction{}).Where("workspace_id = ? AND (owner_id = ? OR owner = ?)", owner.WorkspaceID, owner.ID, owner.Name),
+		tx.Model(&models.TransactionAllocation{}).Where("workspace_id = ? AND owner = ?", owner.WorkspaceID, owner.Name),
+		tx.Model(&models.RecurringExpense{}).Where("workspace_id = ? AND owner = ?", owner.WorkspaceID, owner.Name),
+		tx.Model(&models.SplitShare{}).Where("owner = ? AND split_rule_id IN (?)", owner.Name,
+			tx.Model(&models.SplitRule{}).Select("id").Where("workspace_id = ?", owner.WorkspaceID)),
+		tx.Model(&models.SettlementPayment{}).Where("workspace_id = ? AND (from_owner = ? OR to_owner = ?)", owner.WorkspaceID, owner.Name, owner.Name),
+	}
+	for _, check := range checks {
+		var count int64
+		if err := check.Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rewriteOwnerName renames an owner on the rows that store owners by name only; transactions are updated
+// by owner_id by the callers.
+func rewriteOwnerName(tx *gorm.DB, workspaceID uint, from, to string) error {
+	if err := tx.Model(&models.TransactionAllocation{}).
+		Where("workspace_id = ? AND owner = ?", workspaceID, from).
+		Update("owner", to).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.RecurringExpense{}).
+		Where("workspace_id = ? AND owner = ?", workspaceID, from).
+		Update("owner", to).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.SplitShare{}).
+		Where("owner = ? AND split_rule_id IN (?)", from,
+			tx.Model(&models.SplitRule{}).Select("id").Where("workspace_id = ?", workspaceID)).
+		Update("owner", to).Error; err != nil {
+		return err
+	}
+	for _, column := range []string{"from_owner", "to_owner"} {
+		if err := tx.Model(&models.SettlementPayment{}).
+			Where("workspace_id = ? AND "+column+" = ?", workspaceID, from).
+			Update(column, to).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// combineSplitShares adds up the shares a merge left an owner with twice in one split rule.
+func combineSplitShares(tx *gorm.DB, workspaceID uint, owner string) error {
+	var shares []models.SplitShare
+	if err := tx.Where("owner = ? AND split_rule_id IN (?)", owner,
+		tx.Model(&models.SplitRule{}).Select("id").Where("workspace_id = ?", workspaceID)).
+		Order("id ASC").Find(&shares).Error; err != nil {
+		return err
+	}
+	kept := map[uint]*models.SplitShare{}
+	for i := range shares {
+		share := &shares[i]
+		first, ok := kept[share.SplitRuleID]
+		if !ok {
+			kept[share.SplitRuleID] = share
+			continue
+		}
+		first.Percent += share.Percent
+		if err := tx.Model(first).Update("percent", first.Percent).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(share).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownerResolver maps owner names to owners of one workspace, matching names and aliases by ownerKey. Only
+// the backfill creates owners for new names; a new owner whose name matches exactly one unlinked member's
+// is linked to that member.
+type ownerResolver struct {
+	db          *gorm.DB
+	workspaceID uint
+	loaded      bool
+	byKey       map[string]*models.Owner
+	members     map[string][]uint // unlinked member ids by ownerKey of the user's name
+}
+
+func newOwnerResolver(db *gorm.DB, workspaceID uint) *ownerResolver {
+	return &ownerResolver{db: db, workspaceID: workspaceID}
+}
+
+func (r *ownerResolver) load() error {
+	if r.loaded {
+		return nil
+	}
+	var owners []models.Owner
+	if err := r.db.Preload("Aliases").Where("workspace_id = ?", r.workspaceID).Find(&owners).Error; err != nil {
+		return err
+	}
+	r.byKey = map[string]*models.Owner{}
+	linked := map[uint]bool{}
+	for i := range owners {
+		owner := &owners[i]
+		r.byKey[ownerKey(owner.Name)] = owner
+		for _, alias := range owner.Aliases {
+			r.byKey[ownerKey(alias.Name)] = owner
+		}
+		if owner.MemberID != nil {
+			linked[*owner.MemberID] = true
+		}
+	}
+
+	var members []struct {
+		ID   uint
+		Name string
+	}
+	if err := r.db.Table("workspace_members").
+		Select("workspace_members.id, users.name").
+		Joins("JOIN users ON users.id = workspace_members.user_id").
+		Where("workspace_members.workspace_id = ?", r.workspaceID).
+		Scan(&members).Error; err != nil {
+		return err
+	}
+	r.members = map[string][]uint{}
+	for _, m := range members {
+		if key := ownerKey(m.Name); key != "" && !linked[m.ID] {
+			r.members[key] = append(r.members[key], m.ID)
+		}
+	}
+	r.loaded = true
+	return nil
+}
+
+// resolve returns the owner called name, or nil for an empty name. Names that are neither an owner nor
+// an alias are rejected with ErrInvalidOwner, so a typo never becomes a new owner.
+func (r *ownerResolver) resolve(name string) (*models.Owner, error) {
+	key := ownerKey(name)
+	if key == "" {
+		return nil, nil
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	if owner, ok := r.byKey[key]; ok {
+		return owner, nil
+	}
+	return nil, fmt.Errorf("%w: %s is not an owner or alias", ErrInvalidOwner, strings.TrimSpace(name))
+}
+
+// resolveOrCreate is resolve for names recorded before owners existed: an unknown name becomes a new owner.
+func (r *ownerResolver) resolveOrCreate(name string) (*models.Owner, error) {
+	owner, err := r.resolve(name)
+	if !errors.Is(err, ErrInvalidOwner) {
+		return owner, err
+	}
+
+	key := ownerKey(name)
+	owner = &models.Owner{WorkspaceID: r.workspaceID, Name: strings.TrimSpace(name)}
+	if ids := r.members[key]; len(ids) == 1 {
+		owner.MemberID = &ids[0]
+		delete(r.members, key)
+	}
+	if err := r.db.Omit("Aliases", "Member").Create(owner).Error; err != nil {
+		return nil, err
+	}
+	r.byKey[key] = owner
+	return owner, nil
+}
+
+// name returns the canonical spelling of name, or "" for an empty one.
+func (r *ownerResolver) name(name string) (string, error) {
+	owner, err := r.resolve(name)
+	if err != nil || owner == nil {
+		return "", err
+	}
+	return owner.Name, nil
+}
+
+// syncOwnerID sets t.OwnerID from the name in t.Owner and replaces the name with the owner's own spelling.
+func syncOwnerID(db *gorm.DB, t *models.Transaction) error {
+	owner, err := newOwnerResolver(db, t.WorkspaceID).resolve(t.Owner.String)
+	if err != nil {
+		return err
+	}
+	if owner == nil || !t.Owner.Valid {
+		t.OwnerID = nil
+		t.Owner.Valid = false
+		t.Owner.String = ""
+		return nil
+	}
+	t.OwnerID = &owner.ID
+	t.Owner.String = owner.Name
+	return nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestOwnerKey(t *testing.T) {
+	if ownerKey("  Juan  Pérez ") != ownerKey("juan pérez") {
+		t.Fatal("case and spacing should not distinguish owners")
+	}
+	if ownerKey("J.") == ownerKey("Juan") {
+		t.Fatal("different spellings are only joined by merging")
+	}
+	if ownerKey("   ") != "" {
+		t.Fatal("blank names have no key")
+	}
+}
+
+func TestPreferredSpelling(t *testing.T) {
+	if got := preferredSpelling(map[string]int{"juan": 3, "Juan": 12, "JUAN ": 1}); got != "Juan" {
+		t.Fatalf("got %q, want the most used spelling", got)
+	}
+	if got := preferredSpelling(map[string]int{"juan": 2, "Juan": 2}); got != "Juan" {
+		t.Fatalf("got %q, want the alphabetically first spelling on ties", got)
+	}
+}
+
+func TestOwnerResolverRejectsUnknownNames(t *testing.T) {
+	juan := &models.Owner{ID: 4, WorkspaceID: 3, Name: "Juan"}
+	resolver := &ownerResolver{db: dryRunDB(t), workspaceID: 3, loaded: true,
+		byKey: map[string]*models.Owner{"juan": juan, "j.": juan}, members: map[string][]uint{}}
+
+	if owner, err := resolver.resolve(" J. "); err != nil || owner != juan {
+		t.Fatalf("alias = %+v, %v", owner, err)
+	}
+	if owner, err := resolver.resolve("  "); err != nil || owner != nil {
+		t.Fatalf("blank = %+v, %v", owner, err)
+	}
+	if _, err := resolver.resolve("Juna"); !errors.Is(err, ErrInvalidOwner) {
+		t.Fatalf("expected a typo to be rejected, got %v", err)
+	}
+	if _, ok := resolver.byKey["juna"]; ok {
+		t.Fatal("a rejected name should not become an owner")
+	}
+}
+
+func TestOwnerBackfillSourcesOnlyCountUnlinkedRows(t *testing.T) {
+	db := dryRunDB(t)
+	sources := ownerBackfillSources(db)
+	if len(sources) != 6 {
+		t.Fatalf("sources = %d", len(sources))
+	}
+
+	for i, source := range sources {
+		sql := source.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Scan(&[]ownerUsage{}) })
+		want := "NOT EXISTS (SELECT 1 FROM owners o WHERE o.workspace_id = "
+		if i == 0 {
+			want = "owner_id IS NULL"
+		}
+		if !strings.Contains(sql, want) {
+			t.Errorf("source %d does not contain %q:\n%s", i, want, sql)
+		}
+	}
+}
+
+func TestGroupOwnerSpellings(t *testing.T) {
+	spellings := groupOwnerSpellings([]ownerUsage{
+		{WorkspaceID: 3, Name: "Juan", Count: 10},
+		{WorkspaceID: 3, Name: " juan", Count: 2},
+		{WorkspaceID: 3, Name: "Juan", Count: 1},
+		{WorkspaceID: 3, Name: "  ", Count: 5},
+		{WorkspaceID: 4, Name: "Juan", Count: 1},
+	})
+	if len(spellings) != 2 || len(spellings[3]) != 1 {
+		t.Fatalf("spellings = %v", spellings)
+	}
+	if got := spellings[3]["juan"]; got["Juan"] != 11 || got[" juan"] != 2 {
+		t.Fatalf("workspace 3 = %v", got)
+	}
+	if len(groupOwnerSpellings(nil)) != 0 {
+		t.Fatal("nothing unlinked should leave nothing to backfill")
+	}
+}
+
+func TestMergeSourceIDs(t *testing.T) {
+	ids, err := mergeSourceIDs(4, []uint{7, 4, 9, 7})
+	if err != nil {
+		t.Fatalf("merge ids: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 7 || ids[1] != 9 {
+		t.Fatalf("ids = %v, want the target and repeats dropped", ids)
+	}
+	for _, sources := range [][]uint{nil, {4, 4}} {
+		if _, err := mergeSourceIDs(4, sources); !errors.Is(err, ErrInvalidOwner) {
+			t.Errorf("%v: expected nothing to merge, got %v", sources, err)
+		}
+	}
+}
+
+func TestTakeOwnerMember(t *testing.T) {
+	target := &models.Owner{Name: "Juan"}
+	if err := takeOwnerMember(target, &models.Owner{Name: "J.", MemberID: uintPtr(2)}); err != nil {
+		t.Fatalf("take: %v", err)
+	}
+	if target.MemberID == nil || *target.MemberID != 2 {
+		t.Fatalf("member = %v, want the source's", target.MemberID)
+	}
+	if err := takeOwnerMember(target, &models.Owner{Name: "Juancito"}); err != nil || *target.MemberID != 2 {
+		t.Fatalf("unlinked source = %v, member %v", err, target.MemberID)
+	}
+	if err := takeOwnerMember(target, &models.Owner{Name: "Ana", MemberID: uintPtr(5)}); !errors.Is(err, ErrInvalidOwner) {
+		t.Fatalf("expected different members to be rejected, got %v", err)
+	}
+}
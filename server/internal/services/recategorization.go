@@ -0,0 +1,329 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/categorizer"
+	"etl-banks-ar/internal/models"
+	openAiService "etl-banks-ar/internal/openai"
+	"etl-banks-ar/internal/trainingcsv"
+
+	"gorm.io/gorm"
+)
+
+const maxRecategorizationRows = 2000
+
+const (
+	RecategorizeStrategyRules = "rules"
+	RecategorizeStrategyLocal = "local"
+	RecategorizeStrategyLLM   = "llm"
+)
+
+var (
+	ErrUnknownRecategorizeStrategy = errors.New("strategy must be one of rules, local, llm")
+	ErrRecategorizationApplied     = errors.New("recategorization job was already applied")
+	ErrInvalidRecategorizeFilter   = errors.New("invalid recategorization filter")
+)
+
+type RecategorizationService struct {
+	db              *gorm.DB
+	categoryService *CategoryService
+	ruleService     *CategoryRuleService
+}
+
+func NewRecategorizationService(db *gorm.DB, categoryService *CategoryService, ruleService *CategoryRuleService) *RecategorizationService {
+	return &RecategorizationService{db: db, categoryService: categoryService, ruleService: ruleService}
+}
+
+// RecategorizationFilter selects the historical transactions a job re-evaluates.
+type RecategorizationFilter struct {
+	From        string  `json:"from"` // YYYY-MM-DD, inclusive
+	To          string  `json:"to"`   // YYYY-MM-DD, inclusive
+	Category    *string `json:"category"`
+	Description string  `json:"description"` // case-insensitive substring
+}
+
+type RecategorizationRequest struct {
+	Filter           RecategorizationFilter `json:"filter"`
+	Strategy         string                 `json:"strategy"`
+	IncludeConfirmed bool                   `json:"include_confirmed"`
+}
+
+// Preview runs the strategy over the matching transactions and stores a job with one proposed change per row
+// whose predicted category differs from the current one. Nothing is written to transactions until Apply.
+// Fallback predictions are never proposed, so a job can't move rows into Missing.
+func (s *RecategorizationService) Preview(workspaceID, userID uint, req RecategorizationRequest) (*models.RecategorizationJob, error) {
+	backend, err := s.backend(workspaceID, req.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := s.filterQuery(workspaceID, req.Filter, req.IncludeConfirmed)
+	if err != nil {
+		return nil, err
+	}
+	var matched int64
+	if err := query.Count(&matched).Error; err != nil {
+		return nil, err
+	}
+	if matched > maxRecategorizationRows {
+		return nil, fmt.Errorf("%w: it matches %d transactions; narrow it to at most %d", ErrInvalidRecategorizeFilter, matched, maxRecategorizationRows)
+	}
+
+	var transactions []models.Transaction
+	if err := query.Preload("CategoryRef").Order("date ASC, id ASC").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryService.EnsureMissingCategory(workspaceID); err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryService.List(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := leafNames(categories)
+	categoryIDs := make(map[string]uint, len(categories))
+	for _, c := range categories {
+		categoryIDs[c.Name] = c.ID
+	}
+
+	examples, err := s.loadExamples(workspaceID, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	predictions := []categorizer.Prediction{}
+	if len(transactions) > 0 {
+		predictions, err = backend.Categorize(transactions, examples, allowed)
+		if err != nil {
+			return nil, fmt.Errorf("categorization failed: %w", err)
+		}
+	}
+
+	filterJSON, err := json.Marshal(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	job := &models.RecategorizationJob{
+		WorkspaceID:      workspaceID,
+		CreatedBy:        userID,
+		Strategy:         req.Strategy,
+		FilterJSON:       string(filterJSON),
+		IncludeConfirmed: req.IncludeConfirmed,
+		Status:           models.RecategorizationStatusPreview,
+		MatchedCount:     len(transactions),
+	}
+	for i, tx := range transactions {
+		p := predictions[i]
+		if p.IsFallback() || p.Category == tx.Category.String {
+			continue
+		}
+		change := models.RecategorizationChange{
+			TransactionID: tx.ID,
+			Date:          tx.Date.Format("2006-01-02"),
+			Description:   tx.Description.String,
+			Amount:        tx.Amount.Float64,
+			OldCategoryID: tx.CategoryID,
+			OldCategory:   tx.Category.String,
+			NewCategory:   p.Category,
+			Confidence:    p.Confidence,
+			Source:        string(p.Source),
+			Reason:        p.Reason,
+		}
+		if id, ok := categoryIDs[p.Category]; ok {
+			change.NewCategoryID = &id
+		}
+		job.Changes = append(job.Changes, change)
+	}
+	job.ChangeCount = len(job.Changes)
+
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *RecategorizationService) FindByID(jobID, workspaceID uint) (*models.RecategorizationJob, error) {
+	var job models.RecategorizationJob
+	err := s.db.Preload("Changes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date ASC, id ASC")
+	}).Where("id = ? AND workspace_id = ?", jobID, workspaceID).First(&job).Error
+	return &job, err
+}
+
+// Apply writes the job's proposed categories in one DB transaction. When changeIDs is non-empty only those
+// changes are applied. A change is skipped if its transaction's category was edited after the preview.
+// The job stays open until every change has been applied or skipped, so a partial apply can be resumed.
+func (s *RecategorizationService) Apply(jobID, workspaceID uint, changeIDs []uint) (*models.RecategorizationJob, error) {
+	job, err := s.FindByID(jobID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == models.RecategorizationStatusApplied {
+		return nil, ErrRecategorizationApplied
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		categories := newCategoryResolver(tx, workspaceID)
+		applied := 0
+		for _, i := range pendingChanges(job.Changes, changeIDs) {
+			change := &job.Changes[i]
+			newID := change.NewCategoryID
+			if newID == nil {
+				resolved, err := categories.resolve(change.NewCategory)
+				if err != nil {
+					return err
+				}
+				newID = resolved
+			}
+			query := tx.Model(&models.Transaction{}).Where("id = ? AND workspace_id = ?", change.TransactionID, workspaceID)
+			if change.OldCategoryID != nil {
+				query = query.Where("category_id = ?", *change.OldCategoryID)
+			} else {
+				query = query.Where("category_id IS NULL")
+			}
+			result := query.Update("category_id", newID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				change.Skipped = true
+				if err := tx.Model(change).Update("skipped", true).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			change.Applied = true
+			applied++
+			if err := tx.Model(change).Update("applied", true).Error; err != nil {
+				return err
+			}
+		}
+
+		job.AppliedCount += applied
+		updates := map[string]interface{}{"applied_count": job.AppliedCount}
+		if len(pendingChanges(job.Changes, nil)) == 0 {
+			now := time.Now()
+			job.Status = models.RecategorizationStatusApplied
+			job.AppliedAt = &now
+			updates["status"] = job.Status
+			updates["applied_at"] = job.AppliedAt
+		}
+		return tx.Model(job).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// pendingChanges returns the indexes of changes neither applied nor skipped yet, restricted to changeIDs
+// when it is non-empty.
+func pendingChanges(changes []models.RecategorizationChange, changeIDs []uint) []int {
+	selected := make(map[uint]bool, len(changeIDs))
+	for _, id := range changeIDs {
+		selected[id] = true
+	}
+
+	pending := []int{}
+	for i, change := range changes {
+		if change.Applied || change.Skipped {
+			continue
+		}
+		if len(selected) > 0 && !selected[change.ID] {
+			continue
+		}
+		pending = append(pending, i)
+	}
+	return pending
+}
+
+func (s *RecategorizationService) backend(workspaceID uint, strategy string) (categorizer.Categorizer, error) {
+	switch strategy {
+	case RecategorizeStrategyRules:
+		rules, err := s.ruleService.Rules(workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		return categorizer.RuleCategorizer{Rules: rules}, nil
+	case RecategorizeStrategyLocal:
+		return categorizer.LocalCategorizer{}, nil
+	case RecategorizeStrategyLLM:
+		selector, err := exampleSelectorFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		batch, err := batchOptionsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return categorizer.LLMCategorizer{Client: openAiService.NewClient(), Selector: selector, Batch: batch}, nil
+	default:
+		return nil, ErrUnknownRecategorizeStrategy
+	}
+}
+
+func (s *RecategorizationService) filterQuery(workspaceID uint, filter RecategorizationFilter, includeConfirmed bool) (*gorm.DB, error) {
+	query := s.db.Model(&models.Transaction{}).Where("workspace_id = ?", workspaceID)
+
+	if filter.From != "" {
+		from, err := time.Parse("2006-01-02", filter.From)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from date %q, use YYYY-MM-DD", ErrInvalidRecategorizeFilter, filter.From)
+		}
+		query = query.Where("date >= ?", from)
+	}
+	if filter.To != "" {
+		to, err := time.Parse("2006-01-02", filter.To)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to date %q, use YYYY-MM-DD", ErrInvalidRecategorizeFilter, filter.To)
+		}
+		query = query.Where("date < ?", to.AddDate(0, 0, 1))
+	}
+	if filter.Category != nil {
+		if *filter.Category == "" {
+			query = query.Where("category_id IS NULL")
+		} else {
+			query = query.Where("category_id IN (?)", categoryIDsByName(s.db, workspaceID, []string{*filter.Category}))
+		}
+	}
+	if text := strings.TrimSpace(filter.Description); text != "" {
+		query = query.Where("LOWER(description) LIKE ?", "%"+escapeLike(strings.ToLower(text))+"%")
+	}
+	if !includeConfirmed {
+		query = query.Where("user_confirmed = ?", false)
+	}
+	return query, nil
+}
+
+// loadExamples returns the workspace's categorized rows, newest first, excluding the rows being re-evaluated
+// and rows still labeled Missing.
+func (s *RecategorizationService) loadExamples(workspaceID uint, exclude []models.Transaction) ([]trainingcsv.Example, error) {
+	ids := make([]uint, 0, len(exclude))
+	for _, t := range exclude {
+		ids = append(ids, t.ID)
+	}
+
+	query := s.db.Preload("CategoryRef").
+		Where("workspace_id = ? AND category_id IS NOT NULL", workspaceID).
+		Where("category_id NOT IN (?)", categoryIDsByName(s.db, workspaceID, []string{models.MissingCategoryName}))
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN ?", ids)
+	}
+
+	var rows []models.Transaction
+	if err := query.Order("date DESC").Limit(maxWorkspaceExamplePool).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return transactionsToExamples(rows), nil
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds statements with the MySQL dialect without connecting, so query builders can be inspected.
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	return db
+}
+
+func TestPendingChanges(t *testing.T) {
+	changes := []models.RecategorizationChange{
+		{ID: 1},
+		{ID: 2, Applied: true},
+		{ID: 3, Skipped: true},
+		{ID: 4},
+		{ID: 5},
+	}
+
+	tests := []struct {
+		name      string
+		changeIDs []uint
+		want      []int
+	}{
+		{"all open changes", nil, []int{0, 3, 4}},
+		{"selected subset", []uint{4, 1}, []int{0, 3}},
+		{"already applied or skipped ids are ignored", []uint{2, 3, 5}, []int{4}},
+		{"unknown ids select nothing", []uint{99}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pendingChanges(changes, tt.changeIDs); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("pendingChanges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	done := []models.RecategorizationChange{{ID: 1, Applied: true}, {ID: 2, Skipped: true}}
+	if got := pendingChanges(done, nil); len(got) != 0 {
+		t.Fatalf("a fully resolved job should have no pending changes, got %v", got)
+	}
+}
+
+func TestRecategorizationFilterQuery(t *testing.T) {
+	s := &RecategorizationService{db: dryRunDB(t)}
+	empty := ""
+
+	tests := []struct {
+		name             string
+		filter           RecategorizationFilter
+		includeConfirmed bool
+		contains         []string
+		excludes         []string
+	}{
+		{
+			name:     "defaults to unconfirmed rows",
+			contains: []string{"workspace_id = 7", "user_confirmed = false"},
+			excludes: []string{"date >=", "category_id"},
+		},
+		{
+			name:             "date range is inclusive of to",
+			filter:           RecategorizationFilter{From: "2026-01-01", To: "2026-01-31"},
+			includeConfirmed: true,
+			contains:         []string{"date >= '2026-01-01", "date < '2026-02-01"},
+			excludes:         []string{"user_confirmed"},
+		},
+		{
+			name:     "empty category selects uncategorized rows",
+			filter:   RecategorizationFilter{Category: &empty},
+			contains: []string{"category_id IS NULL"},
+		},
+		{
+			name:     "description is a literal substring",
+			filter:   RecategorizationFilter{Description: " 50%_OFF "},
+			contains: []string{`LOWER(description) LIKE '%50\%\_off%'`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := s.filterQuery(7, tt.filter, tt.includeConfirmed)
+			if err != nil {
+				t.Fatalf("filterQuery: %v", err)
+			}
+			sql := query.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Find(&[]models.Transaction{})
+			})
+			for _, fragment := range tt.contains {
+				if !strings.Contains(sql, fragment) {
+					t.Errorf("SQL %q does not contain %q", sql, fragment)
+				}
+			}
+			for _, fragment := range tt.excludes {
+				if strings.Contains(sql, fragment) {
+					t.Errorf("SQL %q should not contain %q", sql, fragment)
+				}
+			}
+		})
+	}
+
+	if _, err := s.filterQuery(7, RecategorizationFilter{From: "01/02/2026"}, false); !errors.Is(err, ErrInvalidRecategorizeFilter) {
+		t.Fatalf("expected invalid from date to be rejected, got %v", err)
+	}
+	if _, err := s.filterQuery(7, RecategorizationFilter{To: "tomorrow"}, false); !errors.Is(err, ErrInvalidRecategorizeFilter) {
+		t.Fatalf("expected invalid to date to be rejected, got %v", err)
+	}
+}
+
+func TestResolveCategoryIDRejectsUnknownNames(t *testing.T) {
+	// The dry-run DB finds no rows, so every name is unknown.
+	db := dryRunDB(t)
+	if _, err := resolveCategoryID(db, 3, "Supermercdo"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+	if id, err := resolveCategoryID(db, 3, "  "); id != nil || err != nil {
+		t.Fatalf("blank name = %v, %v; want nil", id, err)
+	}
+}
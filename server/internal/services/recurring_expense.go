@@ -42,6 +42,14 @@ func (s *RecurringExpenseService) Create(expense *models.RecurringExpense) error
 	if expense.Amount <= 0 {
 		return errors.New("amount must be positive")
 	}
+	if err := validateAccountID(s.db, expense.WorkspaceID, expense.AccountID); err != nil {
+		return err
+	}
+	owner, err := newOwnerResolver(s.db, expense.WorkspaceID).name(expense.Owner)
+	if err != nil {
+		return err
+	}
+	expense.Owner = owner
 	return s.db.Create(expense).Error
 }
 
@@ -52,6 +60,14 @@ func (s *RecurringExpenseService) Update(expense *models.RecurringExpense) error
 	if expense.Amount <= 0 {
 		return errors.New("amount must be positive")
 	}
+	if err := validateAccountID(s.db, expense.WorkspaceID, expense.AccountID); err != nil {
+		return err
+	}
+	owner, err := newOwnerResolver(s.db, expense.WorkspaceID).name(expense.Owner)
+	if err != nil {
+		return err
+	}
+	expense.Owner = owner
 	return s.db.Save(expense).Error
 }
 
@@ -66,9 +82,12 @@ type RecurringExpenseSummary struct {
 	PendingAmount       float64 `json:"pending_amount"`
 	PreviousMonthTotal  float64 `json:"previous_month_total"`
 	ChangePercentage    float64 `json:"change_percentage"`
+	RealTerms           *RealTerms `json:"real_terms,omitempty"`
 }
 
-func (s *RecurringExpenseService) GetSummary(workspaceID uint, month time.Time) (*RecurringExpenseSummary, error) {
+// GetSummary totals the recurring expenses for month and compares them with the previous month. A non-nil
+// deflator restates both months in real terms, so the change reflects more than inflation.
+func (s *RecurringExpenseService) GetSummary(workspaceID uint, month time.Time, deflator *Deflator) (*RecurringExpenseSummary, error) {
 	expenses, err := s.List(workspaceID)
 	if err != nil {
 		return nil, err
@@ -96,6 +115,11 @@ func (s *RecurringExpenseService) GetSummary(workspaceID uint, month time.Time)
 		return nil, err
 	}
 
+	totalMonthly = deflator.Restate(totalMonthly, month)
+	paidAmount = deflator.Restate(paidAmount, month)
+	pendingAmount = deflator.Restate(pendingAmount, month)
+	prevTotal = deflator.Restate(prevTotal, prevMonth)
+
 	var changePercentage float64
 	if prevTotal > 0 {
 		changePercentage = ((totalMonthly - prevTotal) / prevTotal) * 100
@@ -107,6 +131,7 @@ func (s *RecurringExpenseService) GetSummary(workspaceID uint, month time.Time)
 		PendingAmount:      pendingAmount,
 		PreviousMonthTotal: prevTotal,
 		ChangePercentage:   changePercentage,
+		RealTerms:          deflator.RealTerms(),
 	}, nil
 }
 
@@ -136,11 +161,16 @@ func (s *RecurringExpenseService) MarkPaid(id, workspaceID uint) (*models.Recurr
 		Amount:      sql.NullFloat64{Float64: expense.Amount, Valid: true},
 		Type:        sql.NullString{String: "debit", Valid: true},
 		Category:    sql.NullString{String: categoryName, Valid: categoryName != ""},
+		CategoryID:  expense.CategoryID,
 		Owner:       sql.NullString{String: expense.Owner, Valid: expense.Owner != ""},
+		AccountID:   expense.AccountID,
 	}
 
 	// Use transaction to ensure atomicity
 	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := syncOwnerID(tx, transaction); err != nil {
+			return err
+		}
 		if err := tx.Create(transaction).Error; err != nil {
 			return err
 		}
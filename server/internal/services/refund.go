@@ -0,0 +1,293 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// refundLookbackDays is how far before a refund its purchase may be.
+const refundLookbackDays = 120
+
+var ErrInvalidRefund = errors.New("invalid refund")
+
+var refundKeywordPattern = regexp.MustCompile(`(?i)\b(devoluci[oó]n|devol|reintegro|reembolso|contracargo|anulaci[oó]n|reverso|chargeback|refund)\b`)
+
+// merchantNoise are words of bank descriptions that say nothing about the merchant.
+var merchantNoise = map[string]bool{
+	"compra": true, "compras": true, "devolucion": true, "devolución": true, "devol": true, "reintegro": true,
+	"reembolso": true, "contracargo": true, "anulacion": true, "anulación": true, "reverso": true, "pago": true,
+	"debito": true, "débito": true, "credito": true, "crédito": true, "visa": true, "master": true,
+	"mastercard": true, "tarjeta": true, "con": true, "los": true, "las": true, "del": true, "ars": true,
+	"usd": true, "cuota": true, "cuotas": true, "refund": true, "chargeback": true,
+}
+
+var merchantTokenPattern = regexp.MustCompile(`[\p{L}]{3,}`)
+
+// RefundCandidate is a credit that looks like a refund of an earlier debit.
+type RefundCandidate struct {
+	Refund   models.Transaction `json:"refund"`
+	Original models.Transaction `json:"original"`
+	Score    float64            `json:"score"`
+	Reasons  []string           `json:"reasons"`
+}
+
+type RefundService struct {
+	db *gorm.DB
+}
+
+func NewRefundService(db *gorm.DB) *RefundService {
+	return &RefundService{db: db}
+}
+
+// Candidates suggests, for each unlinked credit dated within [from, to), the earlier debit it most likely
+// refunds: same merchant words, an amount no larger than what is left to refund, and either a refund
+// keyword or the exact amount.
+func (s *RefundService) Candidates(workspaceID uint, from, to *time.Time) ([]RefundCandidate, error) {
+	creditQuery := s.db.Preload("CategoryRef").
+		Where("workspace_id = ? AND type = ?", workspaceID, "credit").
+		Where("id NOT IN (" + linkedTransactionIDsSQL + ")")
+	if from != nil {
+		creditQuery = creditQuery.Where("date >= ?", *from)
+	}
+	if to != nil {
+		creditQuery = creditQuery.Where("date < ?", *to)
+	}
+	var credits []models.Transaction
+	if err := creditQuery.Order("date DESC, id DESC").Find(&credits).Error; err != nil {
+		return nil, err
+	}
+	if len(credits) == 0 {
+		return []RefundCandidate{}, nil
+	}
+
+	earliest := credits[len(credits)-1].Date.AddDate(0, 0, -refundLookbackDays)
+	debitQuery := s.db.Preload("CategoryRef").
+		Where("workspace_id = ? AND type = ? AND date >= ?", workspaceID, "debit", earliest).
+		Where("id NOT IN (" + settledTransactionIDsSQL + ")")
+	if to != nil {
+		debitQuery = debitQuery.Where("date < ?", *to)
+	}
+	var debits []models.Transaction
+	if err := debitQuery.Find(&debits).Error; err != nil {
+		return nil, err
+	}
+
+	refunded, err := s.refundedAmounts(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return matchRefundCandidates(credits, debits, refunded), nil
+}
+
+// refundedAmounts totals the refunds already linked to each purchase.
+func (s *RefundService) refundedAmounts(workspaceID uint) (map[uint]float64, error) {
+	var rows []struct {
+		OriginalTransactionID uint
+		Amount                float64
+	}
+	err := s.db.Table("refund_links r").
+		Select("r.original_transaction_id, COALESCE(SUM(t.amount), 0) as amount").
+		Joins("JOIN transactions t ON t.id = r.refund_transaction_id").
+		Where("r.workspace_id = ?", workspaceID).
+		Group("r.original_transaction_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	amounts := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		amounts[row.OriginalTransactionID] = row.Amount
+	}
+	return amounts, nil
+}
+
+// matchRefundCandidates picks the best earlier debit for each credit. refunded holds what was already
+// refunded per debit, so a purchase is never refunded beyond its amount.
+func matchRefundCandidates(credits, debits []models.Transaction, refunded map[uint]float64) []RefundCandidate {
+	candidates := []RefundCandidate{}
+	for _, credit := range credits {
+		amount := credit.Amount.Float64
+		if amount <= 0 {
+			continue
+		}
+		creditWords := merchantWords(credit.Description.String)
+		keyword := refundKeywordPattern.MatchString(credit.Description.String)
+
+		var best *RefundCandidate
+		for _, debit := range debits {
+			if debit.Date.After(credit.Date) || credit.Date.Sub(debit.Date) > refundLookbackDays*24*time.Hour {
+				continue
+			}
+			if amount > debit.Amount.Float64-refunded[debit.ID]+allocationTolerance {
+				continue
+			}
+			overlap := wordOverlap(creditWords, merchantWords(debit.Description.String))
+			if overlap == 0 {
+				continue
+			}
+			exact := math.Abs(amount-debit.Amount.Float64) <= allocationTolerance
+			if !keyword && !exact {
+				continue
+			}
+
+			score := 0.4 * overlap
+			reasons := []string{"same merchant"}
+			if exact {
+				score += 0.4
+				reasons = append(reasons, "same amount")
+			} else {
+				reasons = append(reasons, "partial amount")
+			}
+			if keyword {
+				score += 0.2
+				reasons = append(reasons, "refund keyword")
+			}
+			if best == nil || score > best.Score || (score == best.Score && debit.Date.After(best.Original.Date)) {
+				best = &RefundCandidate{Refund: credit, Original: debit, Score: math.Round(score*100) / 100, Reasons: reasons}
+			}
+		}
+		if best != nil {
+			candidates = append(candidates, *best)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Refund.Date.After(candidates[j].Refund.Date) })
+	return candidates
+}
+
+// merchantWords extracts the lowercase words of a description that may name the merchant.
+func merchantWords(description string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range merchantTokenPattern.FindAllString(strings.ToLower(description), -1) {
+		if !merchantNoise[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// wordOverlap is the share of the smaller word set found in the other one.
+func wordOverlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	small, large := a, b
+	if len(b) < len(a) {
+		small, large = b, a
+	}
+	shared := 0
+	for word := range small {
+		if large[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(small))
+}
+
+// List returns the workspace's refund links with both transactions, newest first.
+func (s *RefundService) List(workspaceID uint) ([]models.RefundLink, error) {
+	links := []models.RefundLink{}
+	err := s.db.Preload("RefundTransaction.CategoryRef").Preload("OriginalTransaction.CategoryRef").
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at DESC, id DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// Link records refundID as a refund of originalID. The refund must be a later credit that, together with
+// earlier refunds of the same purchase, does not exceed the purchase amount. The refund of a split purchase
+// is reported pro rata against its allocations.
+func (s *RefundService) Link(workspaceID, refundID, originalID, userID uint) (*models.RefundLink, error) {
+	link := &models.RefundLink{
+		WorkspaceID:           workspaceID,
+		RefundTransactionID:   refundID,
+		OriginalTransactionID: originalID,
+		CreatedBy:             userID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var refund, original models.Transaction
+		if err := tx.Where("id = ? AND workspace_id = ?", refundID, workspaceID).First(&refund).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: refund transaction not found", ErrInvalidRefund)
+			}
+			return err
+		}
+		if err := tx.Where("id = ? AND workspace_id = ?", originalID, workspaceID).First(&original).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: original transaction not found", ErrInvalidRefund)
+			}
+			return err
+		}
+		if refund.Type.String != "credit" || original.Type.String != "debit" {
+			return fmt.Errorf("%w: a refund is a credit linked to a debit", ErrInvalidRefund)
+		}
+		if refund.Date.Before(original.Date) {
+			return fmt.Errorf("%w: the refund is dated before the purchase", ErrInvalidRefund)
+		}
+
+		var splits int64
+		if err := tx.Model(&models.TransactionAllocation{}).Where("transaction_id = ?", refund.ID).Count(&splits).Error; err != nil {
+			return err
+		}
+		if splits > 0 {
+			return fmt.Errorf("%w: a split credit cannot be a refund", ErrInvalidRefund)
+		}
+
+		linked, err := anyLinked(tx, refund.ID)
+		if err != nil {
+			return err
+		}
+		if linked {
+			return ErrTransactionLinked
+		}
+		var settled int64
+		if err := tx.Model(&models.Transaction{}).
+			Where("id = ? AND id IN ("+settledTransactionIDsSQL+")", original.ID).
+			Count(&settled).Error; err != nil {
+			return err
+		}
+		if settled > 0 {
+			return ErrTransactionLinked
+		}
+
+		var previous float64
+		if err := tx.Table("refund_links r").
+			Select("COALESCE(SUM(t.amount), 0)").
+			Joins("JOIN transactions t ON t.id = r.refund_transaction_id").
+			Where("r.original_transaction_id = ?", original.ID).
+			Scan(&previous).Error; err != nil {
+			return err
+		}
+		if previous+refund.Amount.Float64 > original.Amount.Float64+allocationTolerance {
+			return fmt.Errorf("%w: refunds would total %.2f, the purchase was %.2f",
+				ErrInvalidRefund, previous+refund.Amount.Float64, original.Amount.Float64)
+		}
+		return tx.Create(link).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Preload("RefundTransaction.CategoryRef").Preload("OriginalTransaction.CategoryRef").First(link, link.ID).Error
+	return link, err
+}
+
+// Unlink removes a refund link; the credit counts as income again.
+func (s *RefundService) Unlink(id, workspaceID uint) error {
+	result := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.RefundLink{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
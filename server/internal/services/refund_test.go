@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+)
+
+func TestMatchRefundCandidates(t *testing.T) {
+	debits := []models.Transaction{
+		transferTxn(1, "debit", 2, 45000, "COMPRA FALABELLA PALERMO"),
+		transferTxn(2, "debit", 5, 45000, "COMPRA GARBARINO"),
+		transferTxn(3, "debit", 8, 30000, "COMPRA FRAVEGA"),
+	}
+	credits := []models.Transaction{
+		transferTxn(10, "credit", 20, 45000, "DEVOLUCION COMPRA FALABELLA"),
+		transferTxn(11, "credit", 21, 10000, "REINTEGRO FRAVEGA"),   // more than is left to refund
+		transferTxn(12, "credit", 22, 4000, "FRAVEGA"),              // neither keyword nor exact amount
+		transferTxn(13, "credit", 1, 45000, "DEVOLUCION GARBARINO"), // before the purchase
+	}
+
+	candidates := matchRefundCandidates(credits, debits, map[uint]float64{3: 25000})
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1: %+v", len(candidates), candidates)
+	}
+	if c := candidates[0]; c.Refund.ID != 10 || c.Original.ID != 1 {
+		t.Fatalf("matched %d to %d, want 10 to 1", c.Refund.ID, c.Original.ID)
+	}
+}
+
+func TestWordOverlapIgnoresNoise(t *testing.T) {
+	if overlap := wordOverlap(merchantWords("COMPRA VISA"), merchantWords("DEVOLUCION COMPRA VISA")); overlap != 0 {
+		t.Fatalf("noise words should not count as a merchant match, got %v", overlap)
+	}
+	if overlap := wordOverlap(merchantWords("Mercado Libre"), merchantWords("DEVOLUCION MERCADO LIBRE SRL")); overlap != 1 {
+		t.Fatalf("overlap = %v, want 1", overlap)
+	}
+}
+
+func TestLedgerSpreadsRefundsOfSplitPurchases(t *testing.T) {
+	for _, fragment := range []string{
+		"LEFT JOIN transaction_allocations oa ON oa.transaction_id = o.id",
+		"ELSE -t.amount * oa.amount / o.amount END AS amount",
+		"ELSE oa.category_id END AS category_id",
+		"ELSE oa.area_id END AS area_id",
+		"COALESCE(NULLIF(oa.owner, ''), o.owner) END AS owner",
+	} {
+		if !strings.Contains(ledgerLinesSQL, fragment) {
+			t.Errorf("ledger SQL does not contain %q", fragment)
+		}
+	}
+}
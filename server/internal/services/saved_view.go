@@ -0,0 +1,245 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSavedView    = errors.New("invalid saved view")
+	ErrSavedViewVisibility = errors.New("only the creator of a saved view can change whether it is shared")
+)
+
+// SavedViewFilter is the stored search definition of a view. Dates can be fixed (month, or from / to as
+// YYYY-MM-DD, inclusive) or relative to the moment the view runs (last_days or last_months).
+type SavedViewFilter struct {
+	Month         string   `json:"month,omitempty"`
+	From          string   `json:"from,omitempty"`
+	To            string   `json:"to,omitempty"`
+	LastDays      int      `json:"last_days,omitempty"`
+	LastMonths    int      `json:"last_months,omitempty"`
+	Categories    []string `json:"categories,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Type          string   `json:"type,omitempty"`
+	MinAmount     *float64 `json:"min_amount,omitempty"`
+	MaxAmount     *float64 `json:"max_amount,omitempty"`
+	Text          string   `json:"q,omitempty"`
+	Owners        []string `json:"owners,omitempty"`
+	AreaIDs       []uint   `json:"area_ids,omitempty"`
+	AccountIDs    []uint   `json:"account_ids,omitempty"`
+	Confirmed     *bool    `json:"confirmed,omitempty"`
+	ImportBatchID *uint    `json:"import_batch_id,omitempty"`
+	Sort          string   `json:"sort,omitempty"`
+	Order         string   `json:"order,omitempty"`
+}
+
+// TransactionFilter resolves the definition into a concrete filter as of now.
+func (f SavedViewFilter) TransactionFilter(workspaceID uint, now time.Time) (TransactionFilter, error) {
+	filter := TransactionFilter{
+		WorkspaceID:   workspaceID,
+		Month:         f.Month,
+		Categories:    f.Categories,
+		Tags:          f.Tags,
+		Type:          f.Type,
+		MinAmount:     f.MinAmount,
+		MaxAmount:     f.MaxAmount,
+		Text:          f.Text,
+		Owners:        f.Owners,
+		AreaIDs:       f.AreaIDs,
+		AccountIDs:    f.AccountIDs,
+		Confirmed:     f.Confirmed,
+		ImportBatchID: f.ImportBatchID,
+		SortBy:        f.Sort,
+		SortOrder:     f.Order,
+	}
+
+	ranges := 0
+	for _, set := range []bool{f.Month != "", f.From != "" || f.To != "", f.LastDays > 0, f.LastMonths > 0} {
+		if set {
+			ranges++
+		}
+	}
+	if ranges > 1 {
+		return filter, fmt.Errorf("%w: use only one of month, from/to, last_days or last_months", ErrInvalidSavedView)
+	}
+	if f.LastDays < 0 || f.LastMonths < 0 {
+		return filter, fmt.Errorf("%w: last_days and last_months must be positive", ErrInvalidSavedView)
+	}
+	if f.Type != "" && f.Type != "debit" && f.Type != "credit" {
+		return filter, fmt.Errorf("%w: type must be debit or credit", ErrInvalidSavedView)
+	}
+
+	if f.From != "" {
+		from, err := time.Parse("2006-01-02", f.From)
+		if err != nil {
+			return filter, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidSavedView)
+		}
+		filter.From = &from
+	}
+	if f.To != "" {
+		to, err := time.Parse("2006-01-02", f.To)
+		if err != nil {
+			return filter, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidSavedView)
+		}
+		end := to.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow := today.AddDate(0, 0, 1)
+	switch {
+	case f.LastDays > 0:
+		from := tomorrow.AddDate(0, 0, -f.LastDays)
+		filter.From, filter.To = &from, &tomorrow
+	case f.LastMonths > 0:
+		from := tomorrow.AddDate(0, -f.LastMonths, 0)
+		filter.From, filter.To = &from, &tomorrow
+	}
+
+	if _, _, err := filterRange(filter); err != nil {
+		return filter, err
+	}
+	if _, err := parseTransactionSort(filter.SortBy, filter.SortOrder); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// SavedView is a stored view together with its decoded filter.
+type SavedView struct {
+	models.SavedView
+	Shared bool            `json:"shared"`
+	Filter SavedViewFilter `json:"filter"`
+}
+
+// SavedViewResult is the outcome of running a view: totals over every match plus one page of rows.
+type SavedViewResult struct {
+	View   SavedView              `json:"view"`
+	Totals TransactionTotals      `json:"totals"`
+	Page   *PaginatedTransactions `json:"page"`
+}
+
+type SavedViewService struct {
+	db                 *gorm.DB
+	transactionService *TransactionService
+}
+
+func NewSavedViewService(db *gorm.DB, transactionService *TransactionService) *SavedViewService {
+	return &SavedViewService{db: db, transactionService: transactionService}
+}
+
+func toSavedView(row models.SavedView) SavedView {
+	view := SavedView{SavedView: row, Shared: row.UserID == nil}
+	_ = json.Unmarshal([]byte(row.FilterJSON), &view.Filter)
+	return view
+}
+
+// visible limits a query to views the user may see: shared ones and their own.
+func (s *SavedViewService) visible(workspaceID, userID uint) *gorm.DB {
+	return s.db.Where("workspace_id = ? AND (user_id IS NULL OR user_id = ?)", workspaceID, userID)
+}
+
+func (s *SavedViewService) List(workspaceID, userID uint) ([]SavedView, error) {
+	var rows []models.SavedView
+	if err := s.visible(workspaceID, userID).Order("name ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	views := make([]SavedView, 0, len(rows))
+	for _, row := range rows {
+		views = append(views, toSavedView(row))
+	}
+	return views, nil
+}
+
+func (s *SavedViewService) FindByID(id, workspaceID, userID uint) (*SavedView, error) {
+	var row models.SavedView
+	if err := s.visible(workspaceID, userID).Where("id = ?", id).First(&row).Error; err != nil {
+		return nil, err
+	}
+	view := toSavedView(row)
+	return &view, nil
+}
+
+// Save validates and stores a new or existing view. shared views have no owner. A nil shared keeps the
+// stored visibility of an existing view (new views default to private); only the creator may change it.
+func (s *SavedViewService) Save(view *SavedView, userID uint, shared *bool) error {
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSavedView)
+	}
+	if _, err := view.Filter.TransactionFilter(view.WorkspaceID, time.Now()); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(view.Filter)
+	if err != nil {
+		return err
+	}
+	if err := applyVisibility(view, userID, shared); err != nil {
+		return err
+	}
+
+	view.FilterJSON = string(raw)
+	return s.db.Save(&view.SavedView).Error
+}
+
+// applyVisibility sets the owner and shared flag of view as requested by userID.
+func applyVisibility(view *SavedView, userID uint, shared *bool) error {
+	switch {
+	case view.ID == 0:
+		view.CreatedBy = userID
+		view.Shared = shared != nil && *shared
+	case shared == nil || *shared == view.Shared:
+		return nil
+	case view.CreatedBy != userID:
+		return ErrSavedViewVisibility
+	default:
+		view.Shared = *shared
+	}
+
+	view.UserID = nil
+	if !view.Shared {
+		view.UserID = &userID
+	}
+	return nil
+}
+
+func (s *SavedViewService) Delete(id, workspaceID, userID uint) error {
+	result := s.visible(workspaceID, userID).Where("id = ?", id).Delete(&models.SavedView{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Execute runs the view as of now, returning totals over all matches and the requested page.
+func (s *SavedViewService) Execute(id, workspaceID, userID uint, page, perPage int, cursor *string) (*SavedViewResult, error) {
+	view, err := s.FindByID(id, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := view.Filter.TransactionFilter(workspaceID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.transactionService.Totals(filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.Page, filter.PerPage, filter.Cursor = page, perPage, cursor
+	rows, _, err := s.transactionService.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &SavedViewResult{View: *view, Totals: *totals, Page: rows}, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSavedViewFilterRelativeRange(t *testing.T) {
+	now := time.Date(2026, 5, 31, 15, 0, 0, 0, time.UTC)
+	filter, err := SavedViewFilter{LastMonths: 3, Categories: []string{"Missing"}}.TransactionFilter(7, now)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if filter.WorkspaceID != 7 || len(filter.Categories) != 1 {
+		t.Fatalf("unexpected filter %+v", filter)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !filter.From.Equal(want) {
+		t.Fatalf("from = %v, want %v", filter.From, want)
+	}
+	if want := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC); !filter.To.Equal(want) {
+		t.Fatalf("to = %v, want %v", filter.To, want)
+	}
+}
+
+func TestSavedViewFilterValidation(t *testing.T) {
+	now := time.Now()
+	cases := []SavedViewFilter{
+		{Month: "2026-01", LastDays: 30},
+		{LastDays: -1},
+		{From: "01/02/2026"},
+		{Type: "transfer"},
+		{Sort: "password"},
+	}
+	for _, f := range cases {
+		_, err := f.TransactionFilter(1, now)
+		if !errors.Is(err, ErrInvalidSavedView) && !errors.Is(err, ErrInvalidTransactionQuery) {
+			t.Errorf("%+v: expected validation error, got %v", f, err)
+		}
+	}
+}
+
+func TestApplyVisibility(t *testing.T) {
+	yes, no := true, false
+	creator, member := uint(1), uint(2)
+
+	stored := func(shared bool) *SavedView {
+		view := &SavedView{Shared: shared}
+		view.ID = 10
+		view.CreatedBy = creator
+		if !shared {
+			view.UserID = &creator
+		}
+		return view
+	}
+
+	tests := []struct {
+		name       string
+		view       *SavedView
+		userID     uint
+		shared     *bool
+		wantErr    error
+		wantShared bool
+		wantOwner  *uint
+	}{
+		{"new view defaults to private", &SavedView{}, member, nil, nil, false, &member},
+		{"new shared view has no owner", &SavedView{}, member, &yes, nil, true, nil},
+		{"member omitting shared keeps a shared view shared", stored(true), member, nil, nil, true, nil},
+		{"member resending the same value", stored(true), member, &yes, nil, true, nil},
+		{"member cannot make a shared view private", stored(true), member, &no, ErrSavedViewVisibility, true, nil},
+		{"creator makes a shared view private", stored(true), creator, &no, nil, false, &creator},
+		{"creator shares a private view", stored(false), creator, &yes, nil, true, nil},
+		{"creator omitting shared keeps a private view private", stored(false), creator, nil, nil, false, &creator},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyVisibility(tt.view, tt.userID, tt.shared)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.view.Shared != tt.wantShared {
+				t.Errorf("shared = %v, want %v", tt.view.Shared, tt.wantShared)
+			}
+			if (tt.view.UserID == nil) != (tt.wantOwner == nil) || (tt.wantOwner != nil && *tt.view.UserID != *tt.wantOwner) {
+				t.Errorf("user_id = %v, want %v", tt.view.UserID, tt.wantOwner)
+			}
+		})
+	}
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// settlementTolerance ignores balances that are rounding noise.
+const settlementTolerance = 0.005
+
+var ErrInvalidSettlement = errors.New("invalid settlement")
+
+type SplitShareInput struct {
+	Owner   string  `json:"owner"`
+	Percent float64 `json:"percent"`
+}
+
+// OwnerCategoryAmount is an owner's spending in one category.
+type OwnerCategoryAmount struct {
+	CategoryID *uint   `json:"category_id"`
+	Category   string  `json:"category"`
+	Amount     float64 `json:"amount"`
+}
+
+// OwnerTotals is one owner's ledger within a period. The empty owner collects lines without one.
+type OwnerTotals struct {
+	Owner      string                `json:"owner"`
+	Spending   float64               `json:"spending"`
+	Income     float64               `json:"income"`
+	Net        float64               `json:"net"`
+	Count      int                   `json:"count"`
+	ByCategory []OwnerCategoryAmount `json:"by_category"`
+}
+
+// OwnerBalance is an owner's position in a settlement. Paid is the shared spending they paid, Share what
+// the split rules assign them. A positive Balance is owed to the owner; a negative one, owed by them.
+type OwnerBalance struct {
+	Owner            string  `json:"owner"`
+	Paid             float64 `json:"paid"`
+	Share            float64 `json:"share"`
+	PaymentsSent     float64 `json:"payments_sent"`
+	PaymentsReceived float64 `json:"payments_received"`
+	Balance          float64 `json:"balance"`
+}
+
+// SettlementTransfer is one payment that settles the period.
+type SettlementTransfer struct {
+	FromOwner string  `json:"from_owner"`
+	ToOwner   string  `json:"to_owner"`
+	Amount    float64 `json:"amount"`
+}
+
+type SettlementReport struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"` // inclusive
+	SharedTotal float64 `json:"shared_total"`
+	// UnassignedShared is shared spending without an owner; nobody is credited for paying it, so it is
+	// left out of the balances until an owner is set.
+	UnassignedShared float64                    `json:"unassigned_shared"`
+	Owners           []OwnerBalance             `json:"owners"`
+	Payments         []models.SettlementPayment `json:"payments"`
+	Transfers        []SettlementTransfer       `json:"transfers"`
+	RealTerms        *RealTerms                 `json:"real_terms,omitempty"`
+}
+
+type SettlementService struct {
+	db *gorm.DB
+}
+
+func NewSettlementService(db *gorm.DB) *SettlementService {
+	return &SettlementService{db: db}
+}
+
+// ownerLine is the ledger total of one day, owner, type and category.
+type ownerLine struct {
+	Date       time.Time
+	Owner      *string
+	Type       string
+	CategoryID *uint
+	Amount     float64
+	Count      int
+}
+
+// OwnerBreakdown totals the ledger within [from, to) per owner, with spending per category at the given
+// taxonomy level. A non-nil deflator restates amounts in real terms.
+func (s *SettlementService) OwnerBreakdown(workspaceID uint, from, to time.Time, level int, deflator *Deflator) ([]OwnerTotals, error) {
+	var rows []ownerLine
+	if err := ledgerLines(s.db).
+		Select("date, owner, type, category_id, COALESCE(SUM(amount), 0) as amount, COUNT(*) as count").
+		Where("workspace_id = ? AND date >= ? AND date < ?", workspaceID, from, to).
+		Group("date, owner, type, category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return foldOwnerTotals(rows, tree, level, deflator), nil
+}
+
+// foldOwnerTotals totals rows per owner, largest spender first, with each owner's categories by amount.
+func foldOwnerTotals(rows []ownerLine, tree *categoryTree, level int, deflator *Deflator) []OwnerTotals {
+	owners := []OwnerTotals{}
+	ownerIndex := map[string]int{}
+	categoryIndex := map[string]map[uint]int{}
+	for _, row := range rows {
+		owner := ""
+		if row.Owner != nil {
+			owner = *row.Owner
+		}
+		i, ok := ownerIndex[owner]
+		if !ok {
+			i = len(owners)
+			ownerIndex[owner] = i
+			categoryIndex[owner] = map[uint]int{}
+			owners = append(owners, OwnerTotals{Owner: owner, ByCategory: []OwnerCategoryAmount{}})
+		}
+		totals := &owners[i]
+		totals.Count += row.Count
+		amount := deflator.Restate(row.Amount, row.Date)
+		if row.Type == "credit" {
+			totals.Income += amount
+			continue
+		}
+		if row.Type != "debit" {
+			continue
+		}
+		totals.Spending += amount
+
+		var categoryID uint
+		name := "Uncategorized"
+		if row.CategoryID != nil {
+			if group, ok := tree.group(*row.CategoryID, level); ok {
+				categoryID, name = group.ID, group.Name
+			}
+		}
+		j, ok := categoryIndex[owner][categoryID]
+		if !ok {
+			j = len(totals.ByCategory)
+			categoryIndex[owner][categoryID] = j
+			entry := OwnerCategoryAmount{Category: name}
+			if categoryID != 0 {
+				id := categoryID
+				entry.CategoryID = &id
+			}
+			totals.ByCategory = append(totals.ByCategory, entry)
+		}
+		totals.ByCategory[j].Amount += amount
+	}
+
+	for i := range owners {
+		owners[i].Net = owners[i].Income - owners[i].Spending
+		sort.SliceStable(owners[i].ByCategory, func(a, b int) bool {
+			return owners[i].ByCategory[a].Amount > owners[i].ByCategory[b].Amount
+		})
+	}
+	sort.SliceStable(owners, func(a, b int) bool { return owners[a].Spending > owners[b].Spending })
+	return owners
+}
+
+func (s *SettlementService) ListRules(workspaceID uint) ([]models.SplitRule, error) {
+	rules := []models.SplitRule{}
+	err := s.db.Preload("Shares").Where("workspace_id = ?", workspaceID).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (s *SettlementService) FindRuleByID(id, workspaceID uint) (*models.SplitRule, error) {
+	var rule models.SplitRule
+	err := s.db.Preload("Shares").Where("id = ? AND workspace_id = ?", id, workspaceID).First(&rule).Error
+	return &rule, err
+}
+
+// SaveRule creates or updates a rule, replacing its shares. A category, or the workspace default, has at
+// most one rule.
+func (s *SettlementService) SaveRule(rule *models.SplitRule, inputs []SplitShareInput) error {
+	shares, err := buildSplitShares(inputs)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		owners := newOwnerResolver(tx, rule.WorkspaceID)
+		seen := map[string]bool{}
+		for i := range shares {
+			name, err := owners.name(shares[i].Owner)
+			if err != nil {
+				return err
+			}
+			if seen[name] {
+				return fmt.Errorf("%w: %s appears twice", ErrInvalidSettlement, name)
+			}
+			seen[name] = true
+			shares[i].Owner = name
+		}
+
+		if rule.CategoryID != nil {
+			var count int64
+			if err := tx.Model(&models.Category{}).
+				Where("id = ? AND workspace_id = ?", *rule.CategoryID, rule.WorkspaceID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("%w: unknown category", ErrInvalidSettlement)
+			}
+		}
+
+		var existing int64
+		query := tx.Model(&models.SplitRule{}).Where("workspace_id = ? AND id <> ?", rule.WorkspaceID, rule.ID)
+		if rule.CategoryID != nil {
+			query = query.Where("category_id = ?", *rule.CategoryID)
+		} else {
+			query = query.Where("category_id IS NULL")
+		}
+		if err := query.Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("%w: a split rule already exists for this category", ErrInvalidSettlement)
+		}
+
+		if err := tx.Omit("Shares").Save(rule).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("split_rule_id = ?", rule.ID).Delete(&models.SplitShare{}).Error; err != nil {
+			return err
+		}
+		for i := range shares {
+			shares[i].SplitRuleID = rule.ID
+		}
+		if err := tx.Create(&shares).Error; err != nil {
+			return err
+		}
+		rule.Shares = shares
+		return nil
+	})
+}
+
+func buildSplitShares(inputs []SplitShareInput) ([]models.SplitShare, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: a split rule needs at least one share", ErrInvalidSettlement)
+	}
+	seen := map[string]bool{}
+	total := 0.0
+	shares := make([]models.SplitShare, 0, len(inputs))
+	for _, in := range inputs {
+		owner := strings.TrimSpace(in.Owner)
+		if owner == "" {
+			return nil, fmt.Errorf("%w: every share needs an owner", ErrInvalidSettlement)
+		}
+		if seen[owner] {
+			return nil, fmt.Errorf("%w: %s appears twice", ErrInvalidSettlement, owner)
+		}
+		seen[owner] = true
+		if in.Percent <= 0 {
+			return nil, fmt.Errorf("%w: %s's percent must be positive", ErrInvalidSettlement, owner)
+		}
+		total += in.Percent
+		shares = append(shares, models.SplitShare{Owner: owner, Percent: in.Percent})
+	}
+	if math.Abs(total-100) > 0.01 {
+		return nil, fmt.Errorf("%w: shares add up to %.2f%%, not 100%%", ErrInvalidSettlement, total)
+	}
+	return shares, nil
+}
+
+func (s *SettlementService) DeleteRule(id, workspaceID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var rule models.SplitRule
+		if err := tx.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&rule).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("split_rule_id = ?", rule.ID).Delete(&models.SplitShare{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&rule).Error
+	})
+}
+
+// ListPayments returns settlement payments dated within [from, to), newest first. Either bound may be nil.
+func (s *SettlementService) ListPayments(workspaceID uint, from, to *time.Time) ([]models.SettlementPayment, error) {
+	query := s.db.Where("workspace_id = ?", workspaceID)
+	if from != nil {
+		query = query.Where("date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("date < ?", *to)
+	}
+	payments := []models.SettlementPayment{}
+	err := query.Order("date DESC, id DESC").Find(&payments).Error
+	return payments, err
+}
+
+func (s *SettlementService) RecordPayment(payment *models.SettlementPayment) error {
+	payment.FromOwner = strings.TrimSpace(payment.FromOwner)
+	payment.ToOwner = strings.TrimSpace(payment.ToOwner)
+	if payment.FromOwner == "" || payment.ToOwner == "" {
+		return fmt.Errorf("%w: from_owner and to_owner are required", ErrInvalidSettlement)
+	}
+	owners := newOwnerResolver(s.db, payment.WorkspaceID)
+	var err error
+	if payment.FromOwner, err = owners.name(payment.FromOwner); err != nil {
+		return err
+	}
+	if payment.ToOwner, err = owners.name(payment.ToOwner); err != nil {
+		return err
+	}
+	if payment.FromOwner == payment.ToOwner {
+		return fmt.Errorf("%w: an owner cannot pay themselves", ErrInvalidSettlement)
+	}
+	if payment.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidSettlement)
+	}
+	return s.db.Create(payment).Error
+}
+
+func (s *SettlementService) DeletePayment(id, workspaceID uint) error {
+	result := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.SettlementPayment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// settlementLine is the debit total of one day, owner and category.
+type settlementLine struct {
+	Date       time.Time
+	Owner      *string
+	CategoryID *uint
+	Amount     float64
+}
+
+// Report works out who owes whom for [from, to): shared spending is credited to whoever paid it and
+// charged to the owners by the split rule of its category (the nearest ancestor's, else the workspace
+// default), and settlement payments in the period count against the balances. A non-nil deflator restates
+// spending and payments in real terms; the listed payments keep their recorded amounts.
+func (s *SettlementService) Report(workspaceID uint, from, to time.Time, deflator *Deflator) (*SettlementReport, error) {
+	rules, err := s.ListRules(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []settlementLine
+	if err := ledgerLines(s.db).
+		Select("date, owner, category_id, COALESCE(SUM(amount), 0) as amount").
+		Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", workspaceID, from, to, "debit").
+		Group("date, owner, category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	payments, err := s.ListPayments(workspaceID, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := foldSettlement(rows, payments, rules, tree, deflator)
+	report.From = from.Format("2006-01-02")
+	report.To = to.AddDate(0, 0, -1).Format("2006-01-02")
+	return report, nil
+}
+
+// splitRuleFinder returns the rule of a category: its own, the nearest ancestor's, else the default rule.
+// It is nil when none applies, meaning the spending is personal.
+func splitRuleFinder(rules []models.SplitRule, tree *categoryTree) func(categoryID *uint) *models.SplitRule {
+	var defaultRule *models.SplitRule
+	ruleByCategory := map[uint]*models.SplitRule{}
+	for i := range rules {
+		if rules[i].CategoryID == nil {
+			defaultRule = &rules[i]
+		} else {
+			ruleByCategory[*rules[i].CategoryID] = &rules[i]
+		}
+	}
+	return func(categoryID *uint) *models.SplitRule {
+		if categoryID != nil {
+			path := tree.path(*categoryID)
+			for i := len(path) - 1; i >= 0; i-- {
+				if rule, ok := ruleByCategory[path[i].ID]; ok {
+					return rule
+				}
+			}
+		}
+		return defaultRule
+	}
+}
+
+// foldSettlement balances the period's debit lines and payments under rules.
+func foldSettlement(rows []settlementLine, payments []models.SettlementPayment, rules []models.SplitRule, tree *categoryTree, deflator *Deflator) *SettlementReport {
+	ruleFor := splitRuleFinder(rules, tree)
+	report := &SettlementReport{Payments: payments}
+	balances := map[string]*OwnerBalance{}
+	balance := func(owner string) *OwnerBalance {
+		if b, ok := balances[owner]; ok {
+			return b
+		}
+		b := &OwnerBalance{Owner: owner}
+		balances[owner] = b
+		return b
+	}
+
+	for _, row := range rows {
+		rule := ruleFor(row.CategoryID)
+		if rule == nil {
+			continue // personal spending
+		}
+		amount := deflator.Restate(row.Amount, row.Date)
+		report.SharedTotal += amount
+		if row.Owner == nil || *row.Owner == "" {
+			report.UnassignedShared += amount
+			continue
+		}
+		balance(*row.Owner).Paid += amount
+		for _, share := range rule.Shares {
+			balance(share.Owner).Share += amount * share.Percent / 100
+		}
+	}
+
+	for _, p := range payments {
+		amount := deflator.Restate(p.Amount, p.Date)
+		balance(p.FromOwner).PaymentsSent += amount
+		balance(p.ToOwner).PaymentsReceived += amount
+	}
+
+	net := map[string]float64{}
+	report.Owners = make([]OwnerBalance, 0, len(balances))
+	for owner, b := range balances {
+		b.Balance = b.Paid - b.Share + b.PaymentsSent - b.PaymentsReceived
+		net[owner] = b.Balance
+		report.Owners = append(report.Owners, *b)
+	}
+	sort.Slice(report.Owners, func(i, j int) bool { return report.Owners[i].Owner < report.Owners[j].Owner })
+	report.Transfers = settleBalances(net)
+	report.RealTerms = deflator.RealTerms()
+	return report
+}
+
+// settleBalances turns balances (positive: owed to the owner) into payments from debtors to creditors,
+// largest first, so each owner pays or is paid by as few others as possible.
+func settleBalances(balances map[string]float64) []SettlementTransfer {
+	type position struct {
+		owner  string
+		amount float64
+	}
+	var creditors, debtors []position
+	for owner, amount := range balances {
+		switch {
+		case amount > settlementTolerance:
+			creditors = append(creditors, position{owner, amount})
+		case amount < -settlementTolerance:
+			debtors = append(debtors, position{owner, -amount})
+		}
+	}
+	byAmount := func(list []position) {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].amount != list[j].amount {
+				return list[i].amount > list[j].amount
+			}
+			return list[i].owner < list[j].owner
+		})
+	}
+	byAmount(creditors)
+	byAmount(debtors)
+
+	transfers := []SettlementTransfer{}
+	for i, j := 0, 0; i < len(debtors) && j < len(creditors); {
+		amount := math.Min(debtors[i].amount, creditors[j].amount)
+		transfers = append(transfers, SettlementTransfer{
+			FromOwner: debtors[i].owner,
+			ToOwner:   creditors[j].owner,
+			Amount:    math.Round(amount*100) / 100,
+		})
+		debtors[i].amount -= amount
+		creditors[j].amount -= amount
+		if debtors[i].amount <= settlementTolerance {
+			i++
+		}
+		if creditors[j].amount <= settlementTolerance {
+			j++
+		}
+	}
+	return transfers
+}
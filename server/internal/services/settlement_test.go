@@ -0,0 +1,169 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"etl-banks-ar/internal/models"
+)
+
+func TestSettleBalances(t *testing.T) {
+	transfers := settleBalances(map[string]float64{"Ana": 150, "Juan": -100, "Sofi": -50, "Leo": 0.001})
+	if len(transfers) != 2 {
+		t.Fatalf("got %d transfers, want 2: %+v", len(transfers), transfers)
+	}
+	if tr := transfers[0]; tr.FromOwner != "Juan" || tr.ToOwner != "Ana" || tr.Amount != 100 {
+		t.Fatalf("unexpected first transfer: %+v", tr)
+	}
+	if tr := transfers[1]; tr.FromOwner != "Sofi" || tr.ToOwner != "Ana" || tr.Amount != 50 {
+		t.Fatalf("unexpected second transfer: %+v", tr)
+	}
+
+	if transfers := settleBalances(map[string]float64{"Ana": 0, "Juan": 0}); len(transfers) != 0 {
+		t.Fatalf("settled balances should need no transfers: %+v", transfers)
+	}
+}
+
+func TestBuildSplitShares(t *testing.T) {
+	if _, err := buildSplitShares([]SplitShareInput{{Owner: "Ana", Percent: 60}, {Owner: "Juan", Percent: 40}}); err != nil {
+		t.Fatalf("60/40 should be valid: %v", err)
+	}
+	invalid := [][]SplitShareInput{
+		nil,
+		{{Owner: "Ana", Percent: 50}, {Owner: "Juan", Percent: 40}},
+		{{Owner: "Ana", Percent: 50}, {Owner: "Ana", Percent: 50}},
+		{{Owner: " ", Percent: 100}},
+		{{Owner: "Ana", Percent: 110}, {Owner: "Juan", Percent: -10}},
+	}
+	for i, shares := range invalid {
+		if _, err := buildSplitShares(shares); err == nil {
+			t.Fatalf("case %d should be rejected", i)
+		}
+	}
+}
+
+func strPtr(v string) *string { return &v }
+
+// Hogar (1) and its subcategories split 70/30; Ocio (5) 50/50; everything else is personal.
+func sampleSplitRules() []models.SplitRule {
+	return []models.SplitRule{
+		{ID: 1, CategoryID: uintPtr(1), Shares: []models.SplitShare{{Owner: "Ana", Percent: 70}, {Owner: "Juan", Percent: 30}}},
+		{ID: 2, CategoryID: uintPtr(5), Shares: []models.SplitShare{{Owner: "Ana", Percent: 50}, {Owner: "Juan", Percent: 50}}},
+	}
+}
+
+func TestSplitRuleFinder(t *testing.T) {
+	tree := sampleCategoryTree()
+	rules := append(sampleSplitRules(), models.SplitRule{ID: 3, CategoryID: uintPtr(4)})
+
+	ruleFor := splitRuleFinder(rules, tree)
+	tests := []struct {
+		name       string
+		categoryID *uint
+		want       uint // 0 for no rule
+	}{
+		{"own rule", uintPtr(5), 2},
+		{"nearest ancestor", uintPtr(3), 1},
+		{"own rule beats the ancestor's", uintPtr(4), 3},
+		{"no rule and no default", uintPtr(6), 0},
+		{"uncategorized without default", nil, 0},
+	}
+	for _, tt := range tests {
+		rule := ruleFor(tt.categoryID)
+		if (rule == nil && tt.want != 0) || (rule != nil && rule.ID != tt.want) {
+			t.Errorf("%s: got %+v, want rule %d", tt.name, rule, tt.want)
+		}
+	}
+
+	withDefault := splitRuleFinder(append(rules, models.SplitRule{ID: 4}), tree)
+	for _, categoryID := range []*uint{uintPtr(6), nil} {
+		if rule := withDefault(categoryID); rule == nil || rule.ID != 4 {
+			t.Errorf("category %v: got %+v, want the default rule", categoryID, rule)
+		}
+	}
+	if rule := withDefault(uintPtr(3)); rule == nil || rule.ID != 1 {
+		t.Errorf("an ancestor's rule should beat the default, got %+v", rule)
+	}
+}
+
+func TestFoldSettlement(t *testing.T) {
+	jan := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	rows := []settlementLine{
+		{Date: jan, Owner: strPtr("Ana"), CategoryID: uintPtr(3), Amount: 1000}, // Luz, Hogar's 70/30
+		{Date: jan, Owner: strPtr("Juan"), CategoryID: uintPtr(5), Amount: 200}, // Ocio, 50/50
+		{Date: jan, CategoryID: uintPtr(5), Amount: 50},                         // shared but unassigned
+		{Date: jan, Owner: strPtr("Ana"), CategoryID: uintPtr(6), Amount: 400},  // personal
+	}
+	payments := []models.SettlementPayment{{FromOwner: "Juan", ToOwner: "Ana", Amount: 100, Date: jan}}
+
+	report := foldSettlement(rows, payments, sampleSplitRules(), sampleCategoryTree(), nil)
+	if report.SharedTotal != 1250 || report.UnassignedShared != 50 || report.RealTerms != nil {
+		t.Fatalf("shared = %v, unassigned = %v, real terms = %+v", report.SharedTotal, report.UnassignedShared, report.RealTerms)
+	}
+	want := []OwnerBalance{
+		{Owner: "Ana", Paid: 1000, Share: 800, PaymentsReceived: 100, Balance: 100},
+		{Owner: "Juan", Paid: 200, Share: 400, PaymentsSent: 100, Balance: -100},
+	}
+	if len(report.Owners) != len(want) {
+		t.Fatalf("owners = %+v", report.Owners)
+	}
+	for i, w := range want {
+		got := report.Owners[i]
+		if got.Owner != w.Owner || math.Abs(got.Paid-w.Paid) > 1e-9 || math.Abs(got.Share-w.Share) > 1e-9 ||
+			got.PaymentsSent != w.PaymentsSent || got.PaymentsReceived != w.PaymentsReceived || math.Abs(got.Balance-w.Balance) > 1e-9 {
+			t.Errorf("owner %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if len(report.Transfers) != 1 || report.Transfers[0] != (SettlementTransfer{FromOwner: "Juan", ToOwner: "Ana", Amount: 100}) {
+		t.Fatalf("transfers = %+v", report.Transfers)
+	}
+}
+
+func TestFoldSettlementInRealTerms(t *testing.T) {
+	jan := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+	deflator, err := newDeflator(map[string]float64{"2024-01": 100, "2024-02": 110}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []settlementLine{
+		{Date: jan, Owner: strPtr("Ana"), CategoryID: uintPtr(5), Amount: 1000},
+		{Date: feb, Owner: strPtr("Juan"), CategoryID: uintPtr(5), Amount: 100},
+	}
+	payments := []models.SettlementPayment{{FromOwner: "Juan", ToOwner: "Ana", Amount: 100, Date: jan}}
+
+	report := foldSettlement(rows, payments, sampleSplitRules(), sampleCategoryTree(), deflator)
+	if math.Abs(report.SharedTotal-1200) > 1e-9 {
+		t.Fatalf("shared total = %v, want 1200", report.SharedTotal)
+	}
+	// Ana: paid 1100, share 600, received 110.
+	if ana := report.Owners[0]; math.Abs(ana.Balance-390) > 1e-9 {
+		t.Fatalf("Ana's balance = %+v", ana)
+	}
+	if report.Payments[0].Amount != 100 || report.RealTerms == nil || report.RealTerms.BaseMonth != "2024-02" {
+		t.Fatalf("payments = %+v, real terms = %+v", report.Payments, report.RealTerms)
+	}
+}
+
+func TestFoldOwnerTotals(t *testing.T) {
+	jan := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	rows := []ownerLine{
+		{Date: jan, Owner: strPtr("Ana"), Type: "debit", CategoryID: uintPtr(3), Amount: 300, Count: 2},
+		{Date: jan, Owner: strPtr("Ana"), Type: "debit", CategoryID: uintPtr(4), Amount: 200, Count: 1},
+		{Date: jan, Owner: strPtr("Ana"), Type: "credit", Amount: 1000, Count: 1},
+		{Date: jan, Type: "debit", CategoryID: uintPtr(5), Amount: 50, Count: 1},
+	}
+
+	owners := foldOwnerTotals(rows, sampleCategoryTree(), 1, nil)
+	if len(owners) != 2 || owners[0].Owner != "Ana" || owners[1].Owner != "" {
+		t.Fatalf("owners = %+v", owners)
+	}
+	ana := owners[0]
+	if ana.Spending != 500 || ana.Income != 1000 || ana.Net != 500 || ana.Count != 4 {
+		t.Fatalf("Ana = %+v", ana)
+	}
+	if len(ana.ByCategory) != 1 || ana.ByCategory[0].Category != "Hogar" || ana.ByCategory[0].Amount != 500 {
+		t.Fatalf("Ana's categories at level 1 = %+v", ana.ByCategory)
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTagNameRequired = errors.New("tag name is required")
+	ErrTagNotFound     = errors.New("tag not found in this workspace")
+)
+
+type TagService struct {
+	db *gorm.DB
+}
+
+func NewTagService(db *gorm.DB) *TagService {
+	return &TagService{db: db}
+}
+
+// normalizeTagName trims and lowercases names so "Trabajo" and "trabajo " are the same tag.
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func normalizeTagNames(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if n := normalizeTagName(name); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (s *TagService) List(workspaceID uint) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := s.db.Where("workspace_id = ?", workspaceID).Order("name ASC").Find(&tags).Error
+	return tags, err
+}
+
+func (s *TagService) FindByID(id, workspaceID uint) (*models.Tag, error) {
+	var tag models.Tag
+	err := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&tag).Error
+	return &tag, err
+}
+
+func (s *TagService) Create(tag *models.Tag) error {
+	tag.Name = normalizeTagName(tag.Name)
+	if tag.Name == "" {
+		return ErrTagNameRequired
+	}
+	return s.db.Create(tag).Error
+}
+
+func (s *TagService) Update(tag *models.Tag) error {
+	tag.Name = normalizeTagName(tag.Name)
+	if tag.Name == "" {
+		return ErrTagNameRequired
+	}
+	return s.db.Save(tag).Error
+}
+
+// Delete removes the tag and its links to transactions.
+func (s *TagService) Delete(id, workspaceID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM transaction_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+}
+
+// SetTransactionTags replaces the tags of a transaction. Every tag must belong to the workspace.
+func (s *TagService) SetTransactionTags(workspaceID, transactionID uint, tagIDs []uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := s.db.Where("id = ? AND workspace_id = ?", transactionID, workspaceID).First(&transaction).Error; err != nil {
+		return nil, err
+	}
+
+	tags := []models.Tag{}
+	if len(tagIDs) > 0 {
+		if err := s.db.Where("workspace_id = ? AND id IN ?", workspaceID, tagIDs).Find(&tags).Error; err != nil {
+			return nil, err
+		}
+		unique := map[uint]bool{}
+		for _, id := range tagIDs {
+			unique[id] = true
+		}
+		if len(tags) != len(unique) {
+			return nil, ErrTagNotFound
+		}
+	}
+
+	if err := s.db.Model(&transaction).Association("Tags").Replace(tags); err != nil {
+		return nil, err
+	}
+	return NewTransactionService(s.db).FindByID(transactionID, workspaceID)
+}
+
+type TagTotal struct {
+	TagID       uint    `json:"tag_id"`
+	Name        string  `json:"name"`
+	Color       string  `json:"color"`
+	DebitTotal  float64 `json:"debit_total"`
+	CreditTotal float64 `json:"credit_total"`
+	Net         float64 `json:"net"`
+	Count       int     `json:"count"`
+}
+
+// Summary totals tagged transactions by tag within [from, to). Either bound may be nil. A transaction with
+// several tags counts toward each of them, so totals across tags can exceed the workspace total. A non-nil
+// deflator restates each day's amounts in real terms.
+func (s *TagService) Summary(workspaceID uint, from, to *time.Time, deflator *Deflator) ([]TagTotal, error) {
+	query := s.db.Table("tags g").
+		Select(`g.id as tag_id, g.name, g.color, t.date,
+			COALESCE(SUM(CASE WHEN t.type = 'debit' THEN t.amount ELSE 0 END), 0) as debit_total,
+			COALESCE(SUM(CASE WHEN t.type = 'credit' THEN t.amount ELSE 0 END), 0) as credit_total,
+			COUNT(t.id) as count`).
+		Joins("JOIN transaction_tags tt ON tt.tag_id = g.id").
+		Joins("JOIN transactions t ON t.id = tt.transaction_id").
+		Where("g.workspace_id = ? AND t.workspace_id = ?", workspaceID, workspaceID)
+	if from != nil {
+		query = query.Where("t.date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("t.date < ?", *to)
+	}
+
+	// Rows are per tag and day so each can be restated with its own month's CPI.
+	var rows []struct {
+		TagTotal
+		Date time.Time
+	}
+	if err := query.Group("g.id, g.name, g.color, t.date").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	totals := []TagTotal{}
+	indexByTag := map[uint]int{}
+	for _, row := range rows {
+		i, ok := indexByTag[row.TagID]
+		if !ok {
+			i = len(totals)
+			indexByTag[row.TagID] = i
+			totals = append(totals, TagTotal{TagID: row.TagID, Name: row.Name, Color: row.Color})
+		}
+		totals[i].DebitTotal += deflator.Restate(row.DebitTotal, row.Date)
+		totals[i].CreditTotal += deflator.Restate(row.CreditTotal, row.Date)
+		totals[i].Count += row.Count
+	}
+	for i := range totals {
+		totals[i].Net = totals[i].CreditTotal - totals[i].DebitTotal
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].DebitTotal > totals[j].DebitTotal })
+	return totals, nil
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"etl-banks-ar/internal/models"
@@ -10,6 +14,14 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrAllocationSum     = errors.New("allocation amounts must add up to the transaction amount")
+	ErrAllocationInvalid = errors.New("invalid allocation")
+)
+
+// allocationTolerance absorbs rounding when comparing allocation totals with the parent amount.
+const allocationTolerance = 0.005
+
 type TransactionService struct {
 	db *gorm.DB
 }
@@ -19,22 +31,39 @@ func NewTransactionService(db *gorm.DB) *TransactionService {
 }
 
 type TransactionFilter struct {
-	WorkspaceID uint
-	Month       string // YYYY-MM format
-	Categories  []string
-	Type        string
-	Page        int
-	PerPage     int
-	SortBy      string
-	SortOrder   string
+	WorkspaceID   uint
+	Month         string     // YYYY-MM format; alternative to From/To
+	From          *time.Time // inclusive
+	To            *time.Time // exclusive
+	Categories    []string
+	Tags          []string // tag names; a transaction matches when it carries any of them
+	Type          string
+	MinAmount     *float64
+	MaxAmount     *float64
+	Text          string // case-insensitive substring of the description
+	Owners        []string
+	AreaIDs       []uint // effective area: the transaction's, else its category's
+	AccountIDs    []uint
+	Confirmed     *bool
+	ImportBatchID *uint
+	Page          int
+	PerPage       int
+	SortBy        string // comma-separated columns; a "-" prefix sorts that column descending
+	SortOrder     string // direction for unprefixed columns: asc | desc (default)
+	// Cursor switches to keyset pagination when non-nil: "" requests the first page and NextCursor the
+	// following ones. Page is ignored in that mode.
+	Cursor *string
 }
 
+const maxTransactionsPerPage = 500
+
 type PaginatedTransactions struct {
 	Transactions []models.Transaction `json:"transactions"`
 	Page         int                  `json:"page"`
 	PerPage      int                  `json:"per_page"`
 	Total        int64                `json:"total"`
 	TotalPages   int                  `json:"total_pages"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
 }
 
 type TransactionSummary struct {
@@ -43,125 +72,345 @@ type TransactionSummary struct {
 	CreditTotal float64 `json:"credit_total"`
 }
 
+// List returns one page of transactions matching filter. The summary totals the debits and credits of the
+// matching ledger lines; it is only computed when the filter has a date range (month or from/to).
 func (s *TransactionService) List(filter TransactionFilter) (*PaginatedTransactions, *TransactionSummary, error) {
-	query := s.db.Model(&models.Transaction{}).Where("workspace_id = ?", filter.WorkspaceID)
-
-	// Filter by month
-	if filter.Month != "" {
-		startDate, err := time.Parse("2006-01", filter.Month)
-		if err == nil {
-			endDate := startDate.AddDate(0, 1, 0)
-			query = query.Where("date >= ? AND date < ?", startDate, endDate)
-		}
+	if filter.Month != "" && (filter.From != nil || filter.To != nil) {
+		return nil, nil, fmt.Errorf("%w: use either month or from/to", ErrInvalidTransactionQuery)
 	}
-
-	// Filter by category
-	if len(filter.Categories) > 0 {
-		query = query.Where("category IN ?", filter.Categories)
+	terms, err := parseTransactionSort(filter.SortBy, filter.SortOrder)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Filter by type
-	if filter.Type != "" {
-		query = query.Where("type = ?", filter.Type)
+	query, err := applyTransactionFilter(s.db, s.db.Model(&models.Transaction{}).Where("transactions.workspace_id = ?", filter.WorkspaceID), filter)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Count total
 	var total int64
-	query.Count(&total)
-
-	// Calculate summary
-	var summary TransactionSummary
-	var debitSum, creditSum sql.NullFloat64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
 
-	s.db.Model(&models.Transaction{}).
-		Where("workspace_id = ?", filter.WorkspaceID).
-		Where("date >= ? AND date < ?", filter.Month+"-01", filter.Month+"-01").
-		Where("type = ?", "debit").
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&debitSum)
+	summary, err := s.rangeSummary(filter)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	s.db.Model(&models.Transaction{}).
-		Where("workspace_id = ?", filter.WorkspaceID).
-		Where("date >= ? AND date < ?", filter.Month+"-01", filter.Month+"-01").
-		Where("type = ?", "credit").
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&creditSum)
+	perPage := filter.PerPage
+	if perPage < 1 {
+		perPage = 20
+	}
+	if perPage > maxTransactionsPerPage {
+		perPage = maxTransactionsPerPage
+	}
 
-	// Recalculate with proper date range for summary
-	if filter.Month != "" {
-		startDate, _ := time.Parse("2006-01", filter.Month)
-		endDate := startDate.AddDate(0, 1, 0)
+	page := query.Session(&gorm.Session{}).
+		Preload("CategoryRef").Preload("Tags").Preload("Allocations.CategoryRef").
+		Order(orderClause(terms))
 
-		s.db.Model(&models.Transaction{}).
-			Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", filter.WorkspaceID, startDate, endDate, "debit").
-			Select("COALESCE(SUM(amount), 0)").
-			Scan(&debitSum)
+	result := &PaginatedTransactions{PerPage: perPage, Total: total}
+	var transactions []models.Transaction
 
-		s.db.Model(&models.Transaction{}).
-			Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", filter.WorkspaceID, startDate, endDate, "credit").
-			Select("COALESCE(SUM(amount), 0)").
-			Scan(&creditSum)
+	if filter.Cursor != nil {
+		if *filter.Cursor != "" {
+			values, err := decodeTransactionCursor(terms, *filter.Cursor)
+			if err != nil {
+				return nil, nil, err
+			}
+			predicate, args := keysetPredicate(terms, values)
+			page = page.Where(predicate, args...)
+		}
+		if err := page.Limit(perPage + 1).Find(&transactions).Error; err != nil {
+			return nil, nil, err
+		}
+		if len(transactions) > perPage {
+			transactions = transactions[:perPage]
+			result.NextCursor = encodeTransactionCursor(terms, &transactions[len(transactions)-1])
+		}
+	} else {
+		result.Page = filter.Page
+		if result.Page < 1 {
+			result.Page = 1
+		}
+		offset := (result.Page - 1) * perPage
+		if err := page.Offset(offset).Limit(perPage).Find(&transactions).Error; err != nil {
+			return nil, nil, err
+		}
+		result.TotalPages = int(total) / perPage
+		if int(total)%perPage > 0 {
+			result.TotalPages++
+		}
 	}
 
-	summary.DebitTotal = debitSum.Float64
-	summary.CreditTotal = creditSum.Float64
-	summary.TotalAmount = debitSum.Float64 + creditSum.Float64
+	result.Transactions = transactions
+	return result, summary, nil
+}
 
-	// Sorting
-	sortBy := "date"
-	if filter.SortBy != "" {
-		sortBy = filter.SortBy
+// TransactionTotals aggregates every transaction matching a filter.
+type TransactionTotals struct {
+	Count       int64   `json:"count"`
+	DebitTotal  float64 `json:"debit_total"`
+	CreditTotal float64 `json:"credit_total"`
+	Net         float64 `json:"net"`
+}
+
+// Totals counts the transactions and sums the ledger lines matching filter, so transfers and card payments
+// are left out and refunds net against their purchase, as in rangeSummary; paging and sorting are ignored.
+func (s *TransactionService) Totals(filter TransactionFilter) (*TransactionTotals, error) {
+	query, err := totalsQuery(s.db, filter)
+	if err != nil {
+		return nil, err
 	}
-	sortOrder := "DESC"
-	if filter.SortOrder == "asc" {
-		sortOrder = "ASC"
+	var totals TransactionTotals
+	if err := query.Scan(&totals).Error; err != nil {
+		return nil, err
 	}
-	query = query.Order(sortBy + " " + sortOrder)
+	totals.Net = totals.CreditTotal - totals.DebitTotal
+	return &totals, nil
+}
 
-	// Pagination
-	page := filter.Page
-	if page < 1 {
-		page = 1
+func totalsQuery(db *gorm.DB, filter TransactionFilter) (*gorm.DB, error) {
+	query, err := ledgerFilter(db, filter)
+	if err != nil {
+		return nil, err
 	}
-	perPage := filter.PerPage
-	if perPage < 1 {
-		perPage = 20
+	return query.Select(`COUNT(DISTINCT t.transaction_id) as count,
+		COALESCE(SUM(CASE WHEN t.type = 'debit' THEN t.amount ELSE 0 END), 0) as debit_total,
+		COALESCE(SUM(CASE WHEN t.type = 'credit' THEN t.amount ELSE 0 END), 0) as credit_total`), nil
+}
+
+// rangeSummary totals the debits and credits of the ledger lines matching filter. It is zero unless the
+// filter has a month or from/to range.
+func (s *TransactionService) rangeSummary(filter TransactionFilter) (*TransactionSummary, error) {
+	from, to, err := filterRange(filter)
+	if err != nil {
+		return nil, err
 	}
 
-	offset := (page - 1) * perPage
-	var transactions []models.Transaction
-	query.Offset(offset).Limit(perPage).Find(&transactions)
+	var summary TransactionSummary
+	if from == nil && to == nil {
+		return &summary, nil
+	}
 
-	totalPages := int(total) / perPage
-	if int(total)%perPage > 0 {
-		totalPages++
+	var totals struct {
+		Debit  float64
+		Credit float64
+	}
+	query, err := ledgerFilter(s.db, filter)
+	if err != nil {
+		return nil, err
+	}
+	err = query.Select(`COALESCE(SUM(CASE WHEN t.type = 'debit' THEN t.amount ELSE 0 END), 0) as debit,
+		COALESCE(SUM(CASE WHEN t.type = 'credit' THEN t.amount ELSE 0 END), 0) as credit`).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
 	}
 
-	return &PaginatedTransactions{
-		Transactions: transactions,
-		Page:         page,
-		PerPage:      perPage,
-		Total:        total,
-		TotalPages:   totalPages,
-	}, &summary, nil
+	summary.DebitTotal = totals.Debit
+	summary.CreditTotal = totals.Credit
+	summary.TotalAmount = totals.Debit + totals.Credit
+	return &summary, nil
 }
 
+// Create stores t, linking it to the workspace category named by t.Category and the owner named by t.Owner,
+// and raises any budget alerts it triggers.
 func (s *TransactionService) Create(t *models.Transaction) error {
-	return s.db.Create(t).Error
+	if err := validateAccountID(s.db, t.WorkspaceID, t.AccountID); err != nil {
+		return err
+	}
+	if err := syncCategoryID(s.db, t); err != nil {
+		return err
+	}
+	if err := syncOwnerID(s.db, t); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+		return evaluateBudgetAlerts(tx, t.WorkspaceID, *t)
+	})
 }
 
 func (s *TransactionService) FindByID(id, workspaceID uint) (*models.Transaction, error) {
 	var transaction models.Transaction
-	err := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&transaction).Error
+	err := s.db.Preload("CategoryRef").Preload("Tags").Preload("Allocations.CategoryRef").
+		Where("id = ? AND workspace_id = ?", id, workspaceID).
+		First(&transaction).Error
 	return &transaction, err
 }
 
+// Update saves t, re-linking it to the workspace category and owner it names. A split transaction's amount
+// cannot change away from the sum of its allocations.
 func (s *TransactionService) Update(t *models.Transaction) error {
-	return s.db.Save(t).Error
+	if err := validateAccountID(s.db, t.WorkspaceID, t.AccountID); err != nil {
+		return err
+	}
+	if err := syncCategoryID(s.db, t); err != nil {
+		return err
+	}
+	if err := syncOwnerID(s.db, t); err != nil {
+		return err
+	}
+	var allocations []models.TransactionAllocation
+	if err := s.db.Where("transaction_id = ?", t.ID).Find(&allocations).Error; err != nil {
+		return err
+	}
+	if len(allocations) > 0 {
+		total := 0.0
+		for _, a := range allocations {
+			total += a.Amount
+		}
+		if math.Abs(total-t.Amount.Float64) > allocationTolerance {
+			return fmt.Errorf("%w: allocations total %.2f", ErrAllocationSum, total)
+		}
+	}
+	// Tags and allocations are managed through their own endpoints.
+	return s.db.Omit("Tags", "Allocations").Save(t).Error
+}
+
+// AllocationInput is one requested part of a split. Category is a name; CategoryID wins when both are set.
+type AllocationInput struct {
+	Amount     float64 `json:"amount"`
+	Category   string  `json:"category"`
+	CategoryID *uint   `json:"category_id"`
+	AreaID     *uint   `json:"area_id"`
+	Owner      string  `json:"owner"`
+	Note       string  `json:"note"`
+}
+
+// SetAllocations replaces the split of a transaction. Passing no allocations removes the split. Otherwise
+// there must be at least two positive allocations adding up to the transaction amount.
+func (s *TransactionService) SetAllocations(workspaceID, transactionID uint, inputs []AllocationInput) (*models.Transaction, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var parent models.Transaction
+		if err := tx.Where("id = ? AND workspace_id = ?", transactionID, workspaceID).First(&parent).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("transaction_id = ?", parent.ID).Delete(&models.TransactionAllocation{}).Error; err != nil {
+			return err
+		}
+		if len(inputs) == 0 {
+			return nil
+		}
+
+		allocations, err := buildAllocations(tx, &parent, inputs)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&allocations).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindByID(transactionID, workspaceID)
+}
+
+func buildAllocations(tx *gorm.DB, parent *models.Transaction, inputs []AllocationInput) ([]models.TransactionAllocation, error) {
+	if len(inputs) < 2 {
+		return nil, fmt.Errorf("%w: a split needs at least two allocations", ErrAllocationInvalid)
+	}
+	if parent.Amount.Float64 <= 0 {
+		return nil, fmt.Errorf("%w: only transactions with a positive amount can be split", ErrAllocationInvalid)
+	}
+	// A transfer, card payment or refund is reported from its link, so splitting it would count it twice.
+	linked, err := anyLinked(tx, parent.ID)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		return nil, fmt.Errorf("%w: unlink it before splitting it", ErrTransactionLinked)
+	}
+
+	categories := newCategoryResolver(tx, parent.WorkspaceID)
+	owners := newOwnerResolver(tx, parent.WorkspaceID)
+	allocations := make([]models.TransactionAllocation, 0, len(inputs))
+	total := 0.0
+	for i, in := range inputs {
+		if in.Amount <= 0 {
+			return nil, fmt.Errorf("%w: allocation %d must have a positive amount", ErrAllocationInvalid, i+1)
+		}
+		total += in.Amount
+
+		categoryID := in.CategoryID
+		if categoryID != nil {
+			var count int64
+			if err := tx.Model(&models.Category{}).
+				Where("id = ? AND workspace_id = ?", *categoryID, parent.WorkspaceID).
+				Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, fmt.Errorf("%w: allocation %d references an unknown category", ErrAllocationInvalid, i+1)
+			}
+		} else {
+			resolved, err := categories.resolve(in.Category)
+			if err != nil {
+				return nil, err
+			}
+			categoryID = resolved
+		}
+		if in.AreaID != nil {
+			var count int64
+			if err := tx.Model(&models.Area{}).
+				Where("id = ? AND workspace_id = ?", *in.AreaID, parent.WorkspaceID).
+				Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, fmt.Errorf("%w: allocation %d references an unknown area", ErrAllocationInvalid, i+1)
+			}
+		}
+
+		owner, err := owners.name(in.Owner)
+		if err != nil {
+			return nil, err
+		}
+
+		allocations = append(allocations, models.TransactionAllocation{
+			TransactionID: parent.ID,
+			WorkspaceID:   parent.WorkspaceID,
+			Amount:        in.Amount,
+			CategoryID:    categoryID,
+			AreaID:        in.AreaID,
+			Owner:         owner,
+			Note:          strings.TrimSpace(in.Note),
+		})
+	}
+
+	if math.Abs(total-parent.Amount.Float64) > allocationTolerance {
+		return nil, fmt.Errorf("%w: allocations total %.2f, transaction is %.2f", ErrAllocationSum, total, parent.Amount.Float64)
+	}
+	return allocations, nil
 }
 
 func (s *TransactionService) Delete(id, workspaceID uint) error {
-	return s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.Transaction{}).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		owned := tx.Model(&models.Transaction{}).Select("id").Where("id = ? AND workspace_id = ?", id, workspaceID)
+		if err := tx.Exec("DELETE FROM transaction_tags WHERE transaction_id IN (?)", owned).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("transaction_id IN (?)", owned).Delete(&models.TransactionAllocation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("debit_transaction_id IN (?) OR credit_transaction_id IN (?)", owned, owned).
+			Delete(&models.TransferLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("transaction_id IN (?) OR card_transaction_id IN (?)", owned, owned).
+			Delete(&models.CardPayment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("refund_transaction_id IN (?) OR original_transaction_id IN (?)", owned, owned).
+			Delete(&models.RefundLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.Transaction{}).Error
+	})
 }
 
 type CategorySummary struct {
@@ -177,6 +426,7 @@ type MonthlySummary struct {
 	TotalIncome   float64           `json:"total_income"`
 	Net           float64           `json:"net"`
 	ByCategory    []CategorySummary `json:"by_category"`
+	RealTerms     *RealTerms        `json:"real_terms,omitempty"`
 }
 
 type MonthlyCategoryAmount struct {
@@ -196,9 +446,12 @@ type YearlySummary struct {
 	TotalIncome   float64                 `json:"total_income"`
 	Net           float64                 `json:"net"`
 	ByCategory    []YearlyCategorySummary `json:"by_category"`
+	RealTerms     *RealTerms              `json:"real_terms,omitempty"`
 }
 
-func (s *TransactionService) GetMonthlySummary(workspaceID uint, month string) (*MonthlySummary, error) {
+// GetMonthlySummary totals the month; ByCategory groups categories at the given taxonomy level
+// (see ParseCategoryLevel). A non-nil deflator restates amounts in real terms.
+func (s *TransactionService) GetMonthlySummary(workspaceID uint, month string, level int, deflator *Deflator) (*MonthlySummary, error) {
 	startDate, err := time.Parse("2006-01", month)
 	if err != nil {
 		return nil, err
@@ -207,54 +460,75 @@ func (s *TransactionService) GetMonthlySummary(workspaceID uint, month string) (
 
 	var debitTotal, creditTotal sql.NullFloat64
 
-	s.db.Model(&models.Transaction{}).
+	ledgerLines(s.db).
 		Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", workspaceID, startDate, endDate, "debit").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&debitTotal)
 
-	s.db.Model(&models.Transaction{}).
+	ledgerLines(s.db).
 		Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", workspaceID, startDate, endDate, "credit").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&creditTotal)
 
 	// Get by category
 	var categoryResults []struct {
-		Category string
-		Amount   float64
-		Count    int
+		CategoryID *uint
+		Amount     float64
+		Count      int
 	}
 
-	s.db.Model(&models.Transaction{}).
-		Select("category, COALESCE(SUM(amount), 0) as amount, COUNT(*) as count").
+	ledgerLines(s.db).
+		Select("category_id, COALESCE(SUM(amount), 0) as amount, COUNT(*) as count").
 		Where("workspace_id = ? AND date >= ? AND date < ?", workspaceID, startDate, endDate).
-		Group("category").
+		Group("category_id").
 		Scan(&categoryResults)
 
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
 	totalAmount := debitTotal.Float64 + creditTotal.Float64
 	var categories []CategorySummary
+	indexByName := map[string]int{}
 	for _, r := range categoryResults {
-		pct := 0.0
+		name := ""
+		if r.CategoryID != nil {
+			if group, ok := tree.group(*r.CategoryID, level); ok {
+				name = group.Name
+			}
+		}
+		i, exists := indexByName[name]
+		if !exists {
+			i = len(categories)
+			indexByName[name] = i
+			categories = append(categories, CategorySummary{Category: name})
+		}
+		categories[i].Amount += r.Amount
+		categories[i].Count += r.Count
+	}
+	for i := range categories {
 		if totalAmount > 0 {
-			pct = (r.Amount / totalAmount) * 100
+			categories[i].Percentage = (categories[i].Amount / totalAmount) * 100
 		}
-		categories = append(categories, CategorySummary{
-			Category:   r.Category,
-			Amount:     r.Amount,
-			Count:      r.Count,
-			Percentage: pct,
-		})
+		categories[i].Amount = deflator.Restate(categories[i].Amount, startDate)
 	}
 
+	spending := deflator.Restate(debitTotal.Float64, startDate)
+	income := deflator.Restate(creditTotal.Float64, startDate)
 	return &MonthlySummary{
 		Month:         month,
-		TotalSpending: debitTotal.Float64,
-		TotalIncome:   creditTotal.Float64,
-		Net:           creditTotal.Float64 - debitTotal.Float64,
+		TotalSpending: spending,
+		TotalIncome:   income,
+		Net:           income - spending,
 		ByCategory:    categories,
+		RealTerms:     deflator.RealTerms(),
 	}, nil
 }
 
-func (s *TransactionService) GetYearlySummary(workspaceID uint, year string) (*YearlySummary, error) {
+// GetYearlySummary totals the year; ByCategory groups categories at the given taxonomy level. A non-nil
+// deflator restates each month's amounts in real terms.
+func (s *TransactionService) GetYearlySummary(workspaceID uint, year string, level int, deflator *Deflator) (*YearlySummary, error) {
 	startDate, err := time.Parse("2006", year)
 	if err != nil {
 		return nil, err
@@ -263,29 +537,34 @@ func (s *TransactionService) GetYearlySummary(workspaceID uint, year string) (*Y
 
 	var debitTotal, creditTotal sql.NullFloat64
 
-	s.db.Model(&models.Transaction{}).
+	ledgerLines(s.db).
 		Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", workspaceID, startDate, endDate, "debit").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&debitTotal)
 
-	s.db.Model(&models.Transaction{}).
+	ledgerLines(s.db).
 		Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", workspaceID, startDate, endDate, "credit").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&creditTotal)
 
+	// Rows are per day and bucketed into months in Go, which works on any SQL dialect.
 	var categoryRows []struct {
-		Category string
-		Month    string
-		Amount   float64
+		CategoryID *uint
+		Date       time.Time
+		Amount     float64
 	}
 
-	s.db.Model(&models.Transaction{}).
-		Select("category, DATE_FORMAT(MIN(date), '%Y-%m-01') as month, COALESCE(SUM(amount), 0) as amount").
+	ledgerLines(s.db).
+		Select("category_id, date, COALESCE(SUM(amount), 0) as amount").
 		Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", workspaceID, startDate, endDate, "debit").
-		Group("category, YEAR(date), MONTH(date)").
-		Order("amount DESC, month ASC").
+		Group("category_id, date").
 		Scan(&categoryRows)
 
+	tree, err := loadCategoryTree(s.db, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
 	monthlyTemplate := make([]MonthlyCategoryAmount, 12)
 	for i := range monthlyTemplate {
 		monthlyTemplate[i] = MonthlyCategoryAmount{
@@ -296,7 +575,12 @@ func (s *TransactionService) GetYearlySummary(workspaceID uint, year string) (*Y
 
 	categoriesByName := map[string]*YearlyCategorySummary{}
 	for _, row := range categoryRows {
-		name := row.Category
+		name := ""
+		if row.CategoryID != nil {
+			if group, ok := tree.group(*row.CategoryID, level); ok {
+				name = group.Name
+			}
+		}
 		if name == "" {
 			name = "Uncategorized"
 		}
@@ -312,16 +596,14 @@ func (s *TransactionService) GetYearlySummary(workspaceID uint, year string) (*Y
 			categoriesByName[name] = summary
 		}
 
-		rowMonth, err := time.Parse("2006-01-02", row.Month)
-		if err != nil {
-			continue
-		}
+		rowMonth := bucketStart(row.Date, BucketMonth)
+		amount := deflator.Restate(row.Amount, rowMonth)
 
 		monthIndex := int(rowMonth.Month()) - 1
 		if monthIndex >= 0 && monthIndex < len(summary.Monthly) {
-			summary.Monthly[monthIndex].Amount = row.Amount
+			summary.Monthly[monthIndex].Amount += amount
 		}
-		summary.Amount += row.Amount
+		summary.Amount += amount
 	}
 
 	categories := make([]YearlyCategorySummary, 0, len(categoriesByName))
@@ -333,29 +615,47 @@ func (s *TransactionService) GetYearlySummary(workspaceID uint, year string) (*Y
 		return categories[i].Amount > categories[j].Amount
 	})
 
+	spending, income := debitTotal.Float64, creditTotal.Float64
+	if deflator != nil {
+		// The category rows cover every debit; income needs its own monthly breakdown.
+		spending = 0
+		for _, category := range categories {
+			spending += category.Amount
+		}
+
+		var incomeRows []struct {
+			Date   time.Time
+			Amount float64
+		}
+		if err := ledgerLines(s.db).
+			Select("date, COALESCE(SUM(amount), 0) as amount").
+			Where("workspace_id = ? AND date >= ? AND date < ? AND type = ?", workspaceID, startDate, endDate, "credit").
+			Group("date").
+			Scan(&incomeRows).Error; err != nil {
+			return nil, err
+		}
+		income = 0
+		for _, row := range incomeRows {
+			income += deflator.Restate(row.Amount, row.Date)
+		}
+	}
+
 	return &YearlySummary{
 		Year:          year,
-		TotalSpending: debitTotal.Float64,
-		TotalIncome:   creditTotal.Float64,
-		Net:           creditTotal.Float64 - debitTotal.Float64,
+		TotalSpending: spending,
+		TotalIncome:   income,
+		Net:           income - spending,
 		ByCategory:    categories,
+		RealTerms:     deflator.RealTerms(),
 	}, nil
 }
 
 func (s *TransactionService) GetCategories(workspaceID uint) ([]string, error) {
 	var categories []string
-	err := s.db.Model(&models.Transaction{}).
-		Where("workspace_id = ?", workspaceID).
-		Distinct("category").
-		Pluck("category", &categories).Error
+	err := s.db.Table("transactions t").
+		Joins("JOIN categories c ON c.id = t.category_id").
+		Where("t.workspace_id = ?", workspaceID).
+		Distinct("c.name").
+		Pluck("c.name", &categories).Error
 	return categories, err
 }
-
-func (s *TransactionService) GetOwners(workspaceID uint) ([]string, error) {
-	var owners []string
-	err := s.db.Model(&models.Transaction{}).
-		Where("workspace_id = ? AND owner IS NOT NULL AND owner != ''", workspaceID).
-		Distinct("owner").
-		Pluck("owner", &owners).Error
-	return owners, err
-}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"etl-banks-ar/internal/models"
+)
+
+// Bucket sizes of an aggregation. Weeks start on Monday.
+const (
+	BucketDay     = "day"
+	BucketWeek    = "week"
+	BucketMonth   = "month"
+	BucketQuarter = "quarter"
+	BucketYear    = "year"
+)
+
+// Dimensions an aggregation can group by within each bucket.
+const (
+	GroupByCategory = "category"
+	GroupByArea     = "area"
+	GroupByOwner    = "owner"
+	GroupByAccount  = "account"
+	GroupByTag      = "tag"
+)
+
+// maxAggregationBuckets keeps a fine bucket over a long range from producing an unbounded response.
+const maxAggregationBuckets = 1000
+
+var ErrInvalidAggregation = errors.New("invalid aggregation")
+
+// AggregationQuery asks for ledger totals within [From, To) per Bucket, optionally split by GroupBy. Level
+// is the category taxonomy level used when grouping by category.
+type AggregationQuery struct {
+	From    time.Time
+	To      time.Time
+	Bucket  string
+	GroupBy string
+	Level   int
+}
+
+// AggregationTotals are the debits, credits and ledger line count of a bucket or group.
+type AggregationTotals struct {
+	Debit  float64 `json:"debit"`
+	Credit float64 `json:"credit"`
+	Net    float64 `json:"net"`
+	Count  int     `json:"count"`
+}
+
+func (t *AggregationTotals) add(kind string, amount float64, count int) {
+	switch kind {
+	case "debit":
+		t.Debit += amount
+	case "credit":
+		t.Credit += amount
+	}
+	t.Net = t.Credit - t.Debit
+	t.Count += count
+}
+
+// AggregationGroup is one value of the grouping dimension. Key is empty for the "none" group (no category,
+// area, owner, account or tag).
+type AggregationGroup struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	AggregationTotals
+}
+
+type AggregationBucket struct {
+	Start string `json:"start"`
+	End   string `json:"end"` // inclusive
+	AggregationTotals
+	Groups []AggregationGroup `json:"groups,omitempty"`
+}
+
+type Aggregation struct {
+	From      string              `json:"from"`
+	To        string              `json:"to"` // inclusive
+	Bucket    string              `json:"bucket"`
+	GroupBy   string              `json:"group_by,omitempty"`
+	Totals    AggregationTotals   `json:"totals"`
+	Buckets   []AggregationBucket `json:"buckets"`
+	RealTerms *RealTerms          `json:"real_terms,omitempty"`
+}
+
+// bucketStart returns the start of the bucket containing t. It is computed in Go so aggregations work on
+// any SQL dialect.
+func bucketStart(t time.Time, bucket string) time.Time {
+	year, month, day := t.Date()
+	switch bucket {
+	case BucketWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case BucketMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case BucketQuarter:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, t.Location())
+	case BucketYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+func nextBucket(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	case BucketQuarter:
+		return start.AddDate(0, 3, 0)
+	case BucketYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// bucketRanges lists the consecutive buckets covering [from, to). The first and last are clipped to the
+// range.
+func bucketRanges(from, to time.Time, bucket string) ([]DateRange, error) {
+	var ranges []DateRange
+	for start := bucketStart(from, bucket); start.Before(to); start = nextBucket(start, bucket) {
+		if len(ranges) == maxAggregationBuckets {
+			return nil, fmt.Errorf("%w: more than %d %s buckets; use a larger bucket or a shorter range",
+				ErrInvalidAggregation, maxAggregationBuckets, bucket)
+		}
+		r := DateRange{From: start, To: nextBucket(start, bucket)}
+		if r.From.Before(from) {
+			r.From = from
+		}
+		if r.To.After(to) {
+			r.To = to
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (q AggregationQuery) validate() error {
+	switch q.Bucket {
+	case BucketDay, BucketWeek, BucketMonth, BucketQuarter, BucketYear:
+	default:
+		return fmt.Errorf("%w: bucket must be day, week, month, quarter or year", ErrInvalidAggregation)
+	}
+	switch q.GroupBy {
+	case "", GroupByCategory, GroupByArea, GroupByOwner, GroupByAccount, GroupByTag:
+	default:
+		return fmt.Errorf("%w: group_by must be category, area, owner, account or tag", ErrInvalidAggregation)
+	}
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidAggregation)
+	}
+	return nil
+}
+
+// aggregationRow is ledger lines summed per day, type and grouping column.
+type aggregationRow struct {
+	Date       time.Time
+	Type       string
+	CategoryID *uint
+	AreaID     *uint
+	Owner      *string
+	AccountID  *uint
+	TagID      *uint
+	Amount     float64
+	Count      int
+}
+
+// Aggregate totals ledger lines per bucket and, with GroupBy, per group within each bucket. A transaction
+// with several tags counts toward each of them. A non-nil deflator restates amounts in real terms.
+func (s *TransactionService) Aggregate(workspaceID uint, q AggregationQuery, deflator *Deflator) (*Aggregation, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	ranges, err := bucketRanges(q.From, q.To, q.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := "t.date, t.type"
+	switch q.GroupBy {
+	case GroupByCategory:
+		columns += ", t.category_id"
+	case GroupByArea:
+		columns += ", t.area_id, t.category_id"
+	case GroupByOwner:
+		columns += ", t.owner"
+	case GroupByAccount:
+		columns += ", t.account_id"
+	case GroupByTag:
+		columns += ", tt.tag_id"
+	}
+	rows, err := s.aggregationRows(workspaceID, q, columns)
+	if err != nil {
+		return nil, err
+	}
+	totalRows := rows
+	if q.GroupBy == GroupByTag {
+		// A line with several tags appears once per tag; take the bucket totals without the tag join.
+		if totalRows, err = s.aggregationRows(workspaceID, AggregationQuery{From: q.From, To: q.To}, "t.date, t.type"); err != nil {
+			return nil, err
+		}
+	}
+
+	groupOf, err := s.aggregationGrouper(workspaceID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Aggregation{
+		From:    q.From.Format("2006-01-02"),
+		To:      q.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		Bucket:  q.Bucket,
+		GroupBy: q.GroupBy,
+		Buckets: make([]AggregationBucket, len(ranges)),
+	}
+	// Keyed by date string: rows may come back in a different time.Location than the query bounds.
+	bucketIndex := make(map[string]int, len(ranges))
+	groups := make([]map[string]*AggregationGroup, len(ranges))
+	for i, r := range ranges {
+		bucketIndex[bucketStart(r.From, q.Bucket).Format("2006-01-02")] = i
+		result.Buckets[i].Start = r.From.Format("2006-01-02")
+		result.Buckets[i].End = r.To.AddDate(0, 0, -1).Format("2006-01-02")
+		groups[i] = map[string]*AggregationGroup{}
+	}
+
+	for _, row := range totalRows {
+		if i, ok := bucketIndex[bucketStart(row.Date, q.Bucket).Format("2006-01-02")]; ok {
+			result.Buckets[i].add(row.Type, deflator.Restate(row.Amount, row.Date), row.Count)
+		}
+	}
+	for _, row := range rows {
+		i, ok := bucketIndex[bucketStart(row.Date, q.Bucket).Format("2006-01-02")]
+		if !ok || groupOf == nil {
+			continue
+		}
+		amount := deflator.Restate(row.Amount, row.Date)
+		key, name := groupOf(row)
+		group, ok := groups[i][key]
+		if !ok {
+			group = &AggregationGroup{Key: key, Name: name}
+			groups[i][key] = group
+		}
+		group.add(row.Type, amount, row.Count)
+	}
+
+	for i := range result.Buckets {
+		for _, group := range groups[i] {
+			result.Buckets[i].Groups = append(result.Buckets[i].Groups, *group)
+		}
+		sort.Slice(result.Buckets[i].Groups, func(a, b int) bool {
+			ga, gb := result.Buckets[i].Groups[a], result.Buckets[i].Groups[b]
+			if ga.Debit != gb.Debit {
+				return ga.Debit > gb.Debit
+			}
+			return ga.Name < gb.Name
+		})
+		bucket := result.Buckets[i].AggregationTotals
+		result.Totals.Debit += bucket.Debit
+		result.Totals.Credit += bucket.Credit
+		result.Totals.Count += bucket.Count
+	}
+	result.Totals.Net = result.Totals.Credit - result.Totals.Debit
+	result.RealTerms = deflator.RealTerms()
+	return result, nil
+}
+
+// aggregationRows sums the ledger lines within the query's range per day, type and the other columns.
+func (s *TransactionService) aggregationRows(workspaceID uint, q AggregationQuery, columns string) ([]aggregationRow, error) {
+	query := ledgerLines(s.db).
+		Select(columns+", COALESCE(SUM(t.amount), 0) as amount, COUNT(*) as count").
+		Where("t.workspace_id = ? AND t.date >= ? AND t.date < ?", workspaceID, q.From, q.To)
+	if q.GroupBy == GroupByTag {
+		query = query.Joins("LEFT JOIN transaction_tags tt ON tt.transaction_id = t.transaction_id")
+	}
+	var rows []aggregationRow
+	err := query.Group(columns).Scan(&rows).Error
+	return rows, err
+}
+
+// aggregationGrouper returns the function that names a row's group, or nil without grouping.
+func (s *TransactionService) aggregationGrouper(workspaceID uint, q AggregationQuery) (func(aggregationRow) (string, string), error) {
+	key := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+
+	switch q.GroupBy {
+	case GroupByCategory, GroupByArea:
+		tree, err := loadCategoryTree(s.db, workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if q.GroupBy == GroupByCategory {
+			return func(row aggregationRow) (string, string) {
+				if row.CategoryID != nil {
+					if group, ok := tree.group(*row.CategoryID, q.Level); ok {
+						return key(&group.ID), group.Name
+					}
+				}
+				return "", "Uncategorized"
+			}, nil
+		}
+
+		var areas []models.Area
+		if err := s.db.Where("workspace_id = ?", workspaceID).Find(&areas).Error; err != nil {
+			return nil, err
+		}
+		names := map[uint]string{}
+		for _, a := range areas {
+			names[a.ID] = a.Name
+		}
+		return func(row aggregationRow) (string, string) {
+			areaID := row.AreaID
+			if areaID == nil && row.CategoryID != nil {
+				areaID = tree.areaID(*row.CategoryID)
+			}
+			if areaID == nil {
+				return "", "Uncategorized"
+			}
+			return key(areaID), names[*areaID]
+		}, nil
+
+	case GroupByOwner:
+		return func(row aggregationRow) (string, string) {
+			if row.Owner == nil || *row.Owner == "" {
+				return "", "Unassigned"
+			}
+			return *row.Owner, *row.Owner
+		}, nil
+
+	case GroupByAccount:
+		var accounts []models.Account
+		if err := s.db.Where("workspace_id = ?", workspaceID).Find(&accounts).Error; err != nil {
+			return nil, err
+		}
+		names := map[uint]string{}
+		for _, a := range accounts {
+			names[a.ID] = a.Name
+		}
+		return func(row aggregationRow) (string, string) {
+			if row.AccountID == nil {
+				return "", "No account"
+			}
+			return key(row.AccountID), names[*row.AccountID]
+		}, nil
+
+	case GroupByTag:
+		var tags []models.Tag
+		if err := s.db.Where("workspace_id = ?", workspaceID).Find(&tags).Error; err != nil {
+			return nil, err
+		}
+		names := map[uint]string{}
+		for _, t := range tags {
+			names[t.ID] = t.Name
+		}
+		return func(row aggregationRow) (string, string) {
+			if row.TagID == nil {
+				return "", "Untagged"
+			}
+			return key(row.TagID), names[*row.TagID]
+		}, nil
+	}
+	return nil, nil
+}
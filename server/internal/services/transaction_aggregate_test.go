@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketStart(t *testing.T) {
+	day := time.Date(2024, 8, 15, 13, 30, 0, 0, time.UTC) // a Thursday
+	tests := map[string]string{
+		BucketDay:     "2024-08-15",
+		BucketWeek:    "2024-08-12",
+		BucketMonth:   "2024-08-01",
+		BucketQuarter: "2024-07-01",
+		BucketYear:    "2024-01-01",
+	}
+	for bucket, want := range tests {
+		if got := bucketStart(day, bucket).Format("2006-01-02"); got != want {
+			t.Errorf("%s bucket of %s = %s, want %s", bucket, day.Format("2006-01-02"), got, want)
+		}
+	}
+
+	sunday := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	if got := bucketStart(sunday, BucketWeek).Format("2006-01-02"); got != "2024-08-26" {
+		t.Errorf("week of a Sunday starts %s, want the previous Monday 2024-08-26", got)
+	}
+}
+
+func TestBucketRangesClipsToRange(t *testing.T) {
+	from := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 8, 20, 0, 0, 0, 0, time.UTC)
+	ranges, err := bucketRanges(from, to, BucketQuarter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 3 {
+		t.Fatalf("got %d quarters, want 3", len(ranges))
+	}
+	if !ranges[0].From.Equal(from) || !ranges[2].To.Equal(to) {
+		t.Fatalf("first and last buckets should be clipped to the range: %+v", ranges)
+	}
+	if got := ranges[1].From.Format("2006-01-02"); got != "2024-04-01" {
+		t.Fatalf("second quarter starts %s, want 2024-04-01", got)
+	}
+
+	if _, err := bucketRanges(from, from.AddDate(5, 0, 0), BucketDay); err == nil {
+		t.Fatal("expected an error for too many buckets")
+	}
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxBulkTransactions caps how many rows one bulk request may touch.
+const maxBulkTransactions = 1000
+
+var (
+	ErrInvalidBulkRequest = errors.New("invalid bulk request")
+	ErrBulkRolledBack     = errors.New("bulk operation rolled back")
+)
+
+// Outcome statuses of a row in a bulk operation.
+const (
+	BulkStatusUpdated    = "updated"
+	BulkStatusDeleted    = "deleted"
+	BulkStatusNotFound   = "not_found"
+	BulkStatusFailed     = "failed"
+	BulkStatusRolledBack = "rolled_back"
+)
+
+// BulkSelection picks the rows of a bulk operation: either explicit IDs or a search filter, not both.
+type BulkSelection struct {
+	IDs    []uint           `json:"ids"`
+	Filter *SavedViewFilter `json:"filter"`
+}
+
+// TransactionPatch lists the fields a bulk update changes; nil fields are left alone. Category is the name of
+// an existing category; CategoryID wins when both are set. An empty Category or Owner, or an AccountID of 0,
+// clears it. Tags are added and removed by id.
+type TransactionPatch struct {
+	Category      *string `json:"category"`
+	CategoryID    *uint   `json:"category_id"`
+	AreaID        *uint   `json:"area_id"`
+	AccountID     *uint   `json:"account_id"`
+	Owner         *string `json:"owner"`
+	UserConfirmed *bool   `json:"user_confirmed"`
+	AddTagIDs     []uint  `json:"add_tag_ids"`
+	RemoveTagIDs  []uint  `json:"remove_tag_ids"`
+}
+
+func (p TransactionPatch) empty() bool {
+	return p.Category == nil && p.CategoryID == nil && p.AreaID == nil && p.AccountID == nil && p.Owner == nil &&
+		p.UserConfirmed == nil && len(p.AddTagIDs) == 0 && len(p.RemoveTagIDs) == 0
+}
+
+type BulkOutcome struct {
+	TransactionID uint   `json:"transaction_id"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+// BulkResult reports every selected row. Applied is false when a failure rolled the whole batch back.
+type BulkResult struct {
+	Applied   bool          `json:"applied"`
+	Matched   int           `json:"matched"`
+	Succeeded int           `json:"succeeded"`
+	Outcomes  []BulkOutcome `json:"outcomes"`
+}
+
+// BulkUpdate applies patch to the selected rows in one database transaction. IDs outside the workspace are
+// reported as not found; any other row failure rolls everything back and returns ErrBulkRolledBack along
+// with the outcomes.
+func (s *TransactionService) BulkUpdate(workspaceID uint, selection BulkSelection, patch TransactionPatch) (*BulkResult, error) {
+	if patch.empty() {
+		return nil, fmt.Errorf("%w: patch changes nothing", ErrInvalidBulkRequest)
+	}
+
+	result := &BulkResult{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		ids, err := selectBulkTransactions(tx, workspaceID, selection, result)
+		if err != nil {
+			return err
+		}
+
+		updates, err := patchColumns(tx, workspaceID, patch)
+		if err != nil {
+			return err
+		}
+		addTags, err := workspaceTags(tx, workspaceID, patch.AddTagIDs)
+		if err != nil {
+			return err
+		}
+		removeTags, err := workspaceTags(tx, workspaceID, patch.RemoveTagIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := patchTransaction(tx, id, updates, addTags, removeTags); err != nil {
+				result.Outcomes = append(result.Outcomes, BulkOutcome{TransactionID: id, Status: BulkStatusFailed, Error: err.Error()})
+				return ErrBulkRolledBack
+			}
+			result.Outcomes = append(result.Outcomes, BulkOutcome{TransactionID: id, Status: BulkStatusUpdated})
+			result.Succeeded++
+		}
+		return nil
+	})
+	return finishBulk(result, err)
+}
+
+// BulkDelete removes the selected rows, with their tags, allocations and transfer, card payment and refund
+// links, in one database transaction.
+func (s *TransactionService) BulkDelete(workspaceID uint, selection BulkSelection) (*BulkResult, error) {
+	result := &BulkResult{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		ids, err := selectBulkTransactions(tx, workspaceID, selection, result)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Exec("DELETE FROM transaction_tags WHERE transaction_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("transaction_id IN ?", ids).Delete(&models.TransactionAllocation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("debit_transaction_id IN ? OR credit_transaction_id IN ?", ids, ids).
+			Delete(&models.TransferLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("transaction_id IN ? OR card_transaction_id IN ?", ids, ids).
+			Delete(&models.CardPayment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("refund_transaction_id IN ? OR original_transaction_id IN ?", ids, ids).
+			Delete(&models.RefundLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ? AND workspace_id = ?", ids, workspaceID).Delete(&models.Transaction{}).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			result.Outcomes = append(result.Outcomes, BulkOutcome{TransactionID: id, Status: BulkStatusDeleted})
+		}
+		result.Succeeded = len(ids)
+		return nil
+	})
+	return finishBulk(result, err)
+}
+
+func finishBulk(result *BulkResult, err error) (*BulkResult, error) {
+	if err == nil {
+		result.Applied = true
+		return result, nil
+	}
+	if !errors.Is(err, ErrBulkRolledBack) {
+		return nil, err
+	}
+	result.Succeeded = 0
+	for i := range result.Outcomes {
+		if result.Outcomes[i].Status == BulkStatusUpdated || result.Outcomes[i].Status == BulkStatusDeleted {
+			result.Outcomes[i].Status = BulkStatusRolledBack
+		}
+	}
+	return result, err
+}
+
+// selectBulkTransactions resolves the selection to workspace transaction IDs, recording requested IDs that
+// are not in the workspace as not found.
+func selectBulkTransactions(tx *gorm.DB, workspaceID uint, selection BulkSelection, result *BulkResult) ([]uint, error) {
+	query, err := bulkSelectionQuery(tx, workspaceID, selection)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	if err := query.Order("transactions.id").Limit(maxBulkTransactions+1).Pluck("transactions.id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return collectBulkIDs(selection.IDs, ids, result)
+}
+
+// bulkSelectionQuery narrows the workspace's transactions to the explicit IDs or the filter of selection.
+func bulkSelectionQuery(tx *gorm.DB, workspaceID uint, selection BulkSelection) (*gorm.DB, error) {
+	if (len(selection.IDs) > 0) == (selection.Filter != nil) {
+		return nil, fmt.Errorf("%w: pass either ids or filter", ErrInvalidBulkRequest)
+	}
+
+	query := tx.Model(&models.Transaction{}).Where("transactions.workspace_id = ?", workspaceID)
+	if selection.Filter == nil {
+		return query.Where("transactions.id IN ?", selection.IDs), nil
+	}
+	if raw, _ := json.Marshal(selection.Filter); string(raw) == "{}" {
+		return nil, fmt.Errorf("%w: filter must narrow the selection", ErrInvalidBulkRequest)
+	}
+	filter, err := selection.Filter.TransactionFilter(workspaceID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return applyTransactionFilter(tx, query, filter)
+}
+
+// collectBulkIDs checks the matched ids against maxBulkTransactions and reports requested ids that did not
+// match as not found.
+func collectBulkIDs(requested, matched []uint, result *BulkResult) ([]uint, error) {
+	if len(matched) > maxBulkTransactions {
+		return nil, fmt.Errorf("%w: selection exceeds %d transactions", ErrInvalidBulkRequest, maxBulkTransactions)
+	}
+	result.Matched = len(matched)
+
+	found := make(map[uint]bool, len(matched))
+	for _, id := range matched {
+		found[id] = true
+	}
+	for _, id := range requested {
+		if !found[id] {
+			found[id] = true
+			result.Outcomes = append(result.Outcomes, BulkOutcome{TransactionID: id, Status: BulkStatusNotFound})
+		}
+	}
+	return matched, nil
+}
+
+// patchColumns validates the scalar part of patch and turns it into column updates.
+func patchColumns(tx *gorm.DB, workspaceID uint, patch TransactionPatch) (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+
+	switch {
+	case patch.CategoryID != nil:
+		var count int64
+		if err := tx.Model(&models.Category{}).
+			Where("id = ? AND workspace_id = ?", *patch.CategoryID, workspaceID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: unknown category", ErrInvalidBulkRequest)
+		}
+		updates["category_id"] = *patch.CategoryID
+	case patch.Category != nil:
+		// Names must match an existing category, so a typo can't create one across a whole selection.
+		name := strings.TrimSpace(*patch.Category)
+		if name == "" {
+			updates["category_id"] = nil
+			break
+		}
+		var ids []uint
+		if err := tx.Model(&models.Category{}).
+			Where("workspace_id = ? AND name = ?", workspaceID, name).
+			Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("%w: unknown category %q", ErrInvalidBulkRequest, name)
+		}
+		updates["category_id"] = ids[0]
+	}
+
+	if patch.AreaID != nil {
+		var count int64
+		if err := tx.Model(&models.Area{}).
+			Where("id = ? AND workspace_id = ?", *patch.AreaID, workspaceID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: unknown area", ErrInvalidBulkRequest)
+		}
+		updates["area_id"] = *patch.AreaID
+	}
+	if patch.AccountID != nil && *patch.AccountID == 0 {
+		updates["account_id"] = nil
+	} else if patch.AccountID != nil {
+		if err := validateAccountID(tx, workspaceID, patch.AccountID); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBulkRequest, err.Error())
+		}
+		updates["account_id"] = *patch.AccountID
+	}
+	if patch.Owner != nil {
+		owner, err := newOwnerResolver(tx, workspaceID).resolve(*patch.Owner)
+		if errors.Is(err, ErrInvalidOwner) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBulkRequest, err.Error())
+		} else if err != nil {
+			return nil, err
+		}
+		if owner == nil {
+			updates["owner"] = nil
+			updates["owner_id"] = nil
+		} else {
+			updates["owner"] = owner.Name
+			updates["owner_id"] = owner.ID
+		}
+	}
+	if patch.UserConfirmed != nil {
+		updates["user_confirmed"] = *patch.UserConfirmed
+	}
+	return updates, nil
+}
+
+// workspaceTags loads the tags with the given ids, failing if any is not in the workspace.
+func workspaceTags(tx *gorm.DB, workspaceID uint, ids []uint) ([]models.Tag, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var tags []models.Tag
+	if err := tx.Where("workspace_id = ? AND id IN ?", workspaceID, ids).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	unique := map[uint]bool{}
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(tags) != len(unique) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBulkRequest, ErrTagNotFound.Error())
+	}
+	return tags, nil
+}
+
+func patchTransaction(tx *gorm.DB, id uint, updates map[string]interface{}, addTags, removeTags []models.Tag) error {
+	transaction := models.Transaction{ID: id}
+	if len(updates) > 0 {
+		if err := tx.Model(&transaction).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if len(addTags) > 0 {
+		if err := tx.Model(&transaction).Association("Tags").Append(addTags); err != nil {
+			return err
+		}
+	}
+	if len(removeTags) > 0 {
+		if err := tx.Model(&transaction).Association("Tags").Delete(removeTags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
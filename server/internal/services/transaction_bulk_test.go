@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func bulkSelectionSQL(t *testing.T, selection BulkSelection) string {
+	t.Helper()
+	db := dryRunDB(t)
+	query, err := bulkSelectionQuery(db, 7, selection)
+	if err != nil {
+		t.Fatalf("selection: %v", err)
+	}
+	return query.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Find(&[]models.Transaction{}) })
+}
+
+func TestBulkSelectionQueryByIDs(t *testing.T) {
+	sql := bulkSelectionSQL(t, BulkSelection{IDs: []uint{4, 9}})
+
+	for _, fragment := range []string{"transactions.workspace_id = 7", "transactions.id IN (4,9)"} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("SQL does not contain %q:\n%s", fragment, sql)
+		}
+	}
+}
+
+func TestBulkSelectionQueryByFilter(t *testing.T) {
+	sql := bulkSelectionSQL(t, BulkSelection{Filter: &SavedViewFilter{Month: "2026-03", Type: "debit"}})
+
+	for _, fragment := range []string{"transactions.workspace_id = 7", "transactions.type = 'debit'", "transactions.date >= '2026-03-01"} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("SQL does not contain %q:\n%s", fragment, sql)
+		}
+	}
+	if strings.Contains(sql, "transactions.id IN") {
+		t.Errorf("filter selection should not restrict ids:\n%s", sql)
+	}
+}
+
+func TestBulkSelectionQueryRejectsAmbiguousSelections(t *testing.T) {
+	db := dryRunDB(t)
+	for name, selection := range map[string]BulkSelection{
+		"neither":      {},
+		"both":         {IDs: []uint{1}, Filter: &SavedViewFilter{Type: "debit"}},
+		"empty filter": {Filter: &SavedViewFilter{}},
+	} {
+		if _, err := bulkSelectionQuery(db, 7, selection); !errors.Is(err, ErrInvalidBulkRequest) {
+			t.Errorf("%s: expected ErrInvalidBulkRequest, got %v", name, err)
+		}
+	}
+
+	_, err := bulkSelectionQuery(db, 7, BulkSelection{Filter: &SavedViewFilter{Type: "transfer"}})
+	if !errors.Is(err, ErrInvalidSavedView) {
+		t.Errorf("expected invalid filter to be rejected, got %v", err)
+	}
+}
+
+func TestCollectBulkIDs(t *testing.T) {
+	result := &BulkResult{}
+	ids, err := collectBulkIDs([]uint{1, 2, 3, 3}, []uint{1, 3}, result)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if len(ids) != 2 || result.Matched != 2 {
+		t.Fatalf("ids = %v, matched = %d", ids, result.Matched)
+	}
+	if len(result.Outcomes) != 1 || result.Outcomes[0] != (BulkOutcome{TransactionID: 2, Status: BulkStatusNotFound}) {
+		t.Fatalf("outcomes = %+v", result.Outcomes)
+	}
+}
+
+func TestCollectBulkIDsEnforcesCap(t *testing.T) {
+	matched := make([]uint, maxBulkTransactions+1)
+	for i := range matched {
+		matched[i] = uint(i + 1)
+	}
+
+	if _, err := collectBulkIDs(nil, matched[:maxBulkTransactions], &BulkResult{}); err != nil {
+		t.Fatalf("selection at the cap: %v", err)
+	}
+	result := &BulkResult{}
+	if _, err := collectBulkIDs(nil, matched, result); !errors.Is(err, ErrInvalidBulkRequest) {
+		t.Fatalf("expected cap to be enforced, got %v", err)
+	}
+	if result.Matched != 0 {
+		t.Fatalf("matched = %d after rejection", result.Matched)
+	}
+}
+
+func TestPatchColumnsRejectsUnknownReferences(t *testing.T) {
+	db := dryRunDB(t)
+	id := uint(12)
+	name := "Groceires"
+
+	for label, patch := range map[string]TransactionPatch{
+		"category id":   {CategoryID: &id},
+		"category name": {Category: &name},
+		"area":          {AreaID: &id},
+	} {
+		if _, err := patchColumns(db, 7, patch); !errors.Is(err, ErrInvalidBulkRequest) {
+			t.Errorf("%s: expected ErrInvalidBulkRequest, got %v", label, err)
+		}
+	}
+}
+
+func TestPatchColumnsClearsAndSetsScalars(t *testing.T) {
+	db := dryRunDB(t)
+	blank, confirmed := "  ", true
+
+	updates, err := patchColumns(db, 7, TransactionPatch{Category: &blank, Owner: &blank, UserConfirmed: &confirmed})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	for _, column := range []string{"category_id", "owner", "owner_id"} {
+		if value, ok := updates[column]; !ok || value != nil {
+			t.Errorf("%s = %v (set: %v), want cleared", column, value, ok)
+		}
+	}
+	if updates["user_confirmed"] != true {
+		t.Errorf("user_confirmed = %v", updates["user_confirmed"])
+	}
+	if len(updates) != 4 {
+		t.Errorf("updates = %v", updates)
+	}
+}
+
+func TestFinishBulk(t *testing.T) {
+	result, err := finishBulk(&BulkResult{Succeeded: 2}, nil)
+	if err != nil || !result.Applied || result.Succeeded != 2 {
+		t.Fatalf("success = %+v, %v", result, err)
+	}
+
+	failure := errors.New("connection lost")
+	if result, err := finishBulk(&BulkResult{}, failure); result != nil || !errors.Is(err, failure) {
+		t.Fatalf("plain error = %+v, %v", result, err)
+	}
+
+	rolledBack := &BulkResult{Matched: 4, Succeeded: 2, Outcomes: []BulkOutcome{
+		{TransactionID: 1, Status: BulkStatusNotFound},
+		{TransactionID: 2, Status: BulkStatusUpdated},
+		{TransactionID: 3, Status: BulkStatusDeleted},
+		{TransactionID: 4, Status: BulkStatusFailed, Error: "boom"},
+	}}
+	result, err = finishBulk(rolledBack, ErrBulkRolledBack)
+	if !errors.Is(err, ErrBulkRolledBack) || result == nil {
+		t.Fatalf("rollback = %+v, %v", result, err)
+	}
+	if result.Applied || result.Succeeded != 0 {
+		t.Fatalf("applied = %v, succeeded = %d", result.Applied, result.Succeeded)
+	}
+	want := []string{BulkStatusNotFound, BulkStatusRolledBack, BulkStatusRolledBack, BulkStatusFailed}
+	for i, outcome := range result.Outcomes {
+		if outcome.Status != want[i] {
+			t.Errorf("outcome %d = %q, want %q", outcome.TransactionID, outcome.Status, want[i])
+		}
+	}
+}
+
+func TestPatchColumnsClearsAccount(t *testing.T) {
+	db := dryRunDB(t)
+	none, missing := uint(0), uint(5)
+
+	updates, err := patchColumns(db, 7, TransactionPatch{AccountID: &none})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if value, ok := updates["account_id"]; !ok || value != nil {
+		t.Fatalf("account_id = %v (set: %v), want cleared", value, ok)
+	}
+	if _, err := patchColumns(db, 7, TransactionPatch{AccountID: &missing}); !errors.Is(err, ErrInvalidBulkRequest) {
+		t.Fatalf("expected unknown account to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidTransactionQuery = errors.New("invalid transaction query")
+
+// transactionSortField is a column clients may sort by. expr is used both in ORDER BY and in the cursor
+// predicate, so nullable columns are coalesced to keep comparisons total.
+type transactionSortField struct {
+	expr  string
+	kind  string // time | float | string
+	value func(t *models.Transaction) interface{}
+}
+
+var transactionSortFields = map[string]transactionSortField{
+	"date": {
+		expr:  "transactions.date",
+		kind:  "time",
+		value: func(t *models.Transaction) interface{} { return t.Date },
+	},
+	"amount": {
+		expr:  "COALESCE(transactions.amount, 0)",
+		kind:  "float",
+		value: func(t *models.Transaction) interface{} { return t.Amount.Float64 },
+	},
+	"description": {
+		expr:  "COALESCE(transactions.description, '')",
+		kind:  "string",
+		value: func(t *models.Transaction) interface{} { return t.Description.String },
+	},
+	"type": {
+		expr:  "COALESCE(transactions.type, '')",
+		kind:  "string",
+		value: func(t *models.Transaction) interface{} { return t.Type.String },
+	},
+	"owner": {
+		expr:  "COALESCE(transactions.owner, '')",
+		kind:  "string",
+		value: func(t *models.Transaction) interface{} { return t.Owner.String },
+	},
+	"category": {
+		expr:  "COALESCE((SELECT c.name FROM categories c WHERE c.id = transactions.category_id), '')",
+		kind:  "string",
+		value: func(t *models.Transaction) interface{} { return t.Category.String },
+	},
+	"created_at": {
+		expr:  "transactions.created_at",
+		kind:  "time",
+		value: func(t *models.Transaction) interface{} { return t.CreatedAt },
+	},
+}
+
+type transactionSortTerm struct {
+	name string
+	desc bool
+}
+
+// parseTransactionSort reads a comma-separated sort list such as "date,-amount". A "-" prefix sorts that
+// column descending; unprefixed columns use defaultOrder ("asc" or "desc"). The id is always appended as a
+// tie-breaker so that ordering, and therefore cursors, are stable.
+func parseTransactionSort(raw, defaultOrder string) ([]transactionSortTerm, error) {
+	defaultDesc := !strings.EqualFold(defaultOrder, "asc")
+	if strings.TrimSpace(raw) == "" {
+		raw = "date"
+	}
+
+	var terms []transactionSortTerm
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		desc := defaultDesc
+		if strings.HasPrefix(part, "-") {
+			desc = true
+			part = part[1:]
+		}
+		if _, ok := transactionSortFields[part]; !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidTransactionQuery, part)
+		}
+		if seen[part] {
+			continue
+		}
+		seen[part] = true
+		terms = append(terms, transactionSortTerm{name: part, desc: desc})
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: empty sort", ErrInvalidTransactionQuery)
+	}
+	return append(terms, transactionSortTerm{name: "id", desc: terms[0].desc}), nil
+}
+
+func sortTermExpr(term transactionSortTerm) string {
+	if term.name == "id" {
+		return "transactions.id"
+	}
+	return transactionSortFields[term.name].expr
+}
+
+func orderClause(terms []transactionSortTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		direction := "ASC"
+		if term.desc {
+			direction = "DESC"
+		}
+		parts = append(parts, sortTermExpr(term)+" "+direction)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortSignature(terms []transactionSortTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term.desc {
+			parts = append(parts, "-"+term.name)
+		} else {
+			parts = append(parts, term.name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+type transactionCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// encodeTransactionCursor captures the sort values of the last row of a page.
+func encodeTransactionCursor(terms []transactionSortTerm, last *models.Transaction) string {
+	values := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term.name == "id" {
+			values = append(values, strconv.FormatUint(uint64(last.ID), 10))
+			continue
+		}
+		switch v := transactionSortFields[term.name].value(last).(type) {
+		case time.Time:
+			values = append(values, v.Format(time.RFC3339Nano))
+		case float64:
+			values = append(values, strconv.FormatFloat(v, 'g', -1, 64))
+		default:
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+	raw, _ := json.Marshal(transactionCursor{Sort: sortSignature(terms), Values: values})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeTransactionCursor returns typed SQL arguments for the cursor, rejecting cursors issued for a
+// different sort.
+func decodeTransactionCursor(terms []transactionSortTerm, encoded string) ([]interface{}, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", ErrInvalidTransactionQuery)
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor transactionCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || len(cursor.Values) != len(terms) {
+		return nil, invalid
+	}
+	if cursor.Sort != sortSignature(terms) {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidTransactionQuery)
+	}
+
+	args := make([]interface{}, len(terms))
+	for i, term := range terms {
+		value := cursor.Values[i]
+		kind := "id"
+		if term.name != "id" {
+			kind = transactionSortFields[term.name].kind
+		}
+		switch kind {
+		case "id":
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			args[i] = id
+		case "time":
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, invalid
+			}
+			args[i] = t
+		case "float":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			args[i] = f
+		default:
+			args[i] = value
+		}
+	}
+	return args, nil
+}
+
+// keysetPredicate builds "(a > ?) OR (a = ? AND b < ?) OR ..." selecting rows strictly after the cursor.
+func keysetPredicate(terms []transactionSortTerm, values []interface{}) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	for i, term := range terms {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, sortTermExpr(terms[j])+" = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if term.desc {
+			op = "<"
+		}
+		parts = append(parts, sortTermExpr(term)+" "+op+" ?")
+		args = append(args, values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+// lineColumns names the columns a line-level filter reads: a transaction's own, its allocations' (aliased a,
+// joined to the parent as p) or a ledger line's (aliased t).
+type lineColumns struct {
+	category string
+	area     string
+	owner    string
+	amount   string
+}
+
+var (
+	transactionLineColumns = lineColumns{"transactions.category_id", "transactions.area_id", "transactions.owner", "transactions.amount"}
+	allocationLineColumns  = lineColumns{"a.category_id", "a.area_id", "COALESCE(NULLIF(a.owner, ''), p.owner)", "a.amount"}
+	ledgerLineColumns      = lineColumns{"t.category_id", "t.area_id", "t.owner", "ABS(t.amount)"}
+)
+
+// applyTransactionFilter narrows query (over the transactions table) to the filter's conditions. Category,
+// owner, area and amount describe a line of spending, so a split transaction matches when one of its
+// allocations meets all of them.
+func applyTransactionFilter(db, query *gorm.DB, filter TransactionFilter) (*gorm.DB, error) {
+	query, err := applyRowFilter(db, query, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Type != "" {
+		query = query.Where("transactions.type = ?", filter.Type)
+	}
+	if !hasLineFilter(filter) {
+		return query, nil
+	}
+
+	unsplit := applyLineFilter(db, db.Where("NOT EXISTS (SELECT 1 FROM transaction_allocations a WHERE a.transaction_id = transactions.id)"),
+		filter, transactionLineColumns)
+	split := applyLineFilter(db, db.Table("transaction_allocations a").Select("a.transaction_id").
+		Joins("JOIN transactions p ON p.id = a.transaction_id").
+		Where("a.workspace_id = ?", filter.WorkspaceID), filter, allocationLineColumns)
+	return query.Where(unsplit.Or("transactions.id IN (?)", split)), nil
+}
+
+// ledgerFilter starts a query over the ledger lines of the transactions matching filter. Type and the
+// line-level conditions are checked on each line, so allocations and refunds count under their own
+// attribution.
+func ledgerFilter(db *gorm.DB, filter TransactionFilter) (*gorm.DB, error) {
+	rows, err := applyRowFilter(db, db.Model(&models.Transaction{}).Select("transactions.id").
+		Where("transactions.workspace_id = ?", filter.WorkspaceID), filter)
+	if err != nil {
+		return nil, err
+	}
+	query := ledgerLines(db).Where("t.workspace_id = ? AND t.transaction_id IN (?)", filter.WorkspaceID, rows)
+	if filter.Type != "" {
+		query = query.Where("t.type = ?", filter.Type)
+	}
+	return applyLineFilter(db, query, filter, ledgerLineColumns), nil
+}
+
+// applyRowFilter applies the conditions that describe a whole transaction: dates, tags, text, account,
+// confirmation and import batch.
+func applyRowFilter(db, query *gorm.DB, filter TransactionFilter) (*gorm.DB, error) {
+	from, to, err := filterRange(filter)
+	if err != nil {
+		return nil, err
+	}
+	if from != nil {
+		query = query.Where("transactions.date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("transactions.date < ?", *to)
+	}
+
+	if len(filter.Tags) > 0 {
+		tagged := db.Table("transaction_tags tt").
+			Select("tt.transaction_id").
+			Joins("JOIN tags g ON g.id = tt.tag_id").
+			Where("g.workspace_id = ? AND g.name IN ?", filter.WorkspaceID, normalizeTagNames(filter.Tags))
+		query = query.Where("transactions.id IN (?)", tagged)
+	}
+	if text := strings.TrimSpace(filter.Text); text != "" {
+		query = query.Where("LOWER(transactions.description) LIKE ?", "%"+escapeLike(strings.ToLower(text))+"%")
+	}
+	if len(filter.AccountIDs) > 0 {
+		query = query.Where("transactions.account_id IN ?", filter.AccountIDs)
+	}
+	if filter.Confirmed != nil {
+		query = query.Where("transactions.user_confirmed = ?", *filter.Confirmed)
+	}
+	if filter.ImportBatchID != nil {
+		query = query.Where("transactions.import_batch_id = ?", *filter.ImportBatchID)
+	}
+	return query, nil
+}
+
+func hasLineFilter(filter TransactionFilter) bool {
+	return len(filter.Categories) > 0 || len(filter.Owners) > 0 || len(filter.AreaIDs) > 0 ||
+		filter.MinAmount != nil || filter.MaxAmount != nil
+}
+
+// applyLineFilter narrows query to the category, owner, area and amount conditions, read from cols.
+func applyLineFilter(db, query *gorm.DB, filter TransactionFilter, cols lineColumns) *gorm.DB {
+	if len(filter.Categories) > 0 {
+		query = query.Where(cols.category+" IN (?)", categoryIDsByName(db, filter.WorkspaceID, filter.Categories))
+	}
+	if filter.MinAmount != nil {
+		query = query.Where(cols.amount+" >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where(cols.amount+" <= ?", *filter.MaxAmount)
+	}
+	if len(filter.Owners) > 0 {
+		// Names merged into another owner (say, in an older saved view) still match through its aliases.
+		aliased := db.Model(&models.OwnerAlias{}).
+			Select("owner_id").Where("workspace_id = ? AND name IN ?", filter.WorkspaceID, filter.Owners)
+		merged := db.Model(&models.Owner{}).Select("name").Where("workspace_id = ? AND id IN (?)", filter.WorkspaceID, aliased)
+		query = query.Where("("+cols.owner+" IN ? OR "+cols.owner+" IN (?))", filter.Owners, merged)
+	}
+	if len(filter.AreaIDs) > 0 {
+		// Effective area: the line's own area, otherwise its category's.
+		inAreaCategories := db.Model(&models.Category{}).Select("id").
+			Where("workspace_id = ? AND area_id IN ?", filter.WorkspaceID, filter.AreaIDs)
+		query = query.Where("("+cols.area+" IN ? OR ("+cols.area+" IS NULL AND "+cols.category+" IN (?)))",
+			filter.AreaIDs, inAreaCategories)
+	}
+	return query
+}
+
+// filterRange resolves the filter's month, or its from/to bounds, into a half-open date range.
+func filterRange(filter TransactionFilter) (from, to *time.Time, err error) {
+	if filter.Month == "" {
+		return filter.From, filter.To, nil
+	}
+	start, err := time.Parse("2006-01", filter.Month)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidTransactionQuery)
+	}
+	end := start.AddDate(0, 1, 0)
+	return &start, &end, nil
+}
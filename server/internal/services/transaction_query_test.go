@@ -0,0 +1,146 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestParseTransactionSort(t *testing.T) {
+	terms, err := parseTransactionSort("date,-amount", "asc")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := sortSignature(terms); got != "date,-amount,id" {
+		t.Fatalf("signature = %q", got)
+	}
+	if got := orderClause(terms); got != "transactions.date ASC, COALESCE(transactions.amount, 0) DESC, transactions.id ASC" {
+		t.Fatalf("order = %q", got)
+	}
+
+	if _, err := parseTransactionSort("amount; DROP TABLE transactions", "desc"); !errors.Is(err, ErrInvalidTransactionQuery) {
+		t.Fatalf("expected whitelist rejection, got %v", err)
+	}
+}
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	terms, _ := parseTransactionSort("-date,amount", "desc")
+	last := &models.Transaction{
+		ID:     42,
+		Date:   time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+		Amount: sql.NullFloat64{Float64: 1234.5, Valid: true},
+	}
+
+	values, err := decodeTransactionCursor(terms, encodeTransactionCursor(terms, last))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !values[0].(time.Time).Equal(last.Date) || values[1].(float64) != 1234.5 || values[2].(uint64) != 42 {
+		t.Fatalf("values = %#v", values)
+	}
+
+	predicate, args := keysetPredicate(terms, values)
+	want := "((transactions.date < ?) OR (transactions.date = ? AND COALESCE(transactions.amount, 0) < ?) OR " +
+		"(transactions.date = ? AND COALESCE(transactions.amount, 0) = ? AND transactions.id < ?))"
+	if predicate != want || len(args) != 6 {
+		t.Fatalf("predicate = %q (%d args)", predicate, len(args))
+	}
+
+	other, _ := parseTransactionSort("amount", "desc")
+	if _, err := decodeTransactionCursor(other, encodeTransactionCursor(terms, last)); !errors.Is(err, ErrInvalidTransactionQuery) {
+		t.Fatalf("expected cursor from another sort to be rejected, got %v", err)
+	}
+}
+
+func TestApplyTransactionFilterMatchesAllocations(t *testing.T) {
+	db := dryRunDB(t)
+	min := 500.0
+	filter := TransactionFilter{WorkspaceID: 3, Owners: []string{"Ana"}, MinAmount: &min, Type: "debit"}
+
+	query, err := applyTransactionFilter(db, db.Model(&models.Transaction{}).Where("transactions.workspace_id = ?", 3), filter)
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+	sql := query.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Find(&[]models.Transaction{}) })
+
+	for _, fragment := range []string{
+		"transactions.type = 'debit'",
+		"NOT EXISTS (SELECT 1 FROM transaction_allocations a WHERE a.transaction_id = transactions.id) AND transactions.amount >= 500",
+		"(transactions.owner IN ('Ana')",
+		"OR transactions.id IN (SELECT a.transaction_id FROM transaction_allocations a JOIN transactions p ON p.id = a.transaction_id WHERE a.workspace_id = 3 AND a.amount >= 500",
+		"(COALESCE(NULLIF(a.owner, ''), p.owner) IN ('Ana')",
+	} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("SQL does not contain %q:\n%s", fragment, sql)
+		}
+	}
+}
+
+func TestApplyTransactionFilterWithoutLineFilters(t *testing.T) {
+	db := dryRunDB(t)
+	query, err := applyTransactionFilter(db, db.Model(&models.Transaction{}), TransactionFilter{WorkspaceID: 3, Text: "dia"})
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+	sql := query.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Find(&[]models.Transaction{}) })
+	if strings.Contains(sql, "transaction_allocations") {
+		t.Fatalf("row-only filters should not touch allocations:\n%s", sql)
+	}
+}
+
+func TestLedgerFilterAppliesEveryFilter(t *testing.T) {
+	db := dryRunDB(t)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	filter := TransactionFilter{WorkspaceID: 3, From: &from, To: &to, Categories: []string{"Super"}, Text: "dia", Type: "credit", AreaIDs: []uint{2}}
+
+	query, err := ledgerFilter(db, filter)
+	if err != nil {
+		t.Fatalf("ledger filter: %v", err)
+	}
+	sql := query.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Find(&[]map[string]interface{}{}) })
+
+	for _, fragment := range []string{
+		"t.transaction_id IN (SELECT transactions.id FROM `transactions` WHERE transactions.workspace_id = 3 AND transactions.date >= '2026-01-01",
+		"LOWER(transactions.description) LIKE '%dia%'",
+		"t.type = 'credit'",
+		"t.category_id IN (SELECT `id` FROM `categories` WHERE workspace_id = 3 AND name IN ('Super'))",
+		"(t.area_id IN (2) OR (t.area_id IS NULL AND t.category_id IN",
+	} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("SQL does not contain %q:\n%s", fragment, sql)
+		}
+	}
+	if strings.Contains(sql, "transactions.type") {
+		t.Errorf("type should be read from the ledger line, not the transaction:\n%s", sql)
+	}
+}
+
+func TestTotalsQuerySumsLedgerLines(t *testing.T) {
+	db := dryRunDB(t)
+	query, err := totalsQuery(db, TransactionFilter{WorkspaceID: 3, Month: "2026-02", Owners: []string{"Ana"}})
+	if err != nil {
+		t.Fatalf("totals query: %v", err)
+	}
+	sql := query.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Scan(&TransactionTotals{}) })
+
+	for _, fragment := range []string{
+		"COUNT(DISTINCT t.transaction_id) as count",
+		"SUM(CASE WHEN t.type = 'debit' THEN t.amount ELSE 0 END)",
+		"t.transaction_id IN (SELECT transactions.id FROM `transactions` WHERE transactions.workspace_id = 3",
+		"(t.owner IN ('Ana')",
+	} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("SQL does not contain %q:\n%s", fragment, sql)
+		}
+	}
+	if strings.Contains(sql, "transactions.amount") {
+		t.Errorf("totals should sum ledger lines, not raw transactions:\n%s", sql)
+	}
+}
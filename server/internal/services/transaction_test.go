@@ -0,0 +1,87 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestBuildAllocations(t *testing.T) {
+	parent := &models.Transaction{ID: 9, WorkspaceID: 4, Amount: sql.NullFloat64{Float64: 1000, Valid: true}}
+
+	tests := []struct {
+		name   string
+		parent *models.Transaction
+		inputs []AllocationInput
+		want   error
+	}{
+		{"single allocation", parent, []AllocationInput{{Amount: 1000}}, ErrAllocationInvalid},
+		{"negative amount", parent, []AllocationInput{{Amount: 1200}, {Amount: -200}}, ErrAllocationInvalid},
+		{"zero amount", parent, []AllocationInput{{Amount: 1000}, {Amount: 0}}, ErrAllocationInvalid},
+		{"does not add up", parent, []AllocationInput{{Amount: 600}, {Amount: 300}}, ErrAllocationSum},
+		{
+			"non-positive parent",
+			&models.Transaction{WorkspaceID: 4, Amount: sql.NullFloat64{Float64: -1000, Valid: true}},
+			[]AllocationInput{{Amount: 600}, {Amount: 400}},
+			ErrAllocationInvalid,
+		},
+		{"category id outside the workspace", parent, []AllocationInput{{Amount: 600, CategoryID: uintPtr(77)}, {Amount: 400}}, ErrAllocationInvalid},
+		{"area outside the workspace", parent, []AllocationInput{{Amount: 600}, {Amount: 400, AreaID: uintPtr(77)}}, ErrAllocationInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The dry-run DB finds no rows, so every referenced category or area is unknown.
+			_, err := buildAllocations(dryRunDB(t), tt.parent, tt.inputs)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("buildAllocations error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAllocationsWithinTolerance(t *testing.T) {
+	parent := &models.Transaction{ID: 9, WorkspaceID: 4, Amount: sql.NullFloat64{Float64: 100, Valid: true}}
+	inputs := []AllocationInput{
+		{Amount: 33.33, Note: "  share A "},
+		{Amount: 33.33},
+		{Amount: 33.338},
+	}
+
+	allocations, err := buildAllocations(dryRunDB(t), parent, inputs)
+	if err != nil {
+		t.Fatalf("buildAllocations: %v", err)
+	}
+	if len(allocations) != 3 {
+		t.Fatalf("got %d allocations, want 3", len(allocations))
+	}
+	first := allocations[0]
+	if first.TransactionID != 9 || first.WorkspaceID != 4 || first.Amount != 33.33 || first.Note != "share A" {
+		t.Fatalf("allocation = %+v", first)
+	}
+	if first.CategoryID != nil || first.Owner != "" {
+		t.Fatalf("blank category and owner should stay unset, got %+v", first)
+	}
+}
+
+func TestBuildAllocationsRejectsLinkedTransactions(t *testing.T) {
+	db := dryRunDB(t)
+	// Answer the link check as if the transaction were a linked refund.
+	if err := db.Callback().Query().After("gorm:query").Register("test:linked", func(tx *gorm.DB) {
+		if count, ok := tx.Statement.Dest.(*int64); ok && strings.Contains(tx.Statement.SQL.String(), "refund_links") {
+			*count, tx.RowsAffected = 1, 1
+		}
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	parent := &models.Transaction{ID: 9, WorkspaceID: 4, Amount: sql.NullFloat64{Float64: 1000, Valid: true}}
+
+	_, err := buildAllocations(db, parent, []AllocationInput{{Amount: 600}, {Amount: 400}})
+	if !errors.Is(err, ErrTransactionLinked) {
+		t.Fatalf("buildAllocations error = %v, want ErrTransactionLinked", err)
+	}
+}
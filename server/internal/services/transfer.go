@@ -0,0 +1,260 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"etl-banks-ar/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTransferWindowDays is how many days apart the two sides of a transfer may be booked.
+	DefaultTransferWindowDays = 3
+	MaxTransferWindowDays     = 10
+)
+
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+var (
+	transferKeywordPattern = regexp.MustCompile(`(?i)\b(transf(erencia)?|trf|transfer|debin|cbu|cvu|alias)\b`)
+	// CBU and CVU numbers are 22 digits; aliases are dotted words such as "juan.perez.mp".
+	transferCBUPattern   = regexp.MustCompile(`\b\d{22}\b`)
+	transferAliasPattern = regexp.MustCompile(`(?i)\b[a-z0-9-]+(?:\.[a-z0-9-]+){1,3}\b`)
+)
+
+// TransferCandidate is a debit and a credit that look like the two sides of one transfer.
+type TransferCandidate struct {
+	Debit     models.Transaction `json:"debit"`
+	Credit    models.Transaction `json:"credit"`
+	DaysApart int                `json:"days_apart"`
+	Score     float64            `json:"score"`
+	Reasons   []string           `json:"reasons"`
+}
+
+type TransferService struct {
+	db *gorm.DB
+}
+
+func NewTransferService(db *gorm.DB) *TransferService {
+	return &TransferService{db: db}
+}
+
+// Candidates suggests transfer pairs among unlinked transactions dated within [from, to): same amount,
+// opposite types, at most window days apart, and a transfer keyword or a shared CBU / alias in the
+// descriptions. Each transaction appears in at most one candidate, the best scoring one.
+func (s *TransferService) Candidates(workspaceID uint, from, to *time.Time, window int) ([]TransferCandidate, error) {
+	if window < 0 || window > MaxTransferWindowDays {
+		return nil, fmt.Errorf("%w: days must be between 0 and %d", ErrInvalidTransfer, MaxTransferWindowDays)
+	}
+
+	// Widen the range so pairs straddling a bound are still found.
+	query := s.db.Preload("CategoryRef").
+		Where("workspace_id = ? AND type IN ?", workspaceID, []string{"debit", "credit"}).
+		Where("id NOT IN (" + linkedTransactionIDsSQL + ")")
+	if from != nil {
+		query = query.Where("date >= ?", from.AddDate(0, 0, -window))
+	}
+	if to != nil {
+		query = query.Where("date < ?", to.AddDate(0, 0, window))
+	}
+	var transactions []models.Transaction
+	if err := query.Order("date ASC, id ASC").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	var debits, credits []models.Transaction
+	for _, t := range transactions {
+		if t.Type.String == "debit" {
+			debits = append(debits, t)
+		} else {
+			credits = append(credits, t)
+		}
+	}
+
+	inRange := func(t models.Transaction) bool {
+		return (from == nil || !t.Date.Before(*from)) && (to == nil || t.Date.Before(*to))
+	}
+	candidates := []TransferCandidate{}
+	for _, c := range matchTransferCandidates(debits, credits, window) {
+		if inRange(c.Debit) || inRange(c.Credit) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
+
+// matchTransferCandidates pairs debits with credits of the same amount, best score first, using every
+// transaction at most once. The result is ordered by the debit's date, newest first.
+func matchTransferCandidates(debits, credits []models.Transaction, window int) []TransferCandidate {
+	creditsByCents := map[int64][]models.Transaction{}
+	for _, c := range credits {
+		cents := int64(math.Round(c.Amount.Float64 * 100))
+		creditsByCents[cents] = append(creditsByCents[cents], c)
+	}
+
+	var all []TransferCandidate
+	for _, d := range debits {
+		cents := int64(math.Round(d.Amount.Float64 * 100))
+		if cents <= 0 {
+			continue
+		}
+		for _, c := range creditsByCents[cents] {
+			days := int(math.Round(math.Abs(c.Date.Sub(d.Date).Hours()) / 24))
+			if days > window {
+				continue
+			}
+			if d.AccountID != nil && c.AccountID != nil && *d.AccountID == *c.AccountID {
+				continue
+			}
+			score, reasons := scoreTransferPair(d, c, days)
+			if len(reasons) == 0 {
+				continue
+			}
+			all = append(all, TransferCandidate{Debit: d, Credit: c, DaysApart: days, Score: score, Reasons: reasons})
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Score > all[j].Score })
+	used := map[uint]bool{}
+	picked := []TransferCandidate{}
+	for _, c := range all {
+		if used[c.Debit.ID] || used[c.Credit.ID] {
+			continue
+		}
+		used[c.Debit.ID], used[c.Credit.ID] = true, true
+		picked = append(picked, c)
+	}
+	sort.SliceStable(picked, func(i, j int) bool { return picked[i].Debit.Date.After(picked[j].Debit.Date) })
+	return picked
+}
+
+// scoreTransferPair rates how likely a same-amount pair is a transfer. Without a keyword or a shared
+// identifier there are no reasons and the pair is not a candidate.
+func scoreTransferPair(debit, credit models.Transaction, days int) (float64, []string) {
+	var reasons []string
+	score := 0.5 - 0.05*float64(days)
+
+	if transferKeywordPattern.MatchString(debit.Description.String) || transferKeywordPattern.MatchString(credit.Description.String) {
+		reasons = append(reasons, "transfer keyword")
+		score += 0.2
+	}
+	if sharesTransferIdentifier(debit.Description.String, credit.Description.String) {
+		reasons = append(reasons, "shared CBU/alias")
+		score += 0.3
+	}
+	if len(reasons) == 0 {
+		return 0, nil
+	}
+	if debit.AccountID != nil && credit.AccountID != nil {
+		reasons = append(reasons, "different accounts")
+		score += 0.1
+	}
+	return math.Min(score, 1), reasons
+}
+
+func sharesTransferIdentifier(a, b string) bool {
+	ids := map[string]bool{}
+	for _, id := range transferIdentifiers(a) {
+		ids[id] = true
+	}
+	for _, id := range transferIdentifiers(b) {
+		if ids[id] {
+			return true
+		}
+	}
+	return false
+}
+
+func transferIdentifiers(description string) []string {
+	ids := transferCBUPattern.FindAllString(description, -1)
+	for _, alias := range transferAliasPattern.FindAllString(description, -1) {
+		// Skip short dotted tokens such as "S.A." and numbers such as "1.234".
+		if len(alias) < 6 || !strings.ContainsAny(strings.ToLower(alias), "abcdefghijklmnopqrstuvwxyz") {
+			continue
+		}
+		ids = append(ids, strings.ToLower(alias))
+	}
+	return ids
+}
+
+// List returns the workspace's transfer links with both transactions, newest first. Links are included
+// when either side is dated within [from, to).
+func (s *TransferService) List(workspaceID uint, from, to *time.Time) ([]models.TransferLink, error) {
+	query := s.db.Preload("DebitTransaction.CategoryRef").Preload("CreditTransaction.CategoryRef").
+		Where("transfer_links.workspace_id = ?", workspaceID)
+	if from != nil || to != nil {
+		sides := s.db.Model(&models.Transaction{}).Select("id").Where("workspace_id = ?", workspaceID)
+		if from != nil {
+			sides = sides.Where("date >= ?", *from)
+		}
+		if to != nil {
+			sides = sides.Where("date < ?", *to)
+		}
+		query = query.Where("debit_transaction_id IN (?) OR credit_transaction_id IN (?)", sides, sides)
+	}
+
+	links := []models.TransferLink{}
+	err := query.Order("created_at DESC, id DESC").Find(&links).Error
+	return links, err
+}
+
+// Link records debitID and creditID as the two sides of a transfer.
+func (s *TransferService) Link(workspaceID, debitID, creditID, userID uint) (*models.TransferLink, error) {
+	if debitID == creditID {
+		return nil, fmt.Errorf("%w: debit and credit must be different transactions", ErrInvalidTransfer)
+	}
+	link := &models.TransferLink{
+		WorkspaceID:         workspaceID,
+		DebitTransactionID:  debitID,
+		CreditTransactionID: creditID,
+		CreatedBy:           userID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for id, wantType := range map[uint]string{debitID: "debit", creditID: "credit"} {
+			var t models.Transaction
+			if err := tx.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&t).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: transaction %d not found", ErrInvalidTransfer, id)
+				}
+				return err
+			}
+			if t.Type.String != wantType {
+				return fmt.Errorf("%w: transaction %d is not a %s", ErrInvalidTransfer, id, wantType)
+			}
+		}
+
+		linked, err := anyLinked(tx, debitID, creditID)
+		if err != nil {
+			return err
+		}
+		if linked {
+			return ErrTransactionLinked
+		}
+		return tx.Create(link).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Preload("DebitTransaction.CategoryRef").Preload("CreditTransaction.CategoryRef").First(link, link.ID).Error
+	return link, err
+}
+
+// Unlink removes a transfer link; both transactions count in summaries again.
+func (s *TransferService) Unlink(id, workspaceID uint) error {
+	result := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&models.TransferLink{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"etl-banks-ar/internal/models"
+)
+
+func transferTxn(id uint, kind string, day int, amount float64, description string) models.Transaction {
+	return models.Transaction{
+		ID:          id,
+		Date:        time.Date(2026, 4, day, 0, 0, 0, 0, time.UTC),
+		Type:        sql.NullString{String: kind, Valid: true},
+		Amount:      sql.NullFloat64{Float64: amount, Valid: true},
+		Description: sql.NullString{String: description, Valid: true},
+	}
+}
+
+func TestMatchTransferCandidates(t *testing.T) {
+	debits := []models.Transaction{
+		transferTxn(1, "debit", 10, 50000, "TRANSFERENCIA A juan.perez.mp"),
+		transferTxn(2, "debit", 10, 12000, "SUPERMERCADO COTO"),
+	}
+	credits := []models.Transaction{
+		transferTxn(3, "credit", 11, 50000, "Ingreso de dinero juan.perez.mp"),
+		transferTxn(4, "credit", 20, 50000, "TRANSFERENCIA RECIBIDA"), // too far apart
+		transferTxn(5, "credit", 10, 12000, "DEVOLUCION COTO S.A."),   // no transfer evidence
+	}
+
+	candidates := matchTransferCandidates(debits, credits, DefaultTransferWindowDays)
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1: %+v", len(candidates), candidates)
+	}
+	c := candidates[0]
+	if c.Debit.ID != 1 || c.Credit.ID != 3 || c.DaysApart != 1 {
+		t.Fatalf("unexpected pair %d/%d (%d days)", c.Debit.ID, c.Credit.ID, c.DaysApart)
+	}
+	if len(c.Reasons) != 2 {
+		t.Fatalf("reasons = %v, want keyword and shared alias", c.Reasons)
+	}
+}
+
+func TestMatchTransferCandidatesUsesEachTransactionOnce(t *testing.T) {
+	debits := []models.Transaction{transferTxn(1, "debit", 5, 1000, "TRANSF CBU 0070999030004012345678")}
+	credits := []models.Transaction{
+		transferTxn(2, "credit", 8, 1000, "TRANSFERENCIA"),
+		transferTxn(3, "credit", 5, 1000, "CREDITO CBU 0070999030004012345678"),
+	}
+
+	candidates := matchTransferCandidates(debits, credits, DefaultTransferWindowDays)
+	if len(candidates) != 1 || candidates[0].Credit.ID != 3 {
+		t.Fatalf("expected the same-day shared-CBU credit to win, got %+v", candidates)
+	}
+}
@@ -3,26 +3,33 @@ package services
 import (
 	"database/sql"
 	"etl-banks-ar/internal/categorizer"
+	"etl-banks-ar/internal/configs"
 	"etl-banks-ar/internal/models"
 	"etl-banks-ar/internal/ocr"
 	openAiService "etl-banks-ar/internal/openai"
 	"etl-banks-ar/internal/trainingcsv"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-const maxWorkspaceLabeledExamples = 120
+const (
+	// maxWorkspaceExamplePool bounds how many categorized rows are loaded as candidates for example selection.
+	maxWorkspaceExamplePool = 2000
+	exampleLookbackMonths   = 12
+)
 
 type UploadService struct {
 	db              *gorm.DB
 	categoryService *CategoryService
+	ruleService     *CategoryRuleService
 }
 
-func NewUploadService(db *gorm.DB, categoryService *CategoryService) *UploadService {
-	return &UploadService{db: db, categoryService: categoryService}
+func NewUploadService(db *gorm.DB, categoryService *CategoryService, ruleService *CategoryRuleService) *UploadService {
+	return &UploadService{db: db, categoryService: categoryService, ruleService: ruleService}
 }
 
 // PreviewTransaction represents a transaction ready for user review
@@ -34,13 +41,20 @@ type PreviewTransaction struct {
 	BalanceAfter float64 `json:"balance_after"`
 	Type         string  `json:"type"`
 	Category     string  `json:"category"`
+	// Confidence is the categorizer's probability (0..1) that Category is correct.
+	Confidence float64            `json:"confidence"`
+	Reason     string             `json:"reason"`
+	Source     categorizer.Source `json:"source"`
+	// NeedsReview marks rows that fell back to a default category or were predicted as Missing.
+	NeedsReview bool `json:"needs_review"`
 }
 
 // UploadPreviewSummary contains summary statistics for the upload
 type UploadPreviewSummary struct {
-	TotalCount  int     `json:"total_count"`
-	TotalDebit  float64 `json:"total_debit"`
-	TotalCredit float64 `json:"total_credit"`
+	TotalCount       int     `json:"total_count"`
+	TotalDebit       float64 `json:"total_debit"`
+	TotalCredit      float64 `json:"total_credit"`
+	NeedsReviewCount int     `json:"needs_review_count"`
 }
 
 // UploadPreview is the response for the upload endpoint
@@ -48,6 +62,11 @@ type UploadPreview struct {
 	Transactions      []PreviewTransaction `json:"transactions"`
 	Summary           UploadPreviewSummary `json:"summary"`
 	AllowedCategories []string             `json:"allowed_categories"`
+	ModelVersion      string               `json:"model_version"`
+	ExampleStrategy   string               `json:"example_strategy"`
+	ExamplePoolSize   int                  `json:"example_pool_size"`
+	// FileName is the uploaded statement's name, echoed back on confirm to label the import batch.
+	FileName string `json:"file_name"`
 }
 
 // ProcessUpload processes a PDF file through OCR and workspace-aware categorization.
@@ -61,9 +80,10 @@ func (s *UploadService) ProcessUpload(workspaceID uint, filePath string) (*Uploa
 		return nil, fmt.Errorf("failed to list categories: %w", err)
 	}
 
+	// Predictions target leaf categories; reports roll them up to parents as needed.
 	allowedCategories := make([]string, 0, len(categories))
-	for _, c := range categories {
-		n := strings.TrimSpace(c.Name)
+	for _, name := range leafNames(categories) {
+		n := strings.TrimSpace(name)
 		if n != "" {
 			allowedCategories = append(allowedCategories, n)
 		}
@@ -83,23 +103,47 @@ func (s *UploadService) ProcessUpload(workspaceID uint, filePath string) (*Uploa
 		}, nil
 	}
 
-	labeledExamples, err := s.loadLabeledExamplesForUpload(workspaceID)
+	selector, err := exampleSelectorFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	examplePool, err := s.loadLabeledExamplesForUpload(workspaceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load categorized history: %w", err)
 	}
+	batchOptions, err := batchOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.ruleService.Rules(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load category rules: %w", err)
+	}
 
-	predictedCategories, err := categorizer.CategorizeWithWorkspaceExamples(client, *transactions, labeledExamples, allowedCategories)
+	// Workspace rules label what they can; only the remaining rows are sent to the model.
+	backend := categorizer.Chain{
+		categorizer.RuleCategorizer{Rules: rules},
+		categorizer.LLMCategorizer{Client: client, Selector: selector, Batch: batchOptions},
+	}
+	predictions, err := backend.Categorize(*transactions, examplePool, allowedCategories)
 	if err != nil {
 		return nil, fmt.Errorf("categorization failed: %w", err)
 	}
 
 	preview := make([]PreviewTransaction, len(*transactions))
 	var totalDebit, totalCredit float64
+	needsReviewCount := 0
 
 	for i, tx := range *transactions {
-		category := ""
-		if i < len(predictedCategories) {
-			category = predictedCategories[i]
+		var prediction categorizer.Prediction
+		if i < len(predictions) {
+			prediction = predictions[i]
+		}
+		needsReview := prediction.IsFallback() || prediction.Category == models.MissingCategoryName || prediction.Category == ""
+		if needsReview {
+			needsReviewCount++
 		}
 
 		amount := tx.Amount.Float64
@@ -116,28 +160,65 @@ func (s *UploadService) ProcessUpload(workspaceID uint, filePath string) (*Uploa
 			Amount:       amount,
 			BalanceAfter: tx.BalanceAfter.Float64,
 			Type:         tx.Type.String,
-			Category:     category,
+			Category:     prediction.Category,
+			Confidence:   prediction.Confidence,
+			Reason:       prediction.Reason,
+			Source:       prediction.Source,
+			NeedsReview:  needsReview,
 		}
 	}
 
 	return &UploadPreview{
 		Transactions: preview,
 		Summary: UploadPreviewSummary{
-			TotalCount:  len(preview),
-			TotalDebit:  totalDebit,
-			TotalCredit: totalCredit,
+			TotalCount:       len(preview),
+			TotalDebit:       totalDebit,
+			TotalCredit:      totalCredit,
+			NeedsReviewCount: needsReviewCount,
 		},
 		AllowedCategories: allowedCategories,
+		ModelVersion:      categorizer.ModelVersion(selector.Name()),
+		ExampleStrategy:   selector.Name(),
+		ExamplePoolSize:   len(examplePool),
 	}, nil
 }
 
+// exampleSelectorFromEnv reads CATEGORIZER_EXAMPLE_STRATEGY (similar | recent) and CATEGORIZER_EXAMPLE_TOKEN_BUDGET.
+func exampleSelectorFromEnv() (categorizer.ExampleSelector, error) {
+	strategy := configs.GetEnvOrDefault("CATEGORIZER_EXAMPLE_STRATEGY", categorizer.ExampleStrategySimilar)
+	budget, err := strconv.Atoi(configs.GetEnvOrDefault("CATEGORIZER_EXAMPLE_TOKEN_BUDGET", strconv.Itoa(categorizer.DefaultExampleTokenBudget)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CATEGORIZER_EXAMPLE_TOKEN_BUDGET: %w", err)
+	}
+	return categorizer.NewExampleSelector(strategy, budget)
+}
+
+// batchOptionsFromEnv reads CATEGORIZER_BATCH_SIZE and CATEGORIZER_CONCURRENCY, keeping defaults when unset.
+func batchOptionsFromEnv() (categorizer.BatchOptions, error) {
+	opts := categorizer.DefaultBatchOptions()
+	batchSize, err := strconv.Atoi(configs.GetEnvOrDefault("CATEGORIZER_BATCH_SIZE", strconv.Itoa(opts.BatchSize)))
+	if err != nil {
+		return opts, fmt.Errorf("invalid CATEGORIZER_BATCH_SIZE: %w", err)
+	}
+	concurrency, err := strconv.Atoi(configs.GetEnvOrDefault("CATEGORIZER_CONCURRENCY", strconv.Itoa(opts.Concurrency)))
+	if err != nil {
+		return opts, fmt.Errorf("invalid CATEGORIZER_CONCURRENCY: %w", err)
+	}
+	opts.BatchSize = batchSize
+	opts.Concurrency = concurrency
+	return opts, nil
+}
+
+// loadLabeledExamplesForUpload returns the candidate pool for example selection: categorized rows from the
+// last exampleLookbackMonths, newest first, falling back to the newest rows overall for dormant workspaces.
 func (s *UploadService) loadLabeledExamplesForUpload(workspaceID uint) ([]trainingcsv.Example, error) {
-	since := time.Now().AddDate(0, -2, 0)
+	since := time.Now().AddDate(0, -exampleLookbackMonths, 0)
 	var recent []models.Transaction
-	err := s.db.Where("workspace_id = ? AND date >= ?", workspaceID, since).
-		Where("category IS NOT NULL AND category != ?", "").
+	err := s.db.Preload("CategoryRef").
+		Where("workspace_id = ? AND date >= ?", workspaceID, since).
+		Where("category_id IS NOT NULL").
 		Order("date DESC").
-		Limit(maxWorkspaceLabeledExamples).
+		Limit(maxWorkspaceExamplePool).
 		Find(&recent).Error
 	if err != nil {
 		return nil, err
@@ -147,10 +228,11 @@ func (s *UploadService) loadLabeledExamplesForUpload(workspaceID uint) ([]traini
 	}
 
 	var fallback []models.Transaction
-	err = s.db.Where("workspace_id = ?", workspaceID).
-		Where("category IS NOT NULL AND category != ?", "").
+	err = s.db.Preload("CategoryRef").
+		Where("workspace_id = ?", workspaceID).
+		Where("category_id IS NOT NULL").
 		Order("date DESC").
-		Limit(30).
+		Limit(maxWorkspaceExamplePool).
 		Find(&fallback).Error
 	if err != nil {
 		return nil, err
@@ -176,17 +258,25 @@ func transactionsToExamples(rows []models.Transaction) []trainingcsv.Example {
 	return out
 }
 
-// ConfirmTransactionInput is the input for confirming a transaction
+// ConfirmTransactionInput is the input for confirming a transaction.
+// The Predicted* fields echo what the preview suggested so corrections can be recorded as feedback.
 type ConfirmTransactionInput struct {
-	Date        string  `json:"date"`
-	Description string  `json:"description"`
-	Amount      float64 `json:"amount"`
-	Type        string  `json:"type"`
-	Category    string  `json:"category"`
+	Date                 string  `json:"date"`
+	Description          string  `json:"description"`
+	Amount               float64 `json:"amount"`
+	Type                 string  `json:"type"`
+	Category             string  `json:"category"`
+	PredictedCategory    string  `json:"predicted_category"`
+	PredictionSource     string  `json:"prediction_source"`
+	PredictionConfidence float64 `json:"prediction_confidence"`
+	ModelVersion         string  `json:"model_version"`
 }
 
-// ConfirmTransactions saves the confirmed transactions to the database
-func (s *UploadService) ConfirmTransactions(workspaceID uint, transactions []ConfirmTransactionInput) (int, error) {
+// ConfirmTransactions saves the confirmed transactions to the database, together with prediction feedback
+// for every row that carried a predicted category.
+// Rows from a statement upload (batch.FileName set) are recorded under batch, which is created here; its ID
+// and RowCount are filled in on success. Rows confirmed by hand get batch's account but no import batch.
+func (s *UploadService) ConfirmTransactions(workspaceID uint, batch *models.ImportBatch, transactions []ConfirmTransactionInput) (int, error) {
 	if len(transactions) == 0 {
 		return 0, nil
 	}
@@ -209,10 +299,95 @@ func (s *UploadService) ConfirmTransactions(workspaceID uint, transactions []Con
 		})
 	}
 
-	result := s.db.Create(&models_txns)
-	if result.Error != nil {
-		return 0, fmt.Errorf("failed to save transactions: %w", result.Error)
+	var created int64
+	err := s.db.Transaction(func(db *gorm.DB) error {
+		categories := newCategoryResolver(db, workspaceID)
+		for i := range models_txns {
+			id, err := categories.resolve(models_txns[i].Category.String)
+			if err != nil {
+				return err
+			}
+			models_txns[i].CategoryID = id
+		}
+
+		if err := validateAccountID(db, workspaceID, batch.AccountID); err != nil {
+			return err
+		}
+		var batchID *uint
+		if strings.TrimSpace(batch.FileName) != "" {
+			batch.WorkspaceID = workspaceID
+			batch.RowCount = len(models_txns)
+			if err := db.Create(batch).Error; err != nil {
+				return err
+			}
+			batchID = &batch.ID
+		}
+		for i := range models_txns {
+			models_txns[i].ImportBatchID = batchID
+			models_txns[i].AccountID = batch.AccountID
+		}
+
+		result := db.Create(&models_txns)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected
+
+		feedback := buildCategorizationFeedback(workspaceID, transactions, models_txns)
+		if len(feedback) > 0 {
+			if err := db.Create(&feedback).Error; err != nil {
+				return err
+			}
+		}
+		return evaluateBudgetAlerts(db, workspaceID, models_txns...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save transactions: %w", err)
 	}
 
-	return int(result.RowsAffected), nil
+	return int(created), nil
+}
+
+// SetStatementBalance records the total due of a card statement import; nil clears it.
+func (s *UploadService) SetStatementBalance(id, workspaceID uint, balance *float64) (*models.ImportBatch, error) {
+	var batch models.ImportBatch
+	if err := s.db.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	batch.StatementBalance = balance
+	if err := s.db.Model(&batch).Update("statement_balance", balance).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListImportBatches returns the workspace's import batches, newest first.
+func (s *UploadService) ListImportBatches(workspaceID uint) ([]models.ImportBatch, error) {
+	var batches []models.ImportBatch
+	err := s.db.Where("workspace_id = ?", workspaceID).Order("created_at DESC, id DESC").Find(&batches).Error
+	return batches, err
+}
+
+// buildCategorizationFeedback pairs each confirmed input with its created row. Rows without a prediction
+// (e.g. manually added in the preview) are skipped.
+func buildCategorizationFeedback(workspaceID uint, inputs []ConfirmTransactionInput, created []models.Transaction) []models.CategorizationFeedback {
+	feedback := make([]models.CategorizationFeedback, 0, len(inputs))
+	for i, in := range inputs {
+		predicted := strings.TrimSpace(in.PredictedCategory)
+		if predicted == "" || i >= len(created) {
+			continue
+		}
+		final := strings.TrimSpace(in.Category)
+		feedback = append(feedback, models.CategorizationFeedback{
+			WorkspaceID:       workspaceID,
+			TransactionID:     created[i].ID,
+			PredictedCategory: predicted,
+			FinalCategory:     final,
+			Source:            in.PredictionSource,
+			Confidence:        in.PredictionConfidence,
+			ModelVersion:      in.ModelVersion,
+			Correct:           predicted == final,
+		})
+	}
+	return feedback
 }
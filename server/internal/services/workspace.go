@@ -86,8 +86,22 @@ func (s *WorkspaceService) GetMembers(workspaceID uint) ([]models.WorkspaceMembe
 	return members, err
 }
 
+// RemoveMember removes the user from the workspace; an owner linked to them becomes an external person.
 func (s *WorkspaceService) RemoveMember(workspaceID, userID uint) error {
-	return s.db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).Delete(&models.WorkspaceMember{}).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var member models.WorkspaceMember
+		err := tx.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Owner{}).Where("member_id = ?", member.ID).Update("member_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&member).Error
+	})
 }
 
 func (s *WorkspaceService) CreateInvite(workspaceID, invitedBy uint, email, role string) (*models.WorkspaceInvite, error) {
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type fieldKind int
@@ -38,6 +39,20 @@ type Example struct {
 	Category     string  `json:"category"`
 }
 
+// dateLayouts are the date formats found in the training CSVs: ISO exports and bank-style day-first dates.
+var dateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", time.RFC3339}
+
+// ParseDate parses an example's date column in any of dateLayouts.
+func ParseDate(raw string) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", raw)
+}
+
 func LoadTrainingData(dir string) (Schema, []Example, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {